@@ -1,35 +1,50 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"spl-notification/internal/api/controller"
 	"spl-notification/internal/api/middleware"
 	"spl-notification/internal/config"
 	"spl-notification/internal/database"
+	"spl-notification/internal/queue"
 	"spl-notification/internal/repository"
 	"spl-notification/internal/server"
 	"spl-notification/internal/service"
-	"time"
 
-	"github.com/go-co-op/gocron/v2"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/fx"
 )
 
 func main() {
+	// Loaded before the app so the shutdown timeout can be applied to fx
+	envConfig := config.NewEnviromentConfig()
+
 	fx.New(
+		fx.StopTimeout(envConfig.ShutdownTimeout),
+		fx.Supply(envConfig),
 		fx.Provide(
-			// Setup environment config
-			config.NewEnviromentConfig,
 			NewValidator,
 			// Database connection
 			database.CreateTursoConnection,
+			// Notification queue
+			queue.NewNotificationQueue,
 			// Middleware
 			middleware.NewAuthMiddleware,
 			// Controllers
 			controller.NewMainController,
 			controller.NewTrackController,
+			controller.NewNotificationController,
+			controller.NewStatsController,
+			controller.NewPreferencesController,
+			controller.NewLocationController,
+			controller.NewDeadLetterController,
+			controller.NewAccessController,
+			controller.NewApiKeyController,
+			controller.NewAuditController,
 			// Services
+			service.NewCircuitBreakers,
+			service.NewRequestVerifier,
 			fx.Annotate(
 				service.NewAccessServiceImpl,
 				fx.As(new(service.AccessService)),
@@ -46,48 +61,137 @@ func main() {
 				service.NewSourceServiceImpl,
 				fx.As(new(service.SourceService)),
 			),
+			fx.Annotate(
+				service.NewOutboxServiceImpl,
+				fx.As(new(service.OutboxService)),
+			),
+			fx.Annotate(
+				service.NewStatsServiceImpl,
+				fx.As(new(service.StatsService)),
+			),
+			fx.Annotate(
+				service.NewPreferencesServiceImpl,
+				fx.As(new(service.PreferencesService)),
+			),
+			fx.Annotate(
+				service.NewLocationServiceImpl,
+				fx.As(new(service.LocationService)),
+			),
+			fx.Annotate(
+				service.NewApiKeyServiceImpl,
+				fx.As(new(service.ApiKeyService)),
+			),
+			fx.Annotate(
+				service.NewAuditServiceImpl,
+				fx.As(new(service.AuditService)),
+			),
+			fx.Annotate(
+				service.NewSchedulerServiceImpl,
+				fx.As(new(service.SchedulerService)),
+			),
+			fx.Annotate(
+				service.NewConsumerServiceImpl,
+				fx.As(new(service.ConsumerService)),
+			),
 			// Setup Repositories
+			// The database repository is only used through the cache
 			fx.Annotate(
 				repository.NewTrackRepositoryImpl,
+				fx.ResultTags(`name:"trackRepositoryDB"`),
+			),
+			fx.Annotate(
+				repository.NewCachedTrackRepositoryImpl,
+				fx.ParamTags(`name:"trackRepositoryDB"`),
 				fx.As(new(repository.TrackRepository)),
+				fx.As(new(repository.CachedTrackRepository)),
+			),
+			fx.Annotate(
+				repository.NewOutboxRepositoryImpl,
+				fx.As(new(repository.OutboxRepository)),
+			),
+			fx.Annotate(
+				repository.NewDeadLetterRepositoryImpl,
+				fx.As(new(repository.DeadLetterRepository)),
+			),
+			fx.Annotate(
+				repository.NewNotificationDeliveryRepositoryImpl,
+				fx.As(new(repository.NotificationDeliveryRepository)),
+			),
+			fx.Annotate(
+				repository.NewNotificationLogRepositoryImpl,
+				fx.As(new(repository.NotificationLogRepository)),
+			),
+			fx.Annotate(
+				repository.NewAccessEventRepositoryImpl,
+				fx.As(new(repository.AccessEventRepository)),
+			),
+			fx.Annotate(
+				repository.NewChatPreferencesRepositoryImpl,
+				fx.As(new(repository.ChatPreferencesRepository)),
+			),
+			fx.Annotate(
+				repository.NewLocationRepositoryImpl,
+				fx.As(new(repository.LocationRepository)),
+			),
+			fx.Annotate(
+				repository.NewAccessCursorRepositoryImpl,
+				fx.As(new(repository.AccessCursorRepository)),
+			),
+			fx.Annotate(
+				repository.NewApiKeyRepositoryImpl,
+				fx.As(new(repository.ApiKeyRepository)),
+			),
+			fx.Annotate(
+				repository.NewAuditEventRepositoryImpl,
+				fx.As(new(repository.AuditEventRepository)),
 			),
 		),
+		// Load location catalogue
+		fx.Invoke(func(lc fx.Lifecycle, locationService service.LocationService) {
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					if err := locationService.Load(ctx); err != nil {
+						return fmt.Errorf("error loading locations: %w", err)
+					}
+					return nil
+				},
+			})
+		}),
 		// Setup Server
 		fx.Invoke(server.CreateFiberServer),
-		// Start Pub/Sub notification consumer
-		fx.Invoke(func(notificationService service.NotificationService) {
-			go notificationService.HandleNotification()
-		}),
-		fx.Invoke(func(accessService service.AccessService) {
-			s, err := gocron.NewScheduler()
-			if err != nil {
-				fmt.Println("Error creating scheduler:", err)
-				return
-			}
-
-			s.NewJob(
-				gocron.DurationJob(5*time.Second),
-				gocron.NewTask(func() {
-					accesses, err := accessService.GetCompleteAccess()
-					if err != nil {
-						fmt.Println("[CRON] Error fetching accesses:", err)
-						return
+		// Start notification consumer and scheduled jobs. Hooks stop in reverse
+		// order: the jobs are drained first, then the consumer and the server.
+		fx.Invoke(func(lc fx.Lifecycle, consumerService service.ConsumerService) {
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					if err := consumerService.Start(); err != nil {
+						return err
 					}
-
-					if len(accesses) == 0 {
-						return
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					if err := consumerService.Stop(ctx); err != nil {
+						return err
 					}
-
-					err = accessService.CheckAccess(accesses)
-					if err != nil {
-						fmt.Println("[CRON] Error checking accesses:", err)
-						return
+					return nil
+				},
+			})
+		}),
+		fx.Invoke(func(lc fx.Lifecycle, schedulerService service.SchedulerService) {
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					if err := schedulerService.Start(); err != nil {
+						return err
 					}
-				}),
-				gocron.WithSingletonMode(gocron.LimitModeWait),
-			)
-
-			s.Start()
+					return nil
+				},
+				OnStop: func(ctx context.Context) error {
+					if err := schedulerService.Stop(ctx); err != nil {
+						return err
+					}
+					return nil
+				},
+			})
 		}),
 	).Run()
 }
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"spl-notification/internal/dto/response"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxAccessEvents bounds the events accepted in a single push.
+const maxAccessEvents = 1000
+
+type AccessController struct {
+	accessService service.AccessService
+	validation    *validator.Validate
+}
+
+func NewAccessController(
+	accessService service.AccessService,
+	validation *validator.Validate,
+) *AccessController {
+	return &AccessController{
+		accessService: accessService,
+		validation:    validation,
+	}
+}
+
+// IngestEvents receives access events pushed by the access service, either a
+// single event or an array of them.
+func (a *AccessController) IngestEvents(c *fiber.Ctx) error {
+	events, err := parseAccessEvents(c.Body())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if len(events) > maxAccessEvents {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("at most %d events are accepted per request", maxAccessEvents),
+		})
+	}
+
+	for i, event := range events {
+		if event == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("event %d: empty event", i),
+			})
+		}
+		if err := a.validation.Struct(event); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("event %d: %s", i, err.Error()),
+			})
+		}
+	}
+
+	accepted, changes, appErr := a.accessService.IngestAccesses(c.UserContext(), events)
+	if appErr != nil {
+		if appErr.HasType(errors.TypeValidation) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": appErr.Err.Error(),
+			})
+		}
+		return errors.InternalError(c, appErr)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"received": len(events),
+		"accepted": accepted,
+		"changes":  changes,
+	})
+}
+
+func parseAccessEvents(body []byte) ([]*response.AccessDTO, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+
+	if body[0] == '[' {
+		var events []*response.AccessDTO
+		if err := json.Unmarshal(body, &events); err != nil {
+			return nil, err
+		}
+		return events, nil
+	}
+
+	var event response.AccessDTO
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return []*response.AccessDTO{&event}, nil
+}
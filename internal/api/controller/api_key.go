@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ApiKeyController struct {
+	apiKeyService service.ApiKeyService
+	validation    *validator.Validate
+}
+
+func NewApiKeyController(
+	apiKeyService service.ApiKeyService,
+	validation *validator.Validate,
+) *ApiKeyController {
+	return &ApiKeyController{
+		apiKeyService: apiKeyService,
+		validation:    validation,
+	}
+}
+
+func (a *ApiKeyController) GetApiKeys(c *fiber.Ctx) error {
+	apiKeys, err := a.apiKeyService.GetAll(c.UserContext())
+	if err != nil {
+		return errors.InternalError(c, err)
+	}
+
+	if len(apiKeys) == 0 {
+		apiKeys = []*model.ApiKey{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": apiKeys,
+	})
+}
+
+// CreateApiKey returns the plain key, which is only shown once.
+func (a *ApiKeyController) CreateApiKey(c *fiber.Ctx) error {
+	var apiKeyDTO request.CreateApiKeyDTO
+	if err := c.BodyParser(&apiKeyDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := a.validation.Struct(apiKeyDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	apiKey, key, err := a.apiKeyService.Create(c.UserContext(), &apiKeyDTO)
+	if err != nil {
+		if err.HasType(errors.TypeValidation) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Err.Error(),
+			})
+		}
+		return errors.InternalError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": apiKey,
+		"key":  key,
+	})
+}
+
+func (a *ApiKeyController) RevokeApiKey(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id parameter",
+		})
+	}
+
+	if err := a.apiKeyService.Revoke(c.UserContext(), int64(id)); err != nil {
+		switch {
+		case err.HasType(errors.TypeNotFound):
+			return c.SendStatus(fiber.StatusNotFound)
+		case err.HasType(errors.TypeValidation):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Err.Error(),
+			})
+		default:
+			return errors.InternalError(c, err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditController struct {
+	auditService service.AuditService
+}
+
+func NewAuditController(
+	auditService service.AuditService,
+) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+func (a *AuditController) GetAuditEvents(c *fiber.Ctx) error {
+	filter := &request.AuditFilterDTO{
+		Limit: c.QueryInt("limit", defaultHistoryLimit),
+	}
+	if filter.Limit <= 0 || filter.Limit > maxHistoryLimit {
+		filter.Limit = defaultHistoryLimit
+	}
+	if chatId := c.Query("chatId"); chatId != "" {
+		filter.ChatID = &chatId
+	}
+
+	var err error
+	if filter.From, err = parseDateQuery(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid from parameter",
+		})
+	}
+	if filter.To, err = parseDateQuery(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid to parameter",
+		})
+	}
+
+	events, appErr := a.auditService.GetEvents(c.UserContext(), filter)
+	if appErr != nil {
+		return errors.InternalError(c, appErr)
+	}
+
+	if len(events) == 0 {
+		events = []*model.AuditEvent{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": events,
+	})
+}
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"spl-notification/internal/api/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// canAccessChat reports whether the caller attached by the auth middleware
+// may act on chatId. Requests without a caller are refused.
+func canAccessChat(c *fiber.Ctx, chatId string) bool {
+	caller := middleware.CallerFrom(c)
+	return caller != nil && caller.CanAccessChat(chatId)
+}
+
+func chatForbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "chat not allowed for this client",
+	})
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeadLetterController struct {
+	notificationService service.NotificationService
+}
+
+func NewDeadLetterController(
+	notificationService service.NotificationService,
+) *DeadLetterController {
+	return &DeadLetterController{
+		notificationService: notificationService,
+	}
+}
+
+func (d *DeadLetterController) GetDeadLetters(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", defaultHistoryLimit)
+	if limit <= 0 || limit > maxHistoryLimit {
+		limit = defaultHistoryLimit
+	}
+
+	deadLetters, err := d.notificationService.GetDeadLetters(c.UserContext(), c.QueryBool("replayed", false), limit)
+	if err != nil {
+		return errors.InternalError(c, err)
+	}
+
+	if len(deadLetters) == 0 {
+		deadLetters = []*model.DeadLetter{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": deadLetters,
+	})
+}
+
+func (d *DeadLetterController) ReplayDeadLetter(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil || id <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid id parameter",
+		})
+	}
+
+	if err := d.notificationService.ReplayDeadLetter(c.UserContext(), int64(id)); err != nil {
+		switch {
+		case err.HasType(errors.TypeNotFound):
+			return c.SendStatus(fiber.StatusNotFound)
+		case err.HasType(errors.TypeValidation):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Err.Error(),
+			})
+		default:
+			return errors.InternalError(c, err)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusAccepted)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type LocationController struct {
+	locationService service.LocationService
+	validation      *validator.Validate
+}
+
+func NewLocationController(
+	locationService service.LocationService,
+	validation *validator.Validate,
+) *LocationController {
+	return &LocationController{
+		locationService: locationService,
+		validation:      validation,
+	}
+}
+
+func (l *LocationController) GetAllLocations(c *fiber.Ctx) error {
+	locations, err := l.locationService.GetAll(c.UserContext())
+	if err != nil {
+		return errors.InternalError(c, err)
+	}
+
+	if len(locations) == 0 {
+		locations = []*model.Location{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data":         locations,
+		"unknownCodes": l.locationService.UnknownCodes(),
+	})
+}
+
+func (l *LocationController) CreateLocation(c *fiber.Ctx) error {
+	var locationDTO request.LocationDTO
+	if err := c.BodyParser(&locationDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := l.validation.Struct(locationDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := l.locationService.Create(c.UserContext(), &locationDTO); err != nil {
+		return l.locationError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+func (l *LocationController) UpdateLocation(c *fiber.Ctx) error {
+	code, err := c.ParamsInt("code")
+	if err != nil || code <= 0 || code > 127 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid code parameter",
+		})
+	}
+
+	var locationDTO request.LocationDTO
+	if err := c.BodyParser(&locationDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	locationDTO.Code = int8(code)
+
+	if err := l.validation.Struct(locationDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := l.locationService.Update(c.UserContext(), &locationDTO); err != nil {
+		return l.locationError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (l *LocationController) DeleteLocation(c *fiber.Ctx) error {
+	code, err := c.ParamsInt("code")
+	if err != nil || code <= 0 || code > 127 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid code parameter",
+		})
+	}
+
+	if err := l.locationService.Delete(c.UserContext(), int8(code)); err != nil {
+		return l.locationError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (l *LocationController) locationError(c *fiber.Ctx, err *errors.AppError) error {
+	switch {
+	case err.HasType(errors.TypeNotFound):
+		return c.SendStatus(fiber.StatusNotFound)
+	case err.HasType(errors.TypeConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	case err.HasType(errors.TypeValidation):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	default:
+		return errors.InternalError(c, err)
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/service"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubLocationService fails the changes with the configured errors.
+type stubLocationService struct {
+	service.LocationService
+	err *apperrors.AppError
+}
+
+func (s stubLocationService) Create(ctx context.Context, locationDTO *request.LocationDTO) *apperrors.AppError {
+	return s.err
+}
+
+func (s stubLocationService) Update(ctx context.Context, locationDTO *request.LocationDTO) *apperrors.AppError {
+	return s.err
+}
+
+func (s stubLocationService) Delete(ctx context.Context, code int8) *apperrors.AppError {
+	return s.err
+}
+
+func newLocationTestApp(err *apperrors.AppError) *fiber.App {
+	locationController := NewLocationController(
+		stubLocationService{err: err},
+		validator.New(validator.WithRequiredStructEnabled()),
+	)
+
+	app := fiber.New()
+	app.Post("/location", locationController.CreateLocation)
+	app.Put("/location/:code", locationController.UpdateLocation)
+	app.Delete("/location/:code", locationController.DeleteLocation)
+	return app
+}
+
+func TestLocationController_ErrorStatus(t *testing.T) {
+	notFound := apperrors.NewAppErrorWithType("LocationService", apperrors.TypeNotFound, errors.New("location 110 not found"))
+	conflict := apperrors.NewAppErrorWithType("LocationRepository", apperrors.TypeConflict, errors.New("location 102 already exists"))
+	invalid := apperrors.NewAppErrorWithType("LocationService", apperrors.TypeValidation, errors.New("unknown time zone Mars/Olympus"))
+	failed := apperrors.NewAppError("LocationRepository", errors.New("database unavailable"))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		err    *apperrors.AppError
+		status int
+	}{
+		{"created", "POST", "/location", `{"code":110,"name":"Antofagasta"}`, nil, http.StatusCreated},
+		{"duplicate code", "POST", "/location", `{"code":102,"name":"Espacio Urbano"}`, conflict, http.StatusConflict},
+		{"invalid time zone", "POST", "/location", `{"code":110,"name":"Antofagasta","timeZone":"Mars/Olympus"}`, invalid, http.StatusBadRequest},
+		{"failed create", "POST", "/location", `{"code":110,"name":"Antofagasta"}`, failed, http.StatusInternalServerError},
+		{"updated", "PUT", "/location/102", `{"name":"Espacio Urbano"}`, nil, http.StatusOK},
+		{"update unknown code", "PUT", "/location/110", `{"name":"Antofagasta"}`, notFound, http.StatusNotFound},
+		{"deleted", "DELETE", "/location/102", "", nil, http.StatusOK},
+		{"delete unknown code", "DELETE", "/location/110", "", notFound, http.StatusNotFound},
+		{"delete invalid code", "DELETE", "/location/abc", "", nil, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := newLocationTestApp(test.err)
+			assert.Equal(t, test.status, doRequest(t, app, test.method, test.target, test.body))
+		})
+	}
+}
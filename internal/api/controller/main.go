@@ -1,14 +1,42 @@
 package controller
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
+	"spl-notification/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
 
 type MainController struct {
+	trackRepository repository.CachedTrackRepository
+	circuitBreakers *service.CircuitBreakers
 }
 
-func NewMainController() *MainController {
-	return &MainController{}
+func NewMainController(
+	trackRepository repository.CachedTrackRepository,
+	circuitBreakers *service.CircuitBreakers,
+) *MainController {
+	return &MainController{
+		trackRepository: trackRepository,
+		circuitBreakers: circuitBreakers,
+	}
 }
 
+// Health reports "degraded" while a dependency has its circuit breaker open,
+// the service itself keeps answering.
 func (a *MainController) Health(c *fiber.Ctx) error {
-	return c.SendStatus(fiber.StatusOK)
+	status := "ok"
+	circuitBreakers := a.circuitBreakers.Stats()
+	for _, breaker := range circuitBreakers {
+		if breaker.State != model.CircuitStateClosed {
+			status = "degraded"
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":          status,
+		"trackCache":      a.trackRepository.CacheStats(),
+		"circuitBreakers": circuitBreakers,
+	})
 }
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = 100
+)
+
+type NotificationController struct {
+	notificationService service.NotificationService
+}
+
+func NewNotificationController(
+	notificationService service.NotificationService,
+) *NotificationController {
+	return &NotificationController{
+		notificationService: notificationService,
+	}
+}
+
+func (n *NotificationController) GetNotificationHistory(c *fiber.Ctx) error {
+	chatId := c.Params("chatId")
+	if chatId == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "chatId parameter is required",
+		})
+	}
+	if !canAccessChat(c, chatId) {
+		return chatForbidden(c)
+	}
+
+	filter := &request.NotificationHistoryDTO{
+		ChatID: chatId,
+		Limit:  c.QueryInt("limit", defaultHistoryLimit),
+	}
+	if filter.Limit <= 0 || filter.Limit > maxHistoryLimit {
+		filter.Limit = defaultHistoryLimit
+	}
+
+	var err error
+	if filter.From, err = parseDateQuery(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid from parameter",
+		})
+	}
+	if filter.To, err = parseDateQuery(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid to parameter",
+		})
+	}
+
+	if typeQuery := c.Query("type"); typeQuery != "" {
+		notificationType, ok := model.ParseNotificationType(typeQuery)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid type parameter",
+			})
+		}
+		filter.Type = &notificationType
+	}
+
+	logs, appErr := n.notificationService.GetNotificationHistory(c.UserContext(), filter)
+	if appErr != nil {
+		return errors.InternalError(c, appErr)
+	}
+
+	if len(logs) == 0 {
+		logs = []*model.NotificationLog{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": logs,
+	})
+}
+
+// parseDateQuery accepts either a full RFC3339 timestamp or a plain date (UTC).
+// With endOfDay a plain date covers the whole day, so it can be used as an
+// inclusive upper bound.
+func parseDateQuery(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return &t, nil
+}
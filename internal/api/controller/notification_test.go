@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"spl-notification/internal/api/middleware"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// historyNotificationService records the history filter it receives.
+type historyNotificationService struct {
+	service.NotificationService
+	filter *request.NotificationHistoryDTO
+	err    *apperrors.AppError
+}
+
+func (s *historyNotificationService) GetNotificationHistory(ctx context.Context, filter *request.NotificationHistoryDTO) ([]*model.NotificationLog, *apperrors.AppError) {
+	s.filter = filter
+	return nil, s.err
+}
+
+func newNotificationTestApp(notificationService service.NotificationService, caller *model.Caller) *fiber.App {
+	notificationController := NewNotificationController(notificationService)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(middleware.CallerKey, caller)
+		return c.Next()
+	})
+	app.Get("/notification/:chatId", notificationController.GetNotificationHistory)
+	return app
+}
+
+func TestNotificationController_HistoryFilter(t *testing.T) {
+	entry := model.NotificationType(model.NotificationTypeEntry)
+	longStay := model.NotificationType(model.NotificationTypeLongStay)
+	date := func(value string) *time.Time {
+		t, _ := time.Parse(time.RFC3339, value)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected *request.NotificationHistoryDTO
+	}{
+		{"defaults", "", &request.NotificationHistoryDTO{ChatID: "chat1", Limit: defaultHistoryLimit}},
+		{"limit", "?limit=50", &request.NotificationHistoryDTO{ChatID: "chat1", Limit: 50}},
+		{"limit above the maximum", "?limit=500", &request.NotificationHistoryDTO{ChatID: "chat1", Limit: defaultHistoryLimit}},
+		{"negative limit", "?limit=-1", &request.NotificationHistoryDTO{ChatID: "chat1", Limit: defaultHistoryLimit}},
+		{"plain dates cover the whole day", "?from=2025-10-01&to=2025-10-02", &request.NotificationHistoryDTO{
+			ChatID: "chat1", From: date("2025-10-01T00:00:00Z"), To: date("2025-10-02T23:59:59Z"), Limit: defaultHistoryLimit,
+		}},
+		{"timestamps", "?from=2025-10-01T08:00:00Z&to=2025-10-01T10:00:00Z", &request.NotificationHistoryDTO{
+			ChatID: "chat1", From: date("2025-10-01T08:00:00Z"), To: date("2025-10-01T10:00:00Z"), Limit: defaultHistoryLimit,
+		}},
+		{"type name", "?type=ENTRY", &request.NotificationHistoryDTO{ChatID: "chat1", Type: &entry, Limit: defaultHistoryLimit}},
+		{"type value", "?type=3", &request.NotificationHistoryDTO{ChatID: "chat1", Type: &longStay, Limit: defaultHistoryLimit}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notificationService := &historyNotificationService{}
+			app := newNotificationTestApp(notificationService, &model.Caller{Name: "admin"})
+
+			assert.Equal(t, http.StatusOK, doRequest(t, app, "GET", "/notification/chat1"+test.query, ""))
+			require.NotNil(t, notificationService.filter)
+			assert.Equal(t, test.expected.Limit, notificationService.filter.Limit)
+			assert.Equal(t, test.expected.Type, notificationService.filter.Type)
+			assert.Equal(t, test.expected.From, notificationService.filter.From)
+			assert.Equal(t, test.expected.To, notificationService.filter.To)
+			assert.Equal(t, test.expected.ChatID, notificationService.filter.ChatID)
+		})
+	}
+}
+
+func TestNotificationController_HistoryErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		caller *model.Caller
+		err    *apperrors.AppError
+		status int
+	}{
+		{"invalid from", "/notification/chat1?from=yesterday", &model.Caller{Name: "admin"}, nil, http.StatusBadRequest},
+		{"invalid to", "/notification/chat1?to=2025-13-01", &model.Caller{Name: "admin"}, nil, http.StatusBadRequest},
+		{"invalid type", "/notification/chat1?type=VISIT", &model.Caller{Name: "admin"}, nil, http.StatusBadRequest},
+		{"other chat", "/notification/chat2", &model.Caller{Name: "bot", ChatIDs: []string{"chat1"}}, nil, http.StatusForbidden},
+		{"failed query", "/notification/chat1", &model.Caller{Name: "admin"}, apperrors.NewAppError("TestError", errors.New("database unavailable")), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			notificationService := &historyNotificationService{err: test.err}
+			app := newNotificationTestApp(notificationService, test.caller)
+
+			assert.Equal(t, test.status, doRequest(t, app, "GET", test.target, ""))
+			if test.err == nil {
+				assert.Nil(t, notificationService.filter)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PreferencesController struct {
+	preferencesService service.PreferencesService
+	validation         *validator.Validate
+}
+
+func NewPreferencesController(
+	preferencesService service.PreferencesService,
+	validation *validator.Validate,
+) *PreferencesController {
+	return &PreferencesController{
+		preferencesService: preferencesService,
+		validation:         validation,
+	}
+}
+
+func (p *PreferencesController) GetPreferences(c *fiber.Ctx) error {
+	chatId := c.Params("chatId")
+	if chatId == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "chatId parameter is required",
+		})
+	}
+	if !canAccessChat(c, chatId) {
+		return chatForbidden(c)
+	}
+
+	preferences, err := p.preferencesService.GetPreferences(c.UserContext(), chatId)
+	if err != nil {
+		return errors.InternalError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": preferences,
+	})
+}
+
+func (p *PreferencesController) UpdatePreferences(c *fiber.Ctx) error {
+	chatId := c.Params("chatId")
+	if chatId == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "chatId parameter is required",
+		})
+	}
+	if !canAccessChat(c, chatId) {
+		return chatForbidden(c)
+	}
+
+	var preferencesDTO request.UpdatePreferencesDTO
+	if err := c.BodyParser(&preferencesDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	preferencesDTO.ChatID = chatId
+
+	if err := p.validation.Struct(preferencesDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	preferences, err := p.preferencesService.UpdatePreferences(c.UserContext(), &preferencesDTO)
+	if err != nil {
+		if err.HasType(errors.TypeValidation) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Err.Error(),
+			})
+		}
+		return errors.InternalError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": preferences,
+	})
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const maxStatsDays = 365
+
+type StatsController struct {
+	statsService service.StatsService
+}
+
+func NewStatsController(
+	statsService service.StatsService,
+) *StatsController {
+	return &StatsController{
+		statsService: statsService,
+	}
+}
+
+func (s *StatsController) GetStats(c *fiber.Ctx) error {
+	chatId := c.Params("chatId")
+	if chatId == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "chatId parameter is required",
+		})
+	}
+	if !canAccessChat(c, chatId) {
+		return chatForbidden(c)
+	}
+
+	// Zero uses the STATS_WINDOW_DAYS default
+	days := c.QueryInt("days", 0)
+	if days <= 0 || days > maxStatsDays {
+		days = 0
+	}
+
+	stats, err := s.statsService.GetStatsByChatId(c.UserContext(), chatId, days)
+	if err != nil {
+		return errors.InternalError(c, err)
+	}
+
+	if len(stats) == 0 {
+		stats = []*model.VisitStats{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": stats,
+	})
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"spl-notification/internal/api/middleware"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// daysStatsService records the window it receives.
+type daysStatsService struct {
+	service.StatsService
+	days int
+}
+
+func (s *daysStatsService) GetStatsByChatId(ctx context.Context, chatId string, days int) ([]*model.VisitStats, *apperrors.AppError) {
+	s.days = days
+	return nil, nil
+}
+
+func TestStatsController_Days(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{"default", "", 0},
+		{"days", "?days=90", 90},
+		{"days above the maximum", "?days=100000", 0},
+		{"negative days", "?days=-1", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statsService := &daysStatsService{days: -1}
+			statsController := NewStatsController(statsService)
+
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				c.Locals(middleware.CallerKey, &model.Caller{Name: "admin"})
+				return c.Next()
+			})
+			app.Get("/track/:chatId/stats", statsController.GetStats)
+
+			assert.Equal(t, http.StatusOK, doRequest(t, app, "GET", "/track/chat1/stats"+test.query, ""))
+			assert.Equal(t, test.expected, statsService.days)
+		})
+	}
+}
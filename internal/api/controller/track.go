@@ -39,8 +39,11 @@ func (t *TrackController) GetAllFollowTracks(c *fiber.Ctx) error {
 			"error": "chatId parameter is required",
 		})
 	}
+	if !canAccessChat(c, chatId) {
+		return chatForbidden(c)
+	}
 
-	tracks, err := t.trackService.GetFollowTracksByChatId(chatId)
+	tracks, err := t.trackService.GetFollowTracksByChatId(c.UserContext(), chatId)
 	if err != nil {
 		return errors.InternalError(c, err)
 	}
@@ -53,6 +56,56 @@ func (t *TrackController) GetAllFollowTracks(c *fiber.Ctx) error {
 	})
 }
 
+func (t *TrackController) GetVisitHistory(c *fiber.Ctx) error {
+	historyDTO := &request.VisitHistoryDTO{
+		ChatID:   c.Params("chatId"),
+		Run:      c.Params("run"),
+		Page:     c.QueryInt("page", 1),
+		PageSize: c.QueryInt("pageSize", defaultHistoryLimit),
+	}
+	if !canAccessChat(c, historyDTO.ChatID) {
+		return chatForbidden(c)
+	}
+	if historyDTO.Page < 1 {
+		historyDTO.Page = 1
+	}
+	if historyDTO.PageSize <= 0 || historyDTO.PageSize > maxHistoryLimit {
+		historyDTO.PageSize = defaultHistoryLimit
+	}
+
+	var err error
+	if historyDTO.From, err = parseDateQuery(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid from parameter",
+		})
+	}
+	if historyDTO.To, err = parseDateQuery(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid to parameter",
+		})
+	}
+
+	visits, total, appErr := t.trackService.GetVisitHistory(c.UserContext(), historyDTO)
+	if appErr != nil {
+		if appErr.HasType(errors.TypeNotFound) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		return errors.InternalError(c, appErr)
+	}
+
+	if len(visits) == 0 {
+		visits = []*model.Visit{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": visits,
+		"pagination": fiber.Map{
+			"page":     historyDTO.Page,
+			"pageSize": historyDTO.PageSize,
+			"total":    total,
+		},
+	})
+}
+
 func (t *TrackController) SendAllFollowTracks(c *fiber.Ctx) error {
 	chatId := c.Params("chatId")
 	if chatId == "" {
@@ -60,8 +113,11 @@ func (t *TrackController) SendAllFollowTracks(c *fiber.Ctx) error {
 			"error": "chatId parameter is required",
 		})
 	}
+	if !canAccessChat(c, chatId) {
+		return chatForbidden(c)
+	}
 
-	err := t.trackService.SendAllFollows(chatId)
+	err := t.trackService.SendAllFollows(c.UserContext(), chatId)
 	if err != nil {
 		return errors.InternalError(c, err)
 	}
@@ -83,13 +139,17 @@ func (t *TrackController) CreateTrack(c *fiber.Ctx) error {
 		})
 	}
 
-	abmUser, err := t.sourceService.GetABMUserByRun(createTrackDto.Run)
+	if !canAccessChat(c, createTrackDto.ChatID) {
+		return chatForbidden(c)
+	}
+
+	abmUser, err := t.sourceService.GetABMUserByRun(c.UserContext(), createTrackDto.Run)
 	if err != nil {
 		return errors.InternalError(c, err)
 	}
 
 	if abmUser == nil {
-		err := t.notificationService.SendMessage(createTrackDto.ChatID, "Usuario no existente")
+		err := t.notificationService.SendMessage(c.UserContext(), createTrackDto.ChatID, "Usuario no existente")
 		if err != nil {
 			return errors.InternalError(c, err)
 		}
@@ -99,14 +159,42 @@ func (t *TrackController) CreateTrack(c *fiber.Ctx) error {
 	createTrackDto.FullName = fmt.Sprintf("%s %s", abmUser.FirstName, abmUser.LastName)
 	createTrackDto.ExternalID = abmUser.ExternalID
 
-	err = t.trackService.Create(&createTrackDto)
+	err = t.trackService.Create(c.UserContext(), &createTrackDto)
 	if err != nil {
-		return errors.InternalError(c, err)
+		return t.trackError(c, err)
 	}
 
 	return c.SendStatus(fiber.StatusOK)
 }
 
+func (t *TrackController) UpdateTrackFilters(c *fiber.Ctx) error {
+	var filtersDTO request.UpdateTrackFiltersDTO
+	if err := c.BodyParser(&filtersDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := t.validation.Struct(filtersDTO); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !canAccessChat(c, filtersDTO.ChatID) {
+		return chatForbidden(c)
+	}
+
+	track, err := t.trackService.UpdateFilters(c.UserContext(), &filtersDTO)
+	if err != nil {
+		return t.trackError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": track,
+	})
+}
+
 func (t *TrackController) DeleteTrack(c *fiber.Ctx) error {
 	var deleteTrackDto request.DeleteTrackDTO
 	if err := c.BodyParser(&deleteTrackDto); err != nil {
@@ -121,10 +209,60 @@ func (t *TrackController) DeleteTrack(c *fiber.Ctx) error {
 		})
 	}
 
-	err := t.trackService.Delete(&deleteTrackDto)
+	if !canAccessChat(c, deleteTrackDto.ChatID) {
+		return chatForbidden(c)
+	}
+
+	err := t.trackService.Delete(c.UserContext(), &deleteTrackDto)
 	if err != nil {
-		return errors.InternalError(c, err)
+		return t.trackError(c, err)
 	}
 
 	return c.SendStatus(fiber.StatusOK)
 }
+
+// RestoreTrack brings back a deleted track.
+func (t *TrackController) RestoreTrack(c *fiber.Ctx) error {
+	var restoreTrackDto request.RestoreTrackDTO
+	if err := c.BodyParser(&restoreTrackDto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := t.validation.Struct(restoreTrackDto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !canAccessChat(c, restoreTrackDto.ChatID) {
+		return chatForbidden(c)
+	}
+
+	track, err := t.trackService.Restore(c.UserContext(), &restoreTrackDto)
+	if err != nil {
+		return t.trackError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": track,
+	})
+}
+
+func (t *TrackController) trackError(c *fiber.Ctx, err *errors.AppError) error {
+	switch {
+	case err.HasType(errors.TypeNotFound):
+		return c.SendStatus(fiber.StatusNotFound)
+	case err.HasType(errors.TypeConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	case err.HasType(errors.TypeValidation):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Err.Error(),
+		})
+	default:
+		return errors.InternalError(c, err)
+	}
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"spl-notification/internal/api/middleware"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTrackService records the calls that pass the chat check.
+type MockTrackService struct {
+	mock.Mock
+}
+
+func (m *MockTrackService) SendAllFollows(ctx context.Context, chatId string) *apperrors.AppError {
+	args := m.Called(chatId)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockTrackService) GetFollowTracksByChatId(ctx context.Context, chatId string) ([]*model.Track, *apperrors.AppError) {
+	args := m.Called(chatId)
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.Track), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockTrackService) GetVisitHistory(ctx context.Context, historyDTO *request.VisitHistoryDTO) ([]*model.Visit, int, *apperrors.AppError) {
+	args := m.Called(historyDTO)
+	if args.Get(2) == nil {
+		return args.Get(0).([]*model.Visit), args.Int(1), nil
+	}
+	return nil, 0, args.Get(2).(*apperrors.AppError)
+}
+
+func (m *MockTrackService) Create(ctx context.Context, trackDTO *request.CreateTrackDTO) *apperrors.AppError {
+	args := m.Called(trackDTO)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockTrackService) UpdateFilters(ctx context.Context, filtersDTO *request.UpdateTrackFiltersDTO) (*model.Track, *apperrors.AppError) {
+	args := m.Called(filtersDTO)
+	if args.Get(1) == nil {
+		return args.Get(0).(*model.Track), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockTrackService) Delete(ctx context.Context, deleteDTO *request.DeleteTrackDTO) *apperrors.AppError {
+	args := m.Called(deleteDTO)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockTrackService) Restore(ctx context.Context, restoreDTO *request.RestoreTrackDTO) (*model.Track, *apperrors.AppError) {
+	args := m.Called(restoreDTO)
+	if args.Get(1) == nil {
+		return args.Get(0).(*model.Track), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+// unusedSourceService and unusedNotificationService fail the test through a
+// nil interface panic if a refused request reaches them.
+type unusedSourceService struct {
+	service.SourceService
+}
+
+type unusedNotificationService struct {
+	service.NotificationService
+}
+
+// newTrackTestApp serves the track routes as the given caller.
+func newTrackTestApp(trackService service.TrackService, caller *model.Caller) *fiber.App {
+	trackController := NewTrackController(
+		trackService,
+		unusedSourceService{},
+		unusedNotificationService{},
+		validator.New(validator.WithRequiredStructEnabled()),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if caller != nil {
+			c.Locals(middleware.CallerKey, caller)
+		}
+		return c.Next()
+	})
+	app.Get("/track/:chatId", trackController.GetAllFollowTracks)
+	app.Get("/track/send/:chatId", trackController.SendAllFollowTracks)
+	app.Get("/track/:chatId/:run/history", trackController.GetVisitHistory)
+	app.Post("/track", trackController.CreateTrack)
+	app.Patch("/track", trackController.UpdateTrackFilters)
+	app.Delete("/track", trackController.DeleteTrack)
+	app.Post("/track/restore", trackController.RestoreTrack)
+	return app
+}
+
+func doRequest(t *testing.T, app *fiber.App, method string, target string, body string) int {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestTrackController_RejectsOtherChats(t *testing.T) {
+	mockService := new(MockTrackService)
+	app := newTrackTestApp(mockService, &model.Caller{
+		Name:    "bot",
+		Scopes:  []string{model.ScopeTracksRead, model.ScopeTracksWrite, model.ScopeMessagesSend},
+		ChatIDs: []string{"chatA"},
+	})
+
+	requests := []struct {
+		method string
+		target string
+		body   string
+	}{
+		{"GET", "/track/chatB", ""},
+		{"GET", "/track/send/chatB", ""},
+		{"GET", "/track/chatB/12345678-9/history", ""},
+		{"POST", "/track", `{"chatId":"chatB","run":"12345678-9"}`},
+		{"PATCH", "/track", `{"chatId":"chatB","run":"12345678-9","notifyType":"ENTRY"}`},
+		{"DELETE", "/track", `{"chatId":"chatB","run":"12345678-9"}`},
+		{"POST", "/track/restore", `{"chatId":"chatB","run":"12345678-9"}`},
+	}
+	for _, r := range requests {
+		assert.Equal(t, http.StatusForbidden, doRequest(t, app, r.method, r.target, r.body), "%s %s", r.method, r.target)
+	}
+
+	// The service is never reached for another chat
+	mockService.AssertNotCalled(t, "GetFollowTracksByChatId", mock.Anything)
+	mockService.AssertNotCalled(t, "SendAllFollows", mock.Anything)
+	mockService.AssertNotCalled(t, "GetVisitHistory", mock.Anything)
+	mockService.AssertNotCalled(t, "UpdateFilters", mock.Anything)
+	mockService.AssertNotCalled(t, "Delete", mock.Anything)
+	mockService.AssertNotCalled(t, "Restore", mock.Anything)
+}
+
+func TestTrackController_AllowsOwnChat(t *testing.T) {
+	mockService := new(MockTrackService)
+	mockService.On("GetFollowTracksByChatId", "chatA").Return([]*model.Track{}, nil)
+	mockService.On("Delete", &request.DeleteTrackDTO{ChatID: "chatA", Run: "12345678-9"}).Return(nil)
+
+	app := newTrackTestApp(mockService, &model.Caller{
+		Name:    "bot",
+		Scopes:  []string{model.ScopeTracksRead, model.ScopeTracksWrite},
+		ChatIDs: []string{"chatA"},
+	})
+
+	assert.Equal(t, http.StatusOK, doRequest(t, app, "GET", "/track/chatA", ""))
+	assert.Equal(t, http.StatusOK, doRequest(t, app, "DELETE", "/track", `{"chatId":"chatA","run":"12345678-9"}`))
+	mockService.AssertExpectations(t)
+}
+
+func TestTrackController_UnrestrictedAndMissingCaller(t *testing.T) {
+	mockService := new(MockTrackService)
+	mockService.On("Delete", mock.Anything).Return(nil)
+
+	// A caller without chats, e.g. AUTH_STRING, may act on every chat
+	app := newTrackTestApp(mockService, &model.Caller{Name: "auth-string", Scopes: []string{model.ScopeAdmin}})
+	assert.Equal(t, http.StatusOK, doRequest(t, app, "DELETE", "/track", `{"chatId":"chatB","run":"12345678-9"}`))
+
+	// A route served without the auth middleware refuses every chat
+	app = newTrackTestApp(mockService, nil)
+	assert.Equal(t, http.StatusForbidden, doRequest(t, app, "DELETE", "/track", `{"chatId":"chatB","run":"12345678-9"}`))
+	mockService.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestTrackController_ErrorStatus(t *testing.T) {
+	notFound := apperrors.NewAppErrorWithType("TrackRepository", apperrors.TypeNotFound, errors.New("track 12345678-9 not found for chat chatA"))
+	conflict := apperrors.NewAppErrorWithType("TrackService", apperrors.TypeConflict, errors.New("track 12345678-9 of chat chatA is not deleted"))
+	failed := apperrors.NewAppError("TrackRepository", errors.New("database unavailable"))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		err    *apperrors.AppError
+		status int
+	}{
+		{"deleted", "DELETE", "/track", nil, http.StatusOK},
+		{"delete unknown track", "DELETE", "/track", notFound, http.StatusNotFound},
+		{"failed delete", "DELETE", "/track", failed, http.StatusInternalServerError},
+		{"restore unknown track", "POST", "/track/restore", notFound, http.StatusNotFound},
+		{"restore active track", "POST", "/track/restore", conflict, http.StatusConflict},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockService := new(MockTrackService)
+			mockService.On("Delete", mock.Anything).Return(test.err)
+			mockService.On("Restore", mock.Anything).Return(nil, test.err)
+
+			app := newTrackTestApp(mockService, &model.Caller{Name: "auth-string", Scopes: []string{model.ScopeAdmin}})
+			assert.Equal(t, test.status, doRequest(t, app, test.method, test.target, `{"chatId":"chatA","run":"12345678-9"}`))
+		})
+	}
+}
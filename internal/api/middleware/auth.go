@@ -1,24 +1,107 @@
 package middleware
 
 import (
+	"crypto/subtle"
+	"log"
 	"spl-notification/internal/config"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// CallerKey is the Fiber local holding the *model.Caller of the request.
+const CallerKey = "caller"
+
 type AuthMiddleware struct {
-	config *config.EnvironmentConfig
+	config        *config.EnvironmentConfig
+	verifier      *service.RequestVerifier
+	apiKeyService service.ApiKeyService
+}
+
+func NewAuthMiddleware(
+	config *config.EnvironmentConfig,
+	verifier *service.RequestVerifier,
+	apiKeyService service.ApiKeyService,
+) *AuthMiddleware {
+	return &AuthMiddleware{
+		config:        config,
+		verifier:      verifier,
+		apiKeyService: apiKeyService,
+	}
+}
+
+// Authorize identifies the caller, attaches it to the context under CallerKey
+// and to the user context for the audit log, and requires it to hold scope.
+// Callers are authenticated with an API key, a request signed with one of the
+// active signing keys, or the static X-Auth-Token while it is enabled; the
+// last two have every scope.
+func (u *AuthMiddleware) Authorize(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		caller, err := u.authenticate(c)
+		if err != nil {
+			return errors.InternalError(c, err)
+		}
+		if caller == nil {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		if !caller.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "missing scope " + scope,
+			})
+		}
+
+		c.Locals(CallerKey, caller)
+		c.SetUserContext(service.ContextWithCaller(c.UserContext(), caller))
+		return c.Next()
+	}
 }
 
-func NewAuthMiddleware(config *config.EnvironmentConfig) *AuthMiddleware {
-	return &AuthMiddleware{config: config}
+// CallerFrom returns the caller attached by Authorize.
+func CallerFrom(c *fiber.Ctx) *model.Caller {
+	caller, _ := c.Locals(CallerKey).(*model.Caller)
+	return caller
 }
 
-func (u *AuthMiddleware) ValidateAuthHeader(c *fiber.Ctx) error {
+func (u *AuthMiddleware) authenticate(c *fiber.Ctx) (*model.Caller, *errors.AppError) {
+	if key := apiKey(c); key != "" {
+		return u.apiKeyService.Authenticate(c.UserContext(), key)
+	}
+
+	if c.Get(service.SignatureHeader) != "" && u.verifier.Enabled() {
+		header := func(key string) string {
+			return c.Get(key)
+		}
+		if err := u.verifier.Verify(c.Method(), c.OriginalURL(), header, c.Body()); err != nil {
+			log.Printf("[AuthMiddleware] Rejected %s %s: %s", c.Method(), c.Path(), err.Err.Error())
+			return nil, nil
+		}
+		return &model.Caller{
+			Name:   "signing-key:" + strings.Clone(c.Get(service.SignatureKeyIDHeader)),
+			Scopes: []string{model.ScopeAdmin},
+		}, nil
+	}
+
+	if !u.config.AuthStaticToken {
+		return nil, nil
+	}
+
 	headerValue := c.Get("X-Auth-Token")
+	if headerValue == "" || subtle.ConstantTimeCompare([]byte(u.config.AuthString), []byte(headerValue)) != 1 {
+		return nil, nil
+	}
+	return &model.Caller{
+		Name:   "auth-string",
+		Scopes: []string{model.ScopeAdmin},
+	}, nil
+}
 
-	if headerValue == "" || u.config.AuthString != headerValue {
-		return c.SendStatus(fiber.StatusUnauthorized)
+// apiKey reads the key from "Authorization: Bearer <key>" or X-Api-Key.
+func apiKey(c *fiber.Ctx) string {
+	if token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer "); found {
+		return strings.TrimSpace(token)
 	}
-	return c.Next()
+	return c.Get("X-Api-Key")
 }
@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"spl-notification/internal/config"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockApiKeyService struct {
+	mock.Mock
+}
+
+func (m *MockApiKeyService) Create(ctx context.Context, apiKeyDTO *request.CreateApiKeyDTO) (*model.ApiKey, string, *apperrors.AppError) {
+	args := m.Called(apiKeyDTO)
+	if args.Get(2) == nil {
+		return args.Get(0).(*model.ApiKey), args.String(1), nil
+	}
+	return nil, "", args.Get(2).(*apperrors.AppError)
+}
+
+func (m *MockApiKeyService) GetAll(ctx context.Context) ([]*model.ApiKey, *apperrors.AppError) {
+	args := m.Called()
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.ApiKey), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockApiKeyService) Revoke(ctx context.Context, id int64) *apperrors.AppError {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockApiKeyService) Authenticate(ctx context.Context, key string) (*model.Caller, *apperrors.AppError) {
+	args := m.Called(key)
+	if args.Get(1) == nil {
+		caller, _ := args.Get(0).(*model.Caller)
+		return caller, nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+var signingKey = model.SigningKey{ID: "2026-10", Secret: "signing-secret"}
+
+// newTestApp serves GET /tracks behind Authorize(ScopeTracksRead), answering
+// with the name of the caller it attached.
+func newTestApp(envConfig *config.EnvironmentConfig, apiKeyService service.ApiKeyService) *fiber.App {
+	auth := NewAuthMiddleware(envConfig, service.NewRequestVerifier(envConfig), apiKeyService)
+
+	app := fiber.New()
+	app.Get("/tracks", auth.Authorize(model.ScopeTracksRead), func(c *fiber.Ctx) error {
+		return c.SendString(CallerFrom(c).Name)
+	})
+	return app
+}
+
+func newTestConfig() *config.EnvironmentConfig {
+	return &config.EnvironmentConfig{
+		AuthString:         "static-token",
+		AuthStaticToken:    true,
+		ApiSigningKeys:     []model.SigningKey{signingKey},
+		ApiSignatureWindow: 5 * time.Minute,
+	}
+}
+
+// sign sets the signature headers of a bodyless request to uri.
+func sign(req *http.Request, key model.SigningKey, uri string) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	digest := sha256.Sum256(nil)
+	mac := hmac.New(sha256.New, []byte(key.Secret))
+	mac.Write([]byte(req.Method + "\n" + uri + "\n" + timestamp + "\n" + hex.EncodeToString(digest[:])))
+
+	req.Header.Set(service.SignatureKeyIDHeader, key.ID)
+	req.Header.Set(service.SignatureTimestampHeader, timestamp)
+	req.Header.Set(service.SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+}
+
+func send(t *testing.T, app *fiber.App, req *http.Request) (int, string) {
+	t.Helper()
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestAuthorize_ApiKey(t *testing.T) {
+	apiKeyService := new(MockApiKeyService)
+	apiKeyService.On("Authenticate", "reader").Return(&model.Caller{Name: "reader", Scopes: []string{model.ScopeTracksRead}}, nil)
+	apiKeyService.On("Authenticate", "sender").Return(&model.Caller{Name: "sender", Scopes: []string{model.ScopeMessagesSend}}, nil)
+	apiKeyService.On("Authenticate", "admin").Return(&model.Caller{Name: "admin", Scopes: []string{model.ScopeAdmin}}, nil)
+	apiKeyService.On("Authenticate", "unknown").Return(nil, nil)
+	apiKeyService.On("Authenticate", "broken").Return(nil, apperrors.NewAppError("TestError", errors.New("database unavailable")))
+	app := newTestApp(newTestConfig(), apiKeyService)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+		body   string
+	}{
+		{"bearer key with the scope", fiber.HeaderAuthorization, "Bearer reader", fiber.StatusOK, "reader"},
+		{"x-api-key with the scope", "X-Api-Key", "reader", fiber.StatusOK, "reader"},
+		{"admin key holds every scope", "X-Api-Key", "admin", fiber.StatusOK, "admin"},
+		{"key without the scope", "X-Api-Key", "sender", fiber.StatusForbidden, `{"error":"missing scope tracks:read"}`},
+		{"unknown key", "X-Api-Key", "unknown", fiber.StatusUnauthorized, "Unauthorized"},
+		{"failed lookup", "X-Api-Key", "broken", fiber.StatusInternalServerError, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/tracks", nil)
+			req.Header.Set(test.header, test.value)
+
+			status, body := send(t, app, req)
+
+			assert.Equal(t, test.status, status)
+			if test.body != "" {
+				assert.Equal(t, test.body, body)
+			}
+		})
+	}
+}
+
+func TestAuthorize_Signature(t *testing.T) {
+	app := newTestApp(newTestConfig(), new(MockApiKeyService))
+
+	req := httptest.NewRequest(fiber.MethodGet, "/tracks", nil)
+	sign(req, signingKey, "/tracks")
+	status, body := send(t, app, req)
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "signing-key:2026-10", body)
+
+	// The same signature is not accepted twice
+	status, _ = send(t, app, req)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+
+	req = httptest.NewRequest(fiber.MethodGet, "/tracks", nil)
+	sign(req, model.SigningKey{ID: "2026-10", Secret: "other-secret"}, "/tracks")
+	status, _ = send(t, app, req)
+	assert.Equal(t, fiber.StatusUnauthorized, status)
+}
+
+func TestAuthorize_StaticToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		token   string
+		status  int
+	}{
+		{"valid token", true, "static-token", fiber.StatusOK},
+		{"wrong token", true, "other-token", fiber.StatusUnauthorized},
+		{"missing token", true, "", fiber.StatusUnauthorized},
+		{"token disabled", false, "static-token", fiber.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			envConfig := newTestConfig()
+			envConfig.AuthStaticToken = test.enabled
+			app := newTestApp(envConfig, new(MockApiKeyService))
+
+			req := httptest.NewRequest(fiber.MethodGet, "/tracks", nil)
+			if test.token != "" {
+				req.Header.Set("X-Auth-Token", test.token)
+			}
+
+			status, body := send(t, app, req)
+
+			assert.Equal(t, test.status, status)
+			if test.status == fiber.StatusOK {
+				assert.Equal(t, "auth-string", body)
+			}
+		})
+	}
+}
+
+func TestAuthorize_Precedence(t *testing.T) {
+	apiKeyService := new(MockApiKeyService)
+	apiKeyService.On("Authenticate", "reader").Return(&model.Caller{Name: "reader", Scopes: []string{model.ScopeTracksRead}}, nil)
+	apiKeyService.On("Authenticate", "unknown").Return(nil, nil)
+
+	tests := []struct {
+		name      string
+		apiKey    string
+		signature *model.SigningKey
+		token     string
+		status    int
+		body      string
+	}{
+		{"api key before signature and token", "reader", &signingKey, "static-token", fiber.StatusOK, "reader"},
+		{"rejected api key doesn't fall back", "unknown", &signingKey, "static-token", fiber.StatusUnauthorized, ""},
+		{"signature before token", "", &signingKey, "static-token", fiber.StatusOK, "signing-key:2026-10"},
+		{"rejected signature doesn't fall back", "", &model.SigningKey{ID: "2026-10", Secret: "other-secret"}, "static-token", fiber.StatusUnauthorized, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app := newTestApp(newTestConfig(), apiKeyService)
+
+			req := httptest.NewRequest(fiber.MethodGet, "/tracks", nil)
+			if test.apiKey != "" {
+				req.Header.Set("X-Api-Key", test.apiKey)
+			}
+			if test.signature != nil {
+				sign(req, *test.signature, "/tracks")
+			}
+			req.Header.Set("X-Auth-Token", test.token)
+
+			status, body := send(t, app, req)
+
+			assert.Equal(t, test.status, status)
+			if test.body != "" {
+				assert.Equal(t, test.body, body)
+			}
+		})
+	}
+
+	// Without signing keys a signed request falls back to the static token
+	envConfig := newTestConfig()
+	envConfig.ApiSigningKeys = nil
+	app := newTestApp(envConfig, apiKeyService)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/tracks", nil)
+	sign(req, signingKey, "/tracks")
+	req.Header.Set("X-Auth-Token", "static-token")
+
+	status, body := send(t, app, req)
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, "auth-string", body)
+}
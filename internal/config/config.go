@@ -4,8 +4,14 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
+	"spl-notification/internal/model"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/robfig/cron/v3"
 )
 
 type EnvironmentConfig struct {
@@ -23,14 +29,77 @@ type EnvironmentConfig struct {
 
 	Zone string `env:"ZONE"`
 
+	// Inbound request signing: active KEY_ID=SECRET pairs, several while a
+	// key is rotated, and the accepted clock skew of the signed timestamp.
+	// The X-Auth-Token header is still accepted unless AuthStaticToken is false
+	ApiSigningKeys     []model.SigningKey `env:"API_SIGNING_KEYS"`
+	ApiSignatureWindow time.Duration      `env:"API_SIGNATURE_WINDOW,default=5m"`
+	AuthStaticToken    bool               `env:"AUTH_STATIC_TOKEN,default=true"`
+
+	// Time given to the running jobs and notifications to finish on shutdown
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT,default=30s"`
+
+	// Statistics
+	StatsWindowDays int    `env:"STATS_WINDOW_DAYS,default=30"`
+	DigestSchedule  string `env:"DIGEST_SCHEDULE,default=0 20 * * 0"`
+
+	// Access ingestion: poll, push (POST /access/events) or hybrid, where a
+	// poll every AccessReconcileInterval catches the events that were missed
+	AccessIngestMode        string        `env:"ACCESS_INGEST_MODE,default=poll"`
+	AccessReconcileInterval time.Duration `env:"ACCESS_RECONCILE_INTERVAL,default=5m"`
+
+	// Access polling: the interval grows up to AccessPollMaxInterval after
+	// errors or every AccessPollIdleCycles cycles without changes, and is at
+	// least AccessPollClosedInterval while every location in OpeningHours is
+	// closed
+	AccessPollInterval       time.Duration               `env:"ACCESS_POLL_INTERVAL,default=5s"`
+	AccessPollMaxInterval    time.Duration               `env:"ACCESS_POLL_MAX_INTERVAL,default=1m"`
+	AccessPollClosedInterval time.Duration               `env:"ACCESS_POLL_CLOSED_INTERVAL,default=5m"`
+	AccessPollIdleCycles     int                         `env:"ACCESS_POLL_IDLE_CYCLES,default=12"`
+	OpeningHours             map[int8]model.OpeningHours `env:"OPENING_HOURS"`
+	// Access fetch: snapshot or incremental (falls back to snapshots when the
+	// access service returns no cursor)
+	AccessFetchMode string `env:"ACCESS_FETCH_MODE,default=snapshot"`
+
+	// Time after which the cached tracks are reloaded from the database
+	TrackCacheReconcileInterval time.Duration `env:"TRACK_CACHE_RECONCILE_INTERVAL,default=5m"`
+
+	// Hours inside before a long stay alert, 0 disables it unless the track
+	// sets its own threshold
+	LongStayHours int `env:"LONG_STAY_HOURS,default=0"`
+
+	// Daily check of the tracks with inactivity reminders
+	InactivitySchedule string `env:"INACTIVITY_SCHEDULE,default=0 10 * * *"`
+
 	// Source Service
 	SourceBaseUrl    string `env:"SOURCE_BASE_URL,required"`
 	SourceAuthString string `env:"SOURCE_AUTH_STRING,required"`
 
+	// Keys used to sign the requests to the source and access services
+	// instead of their static tokens, when they verify signatures
+	SourceSigningKey        *model.SigningKey `env:"SOURCE_SIGNING_KEY"`
+	AccessServiceSigningKey *model.SigningKey `env:"ACCESS_SERVICE_SIGNING_KEY"`
+
+	// Notification queue: pubsub, memory or sqlite
+	NotificationQueue string `env:"NOTIFICATION_QUEUE,default=pubsub"`
+	// How long delivered notification IDs are kept to skip redeliveries
+	DeliveryDedupeRetention time.Duration `env:"DELIVERY_DEDUPE_RETENTION,default=72h"`
+	// Webhook attempts per notification before it is dead-lettered, waiting
+	// DeliveryRetryBaseDelay after the first failure and doubling up to
+	// DeliveryRetryMaxDelay
+	DeliveryMaxAttempts    int           `env:"DELIVERY_MAX_ATTEMPTS,default=5"`
+	DeliveryRetryBaseDelay time.Duration `env:"DELIVERY_RETRY_BASE_DELAY,default=1s"`
+	DeliveryRetryMaxDelay  time.Duration `env:"DELIVERY_RETRY_MAX_DELAY,default=30s"`
+
+	// Consecutive failures that open the circuit breaker of a dependency (0
+	// disables it) and time before a probe call is let through
+	CircuitBreakerFailureThreshold int           `env:"CIRCUIT_BREAKER_FAILURE_THRESHOLD,default=5"`
+	CircuitBreakerOpenTimeout      time.Duration `env:"CIRCUIT_BREAKER_OPEN_TIMEOUT,default=30s"`
+
 	// Google Cloud Pub/Sub
-	PubSubProjectID      string `env:"PUBSUB_PROJECT_ID,required"`
-	PubSubTopicID        string `env:"PUBSUB_TOPIC_ID,required"`
-	PubSubSubscriptionID string `env:"PUBSUB_SUBSCRIPTION_ID,required"`
+	PubSubProjectID      string `env:"PUBSUB_PROJECT_ID"`
+	PubSubTopicID        string `env:"PUBSUB_TOPIC_ID"`
+	PubSubSubscriptionID string `env:"PUBSUB_SUBSCRIPTION_ID"`
 }
 
 var envConfig *EnvironmentConfig
@@ -56,6 +125,25 @@ func NewEnviromentConfig() *EnvironmentConfig {
 
 	// AuthString
 	envConfig.AuthString = os.Getenv("AUTH_STRING")
+	envConfig.AuthStaticToken = os.Getenv("AUTH_STATIC_TOKEN") != "false"
+
+	// Request signing
+	envConfig.ApiSigningKeys, err = ParseSigningKeys(os.Getenv("API_SIGNING_KEYS"))
+	if err != nil {
+		fmt.Println("Error parsing API_SIGNING_KEYS")
+		panic(err)
+	}
+	envConfig.ApiSignatureWindow = parseDuration("API_SIGNATURE_WINDOW", 5*time.Minute)
+	envConfig.SourceSigningKey, err = parseSigningKey("SOURCE_SIGNING_KEY", os.Getenv("SOURCE_SIGNING_KEY"))
+	if err != nil {
+		fmt.Println("Error parsing SOURCE_SIGNING_KEY")
+		panic(err)
+	}
+	envConfig.AccessServiceSigningKey, err = parseSigningKey("ACCESS_SERVICE_SIGNING_KEY", os.Getenv("ACCESS_SERVICE_SIGNING_KEY"))
+	if err != nil {
+		fmt.Println("Error parsing ACCESS_SERVICE_SIGNING_KEY")
+		panic(err)
+	}
 
 	// Turso
 	envConfig.TursoBaseUrl = os.Getenv("TURSO_DATABASE_URL")
@@ -79,6 +167,64 @@ func NewEnviromentConfig() *EnvironmentConfig {
 		envConfig.Zone = "GMT-3"
 	}
 
+	// Shutdown
+	envConfig.ShutdownTimeout = parseDuration("SHUTDOWN_TIMEOUT", 30*time.Second)
+
+	// Statistics
+	envConfig.StatsWindowDays, err = strconv.Atoi(os.Getenv("STATS_WINDOW_DAYS"))
+	if err != nil || envConfig.StatsWindowDays <= 0 {
+		envConfig.StatsWindowDays = 30
+	}
+	envConfig.DigestSchedule, err = parseSchedule(os.Getenv("DIGEST_SCHEDULE"), "0 20 * * 0")
+	if err != nil {
+		fmt.Println("Error parsing DIGEST_SCHEDULE")
+		panic(err)
+	}
+
+	// Access polling
+	envConfig.AccessIngestMode, err = parseMode(os.Getenv("ACCESS_INGEST_MODE"), "poll", "push", "hybrid")
+	if err != nil {
+		fmt.Println("Error parsing ACCESS_INGEST_MODE")
+		panic(err)
+	}
+	envConfig.AccessReconcileInterval = parseDuration("ACCESS_RECONCILE_INTERVAL", 5*time.Minute)
+	envConfig.AccessPollInterval = parseDuration("ACCESS_POLL_INTERVAL", 5*time.Second)
+	envConfig.AccessPollMaxInterval = parseDuration("ACCESS_POLL_MAX_INTERVAL", time.Minute)
+	envConfig.AccessPollClosedInterval = parseDuration("ACCESS_POLL_CLOSED_INTERVAL", 5*time.Minute)
+	if envConfig.AccessPollMaxInterval < envConfig.AccessPollInterval {
+		envConfig.AccessPollMaxInterval = envConfig.AccessPollInterval
+	}
+	envConfig.AccessPollIdleCycles, err = strconv.Atoi(os.Getenv("ACCESS_POLL_IDLE_CYCLES"))
+	if err != nil || envConfig.AccessPollIdleCycles <= 0 {
+		envConfig.AccessPollIdleCycles = 12
+	}
+	envConfig.OpeningHours, err = ParseOpeningHours(os.Getenv("OPENING_HOURS"))
+	if err != nil {
+		fmt.Println("Error parsing OPENING_HOURS")
+		panic(err)
+	}
+	envConfig.AccessFetchMode, err = parseMode(os.Getenv("ACCESS_FETCH_MODE"), "snapshot", "incremental")
+	if err != nil {
+		fmt.Println("Error parsing ACCESS_FETCH_MODE")
+		panic(err)
+	}
+
+	// Track cache
+	envConfig.TrackCacheReconcileInterval = parseDuration("TRACK_CACHE_RECONCILE_INTERVAL", 5*time.Minute)
+
+	// Long stay
+	envConfig.LongStayHours, err = strconv.Atoi(os.Getenv("LONG_STAY_HOURS"))
+	if err != nil || envConfig.LongStayHours < 0 {
+		envConfig.LongStayHours = 0
+	}
+
+	// Inactivity
+	envConfig.InactivitySchedule, err = parseSchedule(os.Getenv("INACTIVITY_SCHEDULE"), "0 10 * * *")
+	if err != nil {
+		fmt.Println("Error parsing INACTIVITY_SCHEDULE")
+		panic(err)
+	}
+
 	// Source Service
 	envConfig.SourceBaseUrl = os.Getenv("SOURCE_BASE_URL")
 	envConfig.SourceAuthString = os.Getenv("SOURCE_AUTH_STRING")
@@ -87,6 +233,26 @@ func NewEnviromentConfig() *EnvironmentConfig {
 	envConfig.AccessServiceBaseUrl = os.Getenv("ACCESS_SERVICE_BASE_URL")
 	envConfig.AccessServiceAuthToken = os.Getenv("ACCESS_SERVICE_AUTH_TOKEN")
 
+	// Notification Queue
+	envConfig.NotificationQueue = os.Getenv("NOTIFICATION_QUEUE")
+	if envConfig.NotificationQueue == "" {
+		envConfig.NotificationQueue = "pubsub"
+	}
+	envConfig.DeliveryDedupeRetention = parseDuration("DELIVERY_DEDUPE_RETENTION", 72*time.Hour)
+	envConfig.DeliveryMaxAttempts, err = strconv.Atoi(os.Getenv("DELIVERY_MAX_ATTEMPTS"))
+	if err != nil || envConfig.DeliveryMaxAttempts <= 0 {
+		envConfig.DeliveryMaxAttempts = 5
+	}
+	envConfig.DeliveryRetryBaseDelay = parseDuration("DELIVERY_RETRY_BASE_DELAY", time.Second)
+	envConfig.DeliveryRetryMaxDelay = parseDuration("DELIVERY_RETRY_MAX_DELAY", 30*time.Second)
+
+	// Circuit breakers
+	envConfig.CircuitBreakerFailureThreshold, err = strconv.Atoi(os.Getenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD"))
+	if err != nil || envConfig.CircuitBreakerFailureThreshold < 0 {
+		envConfig.CircuitBreakerFailureThreshold = 5
+	}
+	envConfig.CircuitBreakerOpenTimeout = parseDuration("CIRCUIT_BREAKER_OPEN_TIMEOUT", 30*time.Second)
+
 	// Google Cloud Pub/Sub
 	envConfig.PubSubProjectID = os.Getenv("PUBSUB_PROJECT_ID")
 	envConfig.PubSubTopicID = os.Getenv("PUBSUB_TOPIC_ID")
@@ -96,6 +262,43 @@ func NewEnviromentConfig() *EnvironmentConfig {
 	return envConfig
 }
 
+// parseDuration reads a positive duration (5s, 1m) from the environment.
+func parseDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// parseMode returns value when it is one of the modes, the first one being the
+// default used when value is empty.
+func parseMode(value string, modes ...string) (string, error) {
+	if value == "" {
+		return modes[0], nil
+	}
+	if !slices.Contains(modes, value) {
+		return "", fmt.Errorf("unknown mode %q, expected one of %s", value, strings.Join(modes, ", "))
+	}
+	return value, nil
+}
+
+// parseSchedule returns value when it is a valid five field cron expression,
+// the one the scheduler runs the job with, or defaultValue when it is empty.
+func parseSchedule(value string, defaultValue string) (string, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	schedule, err := cron.ParseStandard(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid cron expression %q: %w", value, err)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return "", fmt.Errorf("cron expression %q never runs", value)
+	}
+	return value, nil
+}
+
 func printEnvironmentConfig(config EnvironmentConfig) {
 	v := reflect.ValueOf(config)
 	typeOfConfig := v.Type()
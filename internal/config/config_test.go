@@ -0,0 +1,66 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		valid    bool
+	}{
+		{"default", "", "snapshot", true},
+		{"first mode", "snapshot", "snapshot", true},
+		{"other mode", "incremental", "incremental", true},
+		{"unknown mode", "stream", "", false},
+		{"modes are case sensitive", "Incremental", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mode, err := parseMode(test.value, "snapshot", "incremental")
+
+			assert.Equal(t, test.expected, mode)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		valid    bool
+	}{
+		{"default", "", "0 10 * * *", true},
+		{"daily", "30 9 * * *", "30 9 * * *", true},
+		{"weekly", "0 20 * * 0", "0 20 * * 0", true},
+		{"descriptor", "@daily", "@daily", true},
+		{"malformed", "every day", "", false},
+		{"seconds field", "0 0 10 * * *", "", false},
+		{"out of range", "0 25 * * *", "", false},
+		{"never runs", "0 10 30 2 *", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schedule, err := parseSchedule(test.value, "0 10 * * *")
+
+			assert.Equal(t, test.expected, schedule)
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
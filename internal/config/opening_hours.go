@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"spl-notification/internal/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseOpeningHours reads the opening hours of each location written as
+// CODE=HH:MM-HH:MM separated by commas, e.g. 102=06:00-23:00,104=07:00-22:00.
+// A window ending before it starts crosses midnight (22:00-06:00).
+func ParseOpeningHours(value string) (map[int8]model.OpeningHours, error) {
+	openingHours := make(map[int8]model.OpeningHours)
+	if strings.TrimSpace(value) == "" {
+		return openingHours, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		code, window, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid opening hours %q", entry)
+		}
+
+		locationCode, err := strconv.ParseInt(strings.TrimSpace(code), 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid location code %q: %w", code, err)
+		}
+		if _, duplicated := openingHours[int8(locationCode)]; duplicated {
+			return nil, fmt.Errorf("duplicated opening hours for location %d", locationCode)
+		}
+
+		start, end, found := strings.Cut(strings.TrimSpace(window), "-")
+		if !found {
+			return nil, fmt.Errorf("invalid opening hours %q", entry)
+		}
+		for _, clock := range []string{start, end} {
+			if _, err := time.Parse("15:04", clock); err != nil {
+				return nil, fmt.Errorf("invalid opening hours %q: %w", entry, err)
+			}
+		}
+		// The window would never be open
+		if start == end {
+			return nil, fmt.Errorf("invalid opening hours %q: empty window", entry)
+		}
+
+		openingHours[int8(locationCode)] = model.OpeningHours{Start: start, End: end}
+	}
+
+	return openingHours, nil
+}
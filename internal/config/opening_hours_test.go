@@ -0,0 +1,80 @@
+package config
+
+import (
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOpeningHours(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected map[int8]model.OpeningHours
+	}{
+		{"empty", "", map[int8]model.OpeningHours{}},
+		{"blank", "  ", map[int8]model.OpeningHours{}},
+		{"single location", "102=06:00-23:00", map[int8]model.OpeningHours{
+			102: {Start: "06:00", End: "23:00"},
+		}},
+		{"several locations with spaces", " 102 = 06:00-23:00 , 104=07:00-22:00", map[int8]model.OpeningHours{
+			102: {Start: "06:00", End: "23:00"},
+			104: {Start: "07:00", End: "22:00"},
+		}},
+		{"overnight", "107=22:00-06:00", map[int8]model.OpeningHours{
+			107: {Start: "22:00", End: "06:00"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			openingHours, err := ParseOpeningHours(test.value)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, openingHours)
+		})
+	}
+}
+
+func TestParseOpeningHours_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"missing code", "06:00-23:00"},
+		{"missing window", "102="},
+		{"missing end", "102=06:00"},
+		{"code not a number", "abc=06:00-23:00"},
+		{"code out of range", "300=06:00-23:00"},
+		{"invalid hour", "102=25:00-23:00"},
+		{"invalid minute", "102=06:00-23:60"},
+		{"seconds", "102=06:00:00-23:00:00"},
+		{"empty window", "102=06:00-06:00"},
+		{"trailing comma", "102=06:00-23:00,"},
+		{"duplicated code", "102=06:00-23:00,104=07:00-22:00,102=08:00-20:00"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			openingHours, err := ParseOpeningHours(test.value)
+
+			assert.Error(t, err)
+			assert.Nil(t, openingHours)
+		})
+	}
+}
+
+func TestOpeningHours_IsOpenOvernight(t *testing.T) {
+	openingHours, err := ParseOpeningHours("107=22:00-06:00")
+	assert.NoError(t, err)
+
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2025, 10, 5, hour, minute, 0, 0, time.UTC)
+	}
+	assert.True(t, openingHours[107].IsOpen(at(23, 0)))
+	assert.True(t, openingHours[107].IsOpen(at(5, 59)))
+	assert.False(t, openingHours[107].IsOpen(at(6, 0)))
+	assert.False(t, openingHours[107].IsOpen(at(12, 0)))
+}
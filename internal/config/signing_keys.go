@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"spl-notification/internal/model"
+	"strings"
+)
+
+// ParseSigningKeys reads a KEY_ID=SECRET list separated by commas. The secret
+// ends at the next comma and may contain '='.
+func ParseSigningKeys(value string) ([]model.SigningKey, error) {
+	var keys []model.SigningKey
+	if strings.TrimSpace(value) == "" {
+		return keys, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		id, secret, found := strings.Cut(strings.TrimSpace(entry), "=")
+		id = strings.TrimSpace(id)
+		if !found || id == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing key %q, expected KEY_ID=SECRET", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicated signing key %q", id)
+		}
+		seen[id] = true
+
+		keys = append(keys, model.SigningKey{ID: id, Secret: secret})
+	}
+
+	return keys, nil
+}
+
+// parseSigningKey reads a single KEY_ID=SECRET, nil when it is empty.
+func parseSigningKey(key string, value string) (*model.SigningKey, error) {
+	keys, err := ParseSigningKeys(value)
+	if err != nil {
+		return nil, err
+	}
+	switch len(keys) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &keys[0], nil
+	default:
+		return nil, fmt.Errorf("%s accepts a single signing key", key)
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Location resolves Zone into a *time.Location. It accepts IANA names
+// (America/Santiago) and fixed offsets written as GMT-3, UTC+2 or -03:00.
+func (c *EnvironmentConfig) Location() *time.Location {
+	location, err := ParseZone(c.Zone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+func ParseZone(zone string) (*time.Location, error) {
+	if zone == "" {
+		return time.UTC, nil
+	}
+
+	// Offsets are parsed before LoadLocation on purpose: tzdata names such as
+	// GMT-3 follow the POSIX convention and mean UTC+3.
+	offset := strings.TrimPrefix(strings.TrimPrefix(strings.ToUpper(zone), "GMT"), "UTC")
+	if offset == "" {
+		return time.UTC, nil
+	}
+
+	sign := 1
+	switch offset[0] {
+	case '+':
+	case '-':
+		sign = -1
+	default:
+		location, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zone: %s", zone)
+		}
+		return location, nil
+	}
+
+	hours, minutes := offset[1:], "0"
+	if parts := strings.SplitN(hours, ":", 2); len(parts) == 2 {
+		hours, minutes = parts[0], parts[1]
+	}
+
+	h, err := strconv.Atoi(hours)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zone: %s", zone)
+	}
+	m, err := strconv.Atoi(minutes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zone: %s", zone)
+	}
+
+	return time.FixedZone(zone, sign*(h*3600+m*60)), nil
+}
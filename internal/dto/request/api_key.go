@@ -0,0 +1,11 @@
+package request
+
+import "time"
+
+type CreateApiKeyDTO struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=tracks:read tracks:write messages:send admin"`
+	// Chats the key is restricted to, every chat when omitted
+	ChatIDs   []string   `json:"chatIds" validate:"omitempty,min=1,dive,required,excludesall=0x2C"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,10 @@
+package request
+
+import "time"
+
+type AuditFilterDTO struct {
+	ChatID *string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+}
@@ -0,0 +1,9 @@
+package request
+
+type LocationDTO struct {
+	Code     int8    `json:"code" validate:"required"`
+	Name     string  `json:"name" validate:"required,max=255"`
+	Address  *string `json:"address" validate:"omitempty,max=255"`
+	TimeZone *string `json:"timeZone" validate:"omitempty,max=64"`
+	Active   *bool   `json:"active"`
+}
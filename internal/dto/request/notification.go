@@ -0,0 +1,14 @@
+package request
+
+import (
+	"spl-notification/internal/model"
+	"time"
+)
+
+type NotificationHistoryDTO struct {
+	ChatID string
+	From   *time.Time
+	To     *time.Time
+	Type   *model.NotificationType
+	Limit  int
+}
@@ -0,0 +1,10 @@
+package request
+
+type UpdatePreferencesDTO struct {
+	ChatID          string  `json:"-"`
+	NotifyMode      string  `json:"notifyMode" validate:"omitempty,oneof=BOTH ENTRY EXIT"`
+	QuietHoursStart *string `json:"quietHoursStart" validate:"required_with=QuietHoursEnd,omitempty,datetime=15:04"`
+	QuietHoursEnd   *string `json:"quietHoursEnd" validate:"required_with=QuietHoursStart,omitempty,datetime=15:04"`
+	TimeZone        *string `json:"timeZone" validate:"omitempty,max=64"`
+	QuietMode       string  `json:"quietMode" validate:"omitempty,oneof=DROP BATCH"`
+}
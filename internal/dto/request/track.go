@@ -1,12 +1,20 @@
 package request
 
-import "time"
+import (
+	"spl-notification/internal/model"
+	"time"
+)
 
 type DeleteTrackDTO struct {
 	ChatID string `json:"chatId" validate:"required"`
 	Run    string `json:"run" validate:"required"`
 }
 
+type RestoreTrackDTO struct {
+	ChatID string `json:"chatId" validate:"required"`
+	Run    string `json:"run" validate:"required"`
+}
+
 type CreateTrackDTO struct {
 	ChatID     string     `json:"chatId" validate:"required"`
 	ExternalID int32      `json:"externalId"`
@@ -15,4 +23,35 @@ type CreateTrackDTO struct {
 	FullName   string     `json:"fullName"`
 	LastEntry  *time.Time `json:"lastEntry"`
 	LastExit   *time.Time `json:"lastExit"`
+
+	LastEntryLocation *int8 `json:"lastEntryLocation"`
+
+	NotifyType *string                 `json:"notifyType" validate:"omitempty,oneof=BOTH ENTRY EXIT"`
+	Locations  []int8                  `json:"locations"`
+	Schedule   []*model.ScheduleWindow `json:"schedule" validate:"omitempty,dive"`
+
+	LongStayHours *int `json:"longStayHours" validate:"omitempty,min=0,max=24"`
+	InactiveDays  *int `json:"inactiveDays" validate:"omitempty,min=0,max=365"`
+}
+
+// UpdateTrackFiltersDTO only changes the filters present in the body, an empty
+// list (or BOTH for notifyType) removes the restriction.
+type UpdateTrackFiltersDTO struct {
+	ChatID     string                   `json:"chatId" validate:"required"`
+	Run        string                   `json:"run" validate:"required"`
+	NotifyType *string                  `json:"notifyType" validate:"omitempty,oneof=BOTH ENTRY EXIT"`
+	Locations  *[]int8                  `json:"locations"`
+	Schedule   *[]*model.ScheduleWindow `json:"schedule" validate:"omitempty,dive"`
+
+	LongStayHours *int `json:"longStayHours" validate:"omitempty,min=0,max=24"`
+	InactiveDays  *int `json:"inactiveDays" validate:"omitempty,min=0,max=365"`
+}
+
+type VisitHistoryDTO struct {
+	ChatID   string
+	Run      string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
 }
@@ -1,10 +1,12 @@
 package response
 
+// AccessDTO is an access as returned by the access service, also accepted
+// as a pushed event on POST /access/events.
 type AccessDTO struct {
-	ExternalID string  `json:"externalId"`
-	Run        string  `json:"run"`
+	ExternalID string  `json:"externalId" validate:"required,number"`
+	Run        string  `json:"run" validate:"required"`
 	FullName   string  `json:"fullName"`
-	Location   string  `json:"location"`
-	EntryAt    string  `json:"entryAt"`
-	ExitAt     *string `json:"exitAt"`
+	Location   string  `json:"location" validate:"required,number"`
+	EntryAt    string  `json:"entryAt" validate:"required,datetime=2006-01-02T15:04:05Z07:00"`
+	ExitAt     *string `json:"exitAt" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 }
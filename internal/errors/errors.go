@@ -7,6 +7,12 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+const (
+	TypeNotFound   = "NOT_FOUND"
+	TypeValidation = "VALIDATION"
+	TypeConflict   = "CONFLICT"
+)
+
 type AppError struct {
 	Component *string // (ej: "TrackRepository", "TrackService")
 	Type      *string // Tipo/código del error
@@ -28,6 +34,10 @@ func NewAppErrorWithType(component string, errType string, err error) *AppError
 	}
 }
 
+func (e *AppError) HasType(errType string) bool {
+	return e.Type != nil && *e.Type == errType
+}
+
 func (e *AppError) Error() string {
 	errStr := ""
 	if e.Component != nil {
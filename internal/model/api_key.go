@@ -0,0 +1,59 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Scopes granted to an API key. ScopeAdmin grants every other scope.
+const (
+	ScopeTracksRead   = "tracks:read"
+	ScopeTracksWrite  = "tracks:write"
+	ScopeMessagesSend = "messages:send"
+	ScopeAdmin        = "admin"
+)
+
+// ApiKey is a client of the API. Only the SHA-256 hash of the key is stored,
+// the prefix identifies it in listings.
+type ApiKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ChatIDs    []string   `json:"chatIds"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// IsActive reports whether the key is neither revoked nor expired at now.
+func (k *ApiKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// Caller is the authenticated client of a request. ApiKeyID is nil for the
+// callers authenticated with AUTH_STRING or a signing key. ChatIDs restricts
+// the chats it may act on, nil grants every chat.
+type Caller struct {
+	Name     string
+	ApiKeyID *int64
+	Scopes   []string
+	ChatIDs  []string
+}
+
+func (c *Caller) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, ScopeAdmin) || slices.Contains(c.Scopes, scope)
+}
+
+// CanAccessChat reports whether the caller may read or change the tracks,
+// preferences and notifications of chatID.
+func (c *Caller) CanAccessChat(chatID string) bool {
+	return c.ChatIDs == nil || slices.Contains(c.ChatIDs, chatID)
+}
+
+// String names the caller in the request logs.
+func (c *Caller) String() string {
+	return c.Name
+}
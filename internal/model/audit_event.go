@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the audit log.
+const (
+	AuditActionTrackCreate      = "TRACK_CREATE"
+	AuditActionTrackUpdate      = "TRACK_UPDATE"
+	AuditActionTrackDelete      = "TRACK_DELETE"
+	AuditActionTrackRestore     = "TRACK_RESTORE"
+	AuditActionLocationCreate   = "LOCATION_CREATE"
+	AuditActionLocationUpdate   = "LOCATION_UPDATE"
+	AuditActionLocationDelete   = "LOCATION_DELETE"
+	AuditActionApiKeyCreate     = "API_KEY_CREATE"
+	AuditActionApiKeyRevoke     = "API_KEY_REVOKE"
+	AuditActionDeadLetterReplay = "DEAD_LETTER_REPLAY"
+)
+
+// AuditEvent records who changed what and when. Before and After hold the
+// JSON of the changed entity, nil when it didn't exist on that side.
+type AuditEvent struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	ApiKeyID  *int64          `json:"apiKeyId"`
+	Action    string          `json:"action"`
+	ChatID    *string         `json:"chatId"`
+	Run       *string         `json:"run"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
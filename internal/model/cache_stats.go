@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// CacheStats reports how often a cache answered from memory (hits) or had to
+// go to the database (misses).
+type CacheStats struct {
+	Hits     uint64     `json:"hits"`
+	Misses   uint64     `json:"misses"`
+	Size     int        `json:"size"`
+	LoadedAt *time.Time `json:"loadedAt"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+const (
+	NotifyModeBoth  = "BOTH"
+	NotifyModeEntry = "ENTRY"
+	NotifyModeExit  = "EXIT"
+
+	// QuietModeDrop discards the notifications received during quiet hours,
+	// QuietModeBatch sends them as a single summary once quiet hours end.
+	QuietModeDrop  = "DROP"
+	QuietModeBatch = "BATCH"
+)
+
+type ChatPreferences struct {
+	ChatID          string  `json:"chatId"`
+	NotifyMode      string  `json:"notifyMode"`
+	QuietHoursStart *string `json:"quietHoursStart"`
+	QuietHoursEnd   *string `json:"quietHoursEnd"`
+	TimeZone        *string `json:"timeZone"`
+	QuietMode       string  `json:"quietMode"`
+}
+
+func DefaultChatPreferences(chatId string) *ChatPreferences {
+	return &ChatPreferences{
+		ChatID:     chatId,
+		NotifyMode: NotifyModeBoth,
+		QuietMode:  QuietModeDrop,
+	}
+}
+
+func (p *ChatPreferences) Allows(notificationType NotificationType) bool {
+	switch p.NotifyMode {
+	case NotifyModeEntry:
+		return notificationType != NotificationTypeExit
+	case NotifyModeExit:
+		return notificationType != NotificationTypeEntry
+	default:
+		return true
+	}
+}
+
+// InQuietHours reports whether t falls inside the quiet hours window, evaluated
+// in the given location. Windows crossing midnight (22:00-07:00) are supported.
+func (p *ChatPreferences) InQuietHours(t time.Time, location *time.Location) bool {
+	if p.QuietHoursStart == nil || p.QuietHoursEnd == nil {
+		return false
+	}
+
+	return inTimeWindow(t.In(location), *p.QuietHoursStart, *p.QuietHoursEnd)
+}
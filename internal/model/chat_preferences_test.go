@@ -0,0 +1,47 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatPreferences_InQuietHours(t *testing.T) {
+	santiago := time.FixedZone("GMT-3", -3*60*60)
+	at := func(hour int, minute int) time.Time {
+		return time.Date(2025, 10, 5, hour, minute, 0, 0, time.UTC)
+	}
+	window := func(start string, end string) *ChatPreferences {
+		return &ChatPreferences{QuietHoursStart: &start, QuietHoursEnd: &end}
+	}
+
+	tests := []struct {
+		name        string
+		preferences *ChatPreferences
+		t           time.Time
+		location    *time.Location
+		expected    bool
+	}{
+		{"no quiet hours", DefaultChatPreferences("chat1"), at(3, 0), time.UTC, false},
+		{"only start", &ChatPreferences{QuietHoursStart: new(string)}, at(3, 0), time.UTC, false},
+		{"inside same day window", window("13:00", "15:00"), at(14, 0), time.UTC, true},
+		{"start is inclusive", window("13:00", "15:00"), at(13, 0), time.UTC, true},
+		{"end is exclusive", window("13:00", "15:00"), at(15, 0), time.UTC, false},
+		{"before same day window", window("13:00", "15:00"), at(12, 59), time.UTC, false},
+		{"before midnight", window("22:00", "07:00"), at(23, 30), time.UTC, true},
+		{"after midnight", window("22:00", "07:00"), at(3, 0), time.UTC, true},
+		{"end of overnight window", window("22:00", "07:00"), at(7, 0), time.UTC, false},
+		{"outside overnight window", window("22:00", "07:00"), at(12, 0), time.UTC, false},
+		{"evaluated in the location", window("22:00", "07:00"), at(1, 30), santiago, true},
+		{"outside in the location", window("22:00", "07:00"), at(23, 0), santiago, false},
+		{"empty window", window("22:00", "22:00"), at(22, 0), time.UTC, false},
+		{"invalid time", window("25:00", "07:00"), at(3, 0), time.UTC, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.preferences.InQuietHours(test.t, test.location))
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+type CircuitState string
+
+const (
+	CircuitStateClosed   CircuitState = "CLOSED"
+	CircuitStateOpen     CircuitState = "OPEN"
+	CircuitStateHalfOpen CircuitState = "HALF_OPEN"
+)
+
+// CircuitBreakerStats is the state of the circuit breaker of a dependency,
+// reported on the health endpoint.
+type CircuitBreakerStats struct {
+	Name                string       `json:"name"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	OpenedAt            *time.Time   `json:"openedAt"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// DeadLetter is a notification that could not be delivered, either because
+// the webhook rejected it permanently or because the retries were exhausted.
+type DeadLetter struct {
+	ID         int64                `json:"id"`
+	Request    *NotificationRequest `json:"request"`
+	Attempts   int                  `json:"attempts"`
+	LastError  string               `json:"lastError"`
+	StatusCode *int                 `json:"statusCode"`
+	CreatedAt  time.Time            `json:"createdAt"`
+	ReplayedAt *time.Time           `json:"replayedAt"`
+}
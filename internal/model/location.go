@@ -0,0 +1,11 @@
+package model
+
+const UnknownLocationName = "Unknown Location"
+
+type Location struct {
+	Code     int8    `json:"code"`
+	Name     string  `json:"name"`
+	Address  *string `json:"address"`
+	TimeZone *string `json:"timeZone"`
+	Active   bool    `json:"active"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type NotificationStatus string
+
+const (
+	NotificationStatusQueued    NotificationStatus = "QUEUED"
+	NotificationStatusDelivered NotificationStatus = "DELIVERED"
+	NotificationStatusFailed    NotificationStatus = "FAILED"
+	// Not delivered because of the chat preferences (notify mode or quiet hours)
+	NotificationStatusSuppressed NotificationStatus = "SUPPRESSED"
+	// Held during quiet hours, sent later as part of a summary
+	NotificationStatusBatched NotificationStatus = "BATCHED"
+)
+
+type NotificationLog struct {
+	ID           int64              `json:"id"`
+	Type         NotificationType   `json:"type"`
+	ChatID       string             `json:"chatId"`
+	Run          string             `json:"run"`
+	FullName     string             `json:"fullName"`
+	Alias        *string            `json:"alias"`
+	Location     int8               `json:"location"`
+	LocationName string             `json:"locationName"`
+	Date         time.Time          `json:"date"`
+	Status       NotificationStatus `json:"status"`
+	Attempts     int                `json:"attempts"`
+	LastError    *string            `json:"lastError"`
+	CreatedAt    time.Time          `json:"createdAt"`
+	UpdatedAt    time.Time          `json:"updatedAt"`
+}
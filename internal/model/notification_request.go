@@ -1,12 +1,20 @@
 package model
 
-import "time"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
 
 type NotificationType int8
 
 const (
 	NotificationTypeEntry = iota + 1
 	NotificationTypeExit
+	NotificationTypeLongStay
+	NotificationTypeInactive
 )
 
 func (t NotificationType) String() string {
@@ -15,31 +23,34 @@ func (t NotificationType) String() string {
 		return "ENTRY"
 	case NotificationTypeExit:
 		return "EXIT"
+	case NotificationTypeLongStay:
+		return "LONG_STAY"
+	case NotificationTypeInactive:
+		return "INACTIVE"
 	default:
 		return "UNKNOWN"
 	}
 }
 
-func (n NotificationRequest) LocationName() string {
-	switch n.Location {
-	case 102:
-		return "Espacio Urbano"
-	case 104:
-		return "Calama"
-	case 105:
-		return "Pacífico"
-	case 106:
-		return "Arauco"
-	case 107:
-		return "Iquique"
-	case 108:
-		return "Angamos"
+// ParseNotificationType accepts the type name (ENTRY, EXIT, ...) or its numeric value.
+func ParseNotificationType(value string) (NotificationType, bool) {
+	switch strings.ToUpper(value) {
+	case "ENTRY", "1":
+		return NotificationTypeEntry, true
+	case "EXIT", "2":
+		return NotificationTypeExit, true
+	case "LONG_STAY", "3":
+		return NotificationTypeLongStay, true
+	case "INACTIVE", "4":
+		return NotificationTypeInactive, true
 	default:
-		return "Unknown Location"
+		return 0, false
 	}
 }
 
 type NotificationRequest struct {
+	// Deterministic ID used to deliver the notification at most once, see EnsureID
+	ID       string           `json:"id"`
 	Type     NotificationType `json:"type"`
 	Date     time.Time        `json:"date"`
 	ChatID   string           `json:"chatId"`
@@ -47,4 +58,20 @@ type NotificationRequest struct {
 	FullName string           `json:"fullName"`
 	Alias    *string          `json:"alias"`
 	Location int8             `json:"location"`
+
+	// Hours inside when a long stay alert fired
+	Hours int `json:"hours,omitempty"`
+	// Days without an entry when an inactivity reminder fired
+	Days int `json:"days,omitempty"`
+}
+
+// EnsureID sets the ID from the chat, RUN, type and event timestamp when it is
+// missing, so every publication of the same event carries the same ID.
+func (n *NotificationRequest) EnsureID() string {
+	if n.ID == "" {
+		key := fmt.Sprintf("%s|%s|%d|%s", n.ChatID, strings.ToUpper(n.Run), n.Type, n.Date.UTC().Format(time.RFC3339Nano))
+		sum := sha256.Sum256([]byte(key))
+		n.ID = hex.EncodeToString(sum[:16])
+	}
+	return n.ID
 }
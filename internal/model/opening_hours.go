@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// OpeningHours is the daily HH:MM window in which a location is open, it may
+// cross midnight.
+type OpeningHours struct {
+	Start string
+	End   string
+}
+
+// IsOpen reports whether t, already in the location zone, is inside the window.
+func (o OpeningHours) IsOpen(t time.Time) bool {
+	return inTimeWindow(t, o.Start, o.End)
+}
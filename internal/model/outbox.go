@@ -0,0 +1,19 @@
+package model
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "PENDING"
+	OutboxStatusSent    OutboxStatus = "SENT"
+	// OutboxStatusFailed marks rows that can never be relayed, like a payload
+	// that can't be decoded.
+	OutboxStatusFailed OutboxStatus = "FAILED"
+)
+
+type OutboxMessage struct {
+	ID       int64                `json:"id"`
+	Request  *NotificationRequest `json:"request"`
+	Attempts int                  `json:"attempts"`
+	// PayloadError is set instead of Request when the payload can't be decoded
+	PayloadError error `json:"-"`
+}
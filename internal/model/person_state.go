@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// PersonState is the last entry and exit seen for a person, shared by every
+// track that follows it.
+type PersonState struct {
+	ExternalID int32
+	LastEntry  *time.Time
+	LastExit   *time.Time
+}
@@ -0,0 +1,13 @@
+package model
+
+// SigningKey is a shared secret used to sign requests with HMAC, identified
+// by ID so several keys can be active while they are rotated.
+type SigningKey struct {
+	ID     string
+	Secret string
+}
+
+// String hides the secret when the configuration is printed.
+func (k SigningKey) String() string {
+	return k.ID + "=***"
+}
@@ -0,0 +1,14 @@
+package model
+
+type VisitStats struct {
+	Run                   string  `json:"run"`
+	FullName              string  `json:"fullName"`
+	Alias                 *string `json:"alias"`
+	Visits                int     `json:"visits"`
+	TotalMinutes          int64   `json:"totalMinutes"`
+	AverageMinutes        int64   `json:"averageMinutes"`
+	FavouriteLocation     *int8   `json:"favouriteLocation"`
+	FavouriteLocationName *string `json:"favouriteLocationName"`
+	CurrentStreak         int     `json:"currentStreak"`
+	LongestStreak         int     `json:"longestStreak"`
+}
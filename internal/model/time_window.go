@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// inTimeWindow reports whether the clock time of t is inside [start, end),
+// both written as HH:MM. Windows crossing midnight (22:00-07:00) are supported
+// and an empty window (start == end) never matches.
+func inTimeWindow(t time.Time, start string, end string) bool {
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return false
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	startMinute := startTime.Hour()*60 + startTime.Minute()
+	endMinute := endTime.Hour()*60 + endTime.Minute()
+
+	if startMinute == endMinute {
+		return false
+	}
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
@@ -11,4 +11,104 @@ type Track struct {
 	Alias      *string    `json:"alias"`
 	LastEntry  *time.Time `json:"lastEntry"`
 	LastExit   *time.Time `json:"lastExit"`
+
+	// Location of the last entry, nil when it isn't known
+	LastEntryLocation *int8 `json:"lastEntryLocation"`
+
+	// Optional notification filters, nil/empty means no restriction
+	NotifyType *string           `json:"notifyType"`
+	Locations  []int8            `json:"locations"`
+	Schedule   []*ScheduleWindow `json:"schedule"`
+
+	// Hours inside before a long stay alert, nil uses LONG_STAY_HOURS and 0
+	// disables the alert for this track
+	LongStayHours *int `json:"longStayHours"`
+
+	// Days without an entry before an inactivity reminder, nil disables it
+	InactiveDays *int `json:"inactiveDays"`
+}
+
+// ScheduleWindow restricts notifications to a time range (HH:MM) on the given
+// days of the week (0 = Sunday). No days means every day and no range means
+// the whole day.
+type ScheduleWindow struct {
+	Days  []time.Weekday `json:"days" validate:"dive,min=0,max=6"`
+	Start string         `json:"start" validate:"required_with=End,omitempty,datetime=15:04"`
+	End   string         `json:"end" validate:"required_with=Start,omitempty,datetime=15:04"`
+}
+
+// Matches reports whether the track filters allow a notification of the given
+// type, at the given location and time (already in the local zone).
+func (t *Track) Matches(notificationType NotificationType, location int8, date time.Time) bool {
+	switch {
+	case t.NotifyType == nil:
+	case notificationType == NotificationTypeLongStay:
+		// Long stay alerts are enabled through their own threshold
+	case *t.NotifyType == NotifyModeEntry && notificationType != NotificationTypeEntry:
+		return false
+	case *t.NotifyType == NotifyModeExit && notificationType != NotificationTypeExit:
+		return false
+	}
+
+	if len(t.Locations) > 0 {
+		found := false
+		for _, l := range t.Locations {
+			if l == location {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(t.Schedule) == 0 {
+		return true
+	}
+
+	for _, window := range t.Schedule {
+		if window.includes(date) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *ScheduleWindow) includes(date time.Time) bool {
+	if len(w.Days) > 0 {
+		found := false
+		for _, day := range w.Days {
+			if day == date.Weekday() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if w.Start == "" && w.End == "" {
+		return true
+	}
+	return inTimeWindow(date, w.Start, w.End)
+}
+
+// LongStayCandidate is a track with an open visit, along with the threshold
+// that applies to it and the location of the entry.
+type LongStayCandidate struct {
+	Track          *Track
+	ThresholdHours int
+	Location       int8
+}
+
+// InactiveCandidate is a track with inactivity reminders enabled. Since is
+// the start of the current inactivity period (last entry, or the track
+// creation when there was none) and AlertedAt the last reminder sent.
+type InactiveCandidate struct {
+	Track     *Track
+	Since     time.Time
+	AlertedAt *time.Time
+	Location  int8
 }
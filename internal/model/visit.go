@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Visit groups the entry and exit events that share the same EntryAt.
+type Visit struct {
+	EntryAt         time.Time  `json:"entryAt"`
+	ExitAt          *time.Time `json:"exitAt"`
+	DurationMinutes *int64     `json:"durationMinutes"`
+	Location        int8       `json:"location"`
+	LocationName    string     `json:"locationName"`
+}
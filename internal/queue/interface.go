@@ -0,0 +1,18 @@
+package queue
+
+import (
+	"context"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+)
+
+// NotificationHandler processes a single notification taken from the queue.
+// Returning an error asks the queue to redeliver the message later, failed
+// deliveries are retried and dead-lettered by the handler itself.
+type NotificationHandler func(ctx context.Context, request *model.NotificationRequest) *errors.AppError
+
+type NotificationQueue interface {
+	Publish(ctx context.Context, request *model.NotificationRequest) *errors.AppError
+	Consume(ctx context.Context, handler NotificationHandler) *errors.AppError
+	Close() error
+}
@@ -0,0 +1,79 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"sync"
+	"time"
+)
+
+const (
+	memoryQueueSize       = 1000
+	memoryQueueRetryDelay = 5 * time.Second
+)
+
+// memoryQueueImpl is an in-process queue backed by a buffered channel. Messages
+// are lost on restart, so it is meant for local development and tests.
+type memoryQueueImpl struct {
+	messages  chan *model.NotificationRequest
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewMemoryQueueImpl() NotificationQueue {
+	return &memoryQueueImpl{
+		messages: make(chan *model.NotificationRequest, memoryQueueSize),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (q *memoryQueueImpl) Publish(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
+	select {
+	case <-q.closed:
+		return q.error(fmt.Errorf("queue is closed"))
+	default:
+	}
+
+	select {
+	case q.messages <- request:
+		return nil
+	case <-ctx.Done():
+		return q.error(ctx.Err())
+	case <-q.closed:
+		return q.error(fmt.Errorf("queue is closed"))
+	}
+}
+
+func (q *memoryQueueImpl) Consume(ctx context.Context, handler NotificationHandler) *errors.AppError {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-q.closed:
+			return nil
+		case request := <-q.messages:
+			// A message taken from the channel is finished even on shutdown
+			if err := handler(context.WithoutCancel(ctx), request); err != nil {
+				log.Printf("%v\n", err)
+				// Redeliver later, the same way a Nack does on Pub/Sub
+				time.AfterFunc(memoryQueueRetryDelay, func() {
+					q.Publish(context.Background(), request)
+				})
+			}
+		}
+	}
+}
+
+func (q *memoryQueueImpl) Close() error {
+	q.closeOnce.Do(func() {
+		close(q.closed)
+	})
+	return nil
+}
+
+func (q *memoryQueueImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("MemoryQueue", err)
+}
@@ -0,0 +1,126 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"spl-notification/internal/config"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+)
+
+const pubSubQueueRetryDelay = 10 * time.Second
+
+type pubSubQueueImpl struct {
+	pubsubClient       *pubsub.Client
+	pubsubTopic        *pubsub.Topic
+	pubsubSubscription *pubsub.Subscription
+	retryDelay         time.Duration
+}
+
+func NewPubSubQueueImpl(enviromentConfig *config.EnvironmentConfig) (NotificationQueue, error) {
+	// Inicializar cliente de Pub/Sub
+	pubsubClient, err := pubsub.NewClient(context.Background(), enviromentConfig.PubSubProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear cliente de Pub/Sub: %w", err)
+	}
+
+	return &pubSubQueueImpl{
+		pubsubClient:       pubsubClient,
+		pubsubTopic:        pubsubClient.Topic(enviromentConfig.PubSubTopicID),
+		pubsubSubscription: pubsubClient.Subscription(enviromentConfig.PubSubSubscriptionID),
+		retryDelay:         pubSubQueueRetryDelay,
+	}, nil
+}
+
+func (q *pubSubQueueImpl) Publish(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
+	messageData, err := json.Marshal(request)
+	if err != nil {
+		return q.error(fmt.Errorf("error serializing notification: %w", err))
+	}
+
+	msg := &pubsub.Message{
+		Data: messageData,
+		Attributes: map[string]string{
+			"id":       request.EnsureID(),
+			"type":     request.Type.String(),
+			"chatId":   request.ChatID,
+			"run":      request.Run,
+			"location": fmt.Sprintf("%d", request.Location),
+		},
+	}
+
+	// Esperar a que se complete la publicación
+	_, err = q.pubsubTopic.Publish(ctx, msg).Get(ctx)
+	if err != nil {
+		return q.error(fmt.Errorf("error publishing message to Pub/Sub: %w", err))
+	}
+
+	return nil
+}
+
+func (q *pubSubQueueImpl) Consume(ctx context.Context, handler NotificationHandler) *errors.AppError {
+	err := q.pubsubSubscription.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		var notificationRequest model.NotificationRequest
+		if err := json.Unmarshal(msg.Data, &notificationRequest); err != nil {
+			// A redelivery can't decode it either, acknowledge it so it is
+			// discarded instead of redelivered forever
+			log.Printf("Error deserializing message %s, discarding it: %v: %s\n", msg.ID, err, msg.Data)
+			msg.Ack()
+			return
+		}
+
+		// Receive waits for in-flight handlers when ctx is cancelled, they
+		// run detached so the message is acknowledged instead of redelivered
+		if err := handler(context.WithoutCancel(ctx), &notificationRequest); err != nil {
+			log.Printf("%v\n", err)
+			q.nackLater(ctx, msg)
+			return
+		}
+
+		// Message sended with success
+		msg.Ack()
+	})
+	if err != nil {
+		return q.error(fmt.Errorf("error in Pub/Sub subscription: %w", err))
+	}
+
+	return nil
+}
+
+// nackLater holds a rejected message for the retry delay before the Nack, which
+// is redelivered right away: otherwise a message failing while a dependency is
+// down, as when the gateway breaker is open, is redelivered in a tight loop.
+// Held messages count against the flow control, so pulling slows down too. On
+// shutdown the message is released at once.
+func (q *pubSubQueueImpl) nackLater(ctx context.Context, msg *pubsub.Message) {
+	timer := time.NewTimer(q.retryDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	msg.Nack()
+}
+
+func (q *pubSubQueueImpl) Close() error {
+	// Detener el topic para que no acepte más publicaciones
+	q.pubsubTopic.Stop()
+
+	// Cerrar el cliente de Pub/Sub
+	err := q.pubsubClient.Close()
+	if err != nil {
+		return fmt.Errorf("error al cerrar cliente de Pub/Sub: %w", err)
+	}
+
+	return nil
+}
+
+func (q *pubSubQueueImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("PubSubQueue", err)
+}
@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newTestPubSubQueue returns a queue backed by an in-process Pub/Sub fake.
+func newTestPubSubQueue(t *testing.T, retryDelay time.Duration) (*pubSubQueueImpl, *pstest.Server) {
+	t.Helper()
+	ctx := context.Background()
+
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+
+	client, err := pubsub.NewClient(ctx, "project",
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	require.NoError(t, err)
+
+	topic, err := client.CreateTopic(ctx, "notifications")
+	require.NoError(t, err)
+	subscription, err := client.CreateSubscription(ctx, "notifications-sub", pubsub.SubscriptionConfig{Topic: topic})
+	require.NoError(t, err)
+
+	q := &pubSubQueueImpl{
+		pubsubClient:       client,
+		pubsubTopic:        topic,
+		pubsubSubscription: subscription,
+		retryDelay:         retryDelay,
+	}
+	t.Cleanup(func() { q.Close() })
+	return q, srv
+}
+
+func TestPubSubQueue_DelaysRedeliveryOfRejectedMessages(t *testing.T) {
+	retryDelay := 300 * time.Millisecond
+	q, _ := newTestPubSubQueue(t, retryDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	require.Nil(t, q.Publish(ctx, &model.NotificationRequest{ChatID: "chat1", Run: "12345678-9"}))
+
+	var mu sync.Mutex
+	var deliveries []time.Time
+	err := q.Consume(ctx, func(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
+		mu.Lock()
+		defer mu.Unlock()
+
+		deliveries = append(deliveries, time.Now())
+		if len(deliveries) == 1 {
+			return errors.NewAppError("Test", fmt.Errorf("circuit breaker notification is open"))
+		}
+		cancel()
+		return nil
+	})
+	require.Nil(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, deliveries, 2)
+	assert.GreaterOrEqual(t, deliveries[1].Sub(deliveries[0]), retryDelay)
+}
+
+func TestPubSubQueue_DiscardsUndecodableMessages(t *testing.T) {
+	q, srv := newTestPubSubQueue(t, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := q.pubsubTopic.Publish(ctx, &pubsub.Message{Data: []byte("not json")}).Get(ctx)
+	require.NoError(t, err)
+
+	done := make(chan *errors.AppError)
+	go func() {
+		done <- q.Consume(ctx, func(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
+			t.Errorf("unexpected notification %+v", request)
+			return nil
+		})
+	}()
+
+	// Acknowledged on the first delivery instead of redelivered
+	assert.Eventually(t, func() bool {
+		messages := srv.Messages()
+		return len(messages) == 1 && messages[0].Acks == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	require.Nil(t, <-done)
+	assert.Equal(t, 1, srv.Messages()[0].Deliveries)
+}
+
+func TestPubSubQueue_ReleasesHeldMessagesOnShutdown(t *testing.T) {
+	q, _ := newTestPubSubQueue(t, time.Hour)
+
+	require.Nil(t, q.Publish(context.Background(), &model.NotificationRequest{ChatID: "chat1", Run: "12345678-9"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan struct{})
+	var receivedOnce sync.Once
+	done := make(chan *errors.AppError)
+	go func() {
+		done <- q.Consume(ctx, func(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
+			receivedOnce.Do(func() { close(received) })
+			return errors.NewAppError("Test", fmt.Errorf("circuit breaker notification is open"))
+		})
+	}()
+
+	<-received
+	cancel()
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the held message blocked the shutdown")
+	}
+}
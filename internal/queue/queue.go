@@ -0,0 +1,31 @@
+package queue
+
+import (
+	"database/sql"
+	"fmt"
+	"spl-notification/internal/config"
+)
+
+const (
+	QueueTypePubSub = "pubsub"
+	QueueTypeMemory = "memory"
+	QueueTypeSQLite = "sqlite"
+)
+
+// NewNotificationQueue builds the notification transport selected by
+// NOTIFICATION_QUEUE, so the service can run without GCP credentials.
+func NewNotificationQueue(
+	enviromentConfig *config.EnvironmentConfig,
+	db *sql.DB,
+) (NotificationQueue, error) {
+	switch enviromentConfig.NotificationQueue {
+	case QueueTypePubSub, "":
+		return NewPubSubQueueImpl(enviromentConfig)
+	case QueueTypeMemory:
+		return NewMemoryQueueImpl(), nil
+	case QueueTypeSQLite:
+		return NewSQLiteQueueImpl(db), nil
+	default:
+		return nil, fmt.Errorf("unknown notification queue: %s", enviromentConfig.NotificationQueue)
+	}
+}
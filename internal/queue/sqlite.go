@@ -0,0 +1,179 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"time"
+)
+
+const (
+	sqliteQueuePollInterval = 1 * time.Second
+	sqliteQueueBatchSize    = 20
+	sqliteQueueRetryDelay   = 10 * time.Second
+)
+
+type queuedNotification struct {
+	id      int64
+	request *model.NotificationRequest
+}
+
+// sqliteQueueImpl stores pending notifications in the notification_queue table,
+// so messages survive restarts without an external broker.
+type sqliteQueueImpl struct {
+	db *sql.DB
+}
+
+func NewSQLiteQueueImpl(db *sql.DB) NotificationQueue {
+	return &sqliteQueueImpl{db: db}
+}
+
+func (q *sqliteQueueImpl) Publish(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return q.error(err)
+	}
+
+	query := `
+		INSERT INTO notification_queue (payload, available_at)
+		VALUES (?, ?)
+	`
+
+	_, err = q.db.ExecContext(ctx, query, string(payload), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return q.error(err)
+	}
+
+	return nil
+}
+
+func (q *sqliteQueueImpl) Consume(ctx context.Context, handler NotificationHandler) *errors.AppError {
+	ticker := time.NewTicker(sqliteQueuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		messages, err := q.getAvailable(ctx)
+		if err != nil {
+			log.Printf("%v\n", err)
+			continue
+		}
+
+		for _, message := range messages {
+			// On shutdown the remaining messages stay queued for the next start
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			// The message being handled is finished even if ctx is cancelled
+			messageCtx := context.WithoutCancel(ctx)
+			if err := handler(messageCtx, message.request); err != nil {
+				log.Printf("%v\n", err)
+				if err := q.retry(messageCtx, message.id); err != nil {
+					log.Printf("%v\n", err)
+				}
+				continue
+			}
+
+			if err := q.delete(messageCtx, message.id); err != nil {
+				log.Printf("%v\n", err)
+			}
+		}
+	}
+}
+
+// getAvailable returns the messages due for delivery. A message whose payload
+// can't be decoded is logged and deleted, it would otherwise stay first in
+// line and hold back the ones behind it.
+func (q *sqliteQueueImpl) getAvailable(ctx context.Context) ([]*queuedNotification, *errors.AppError) {
+	query := `
+		SELECT id, payload
+		FROM notification_queue
+		WHERE available_at <= ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, time.Now().UTC().Format(time.RFC3339), sqliteQueueBatchSize)
+	if err != nil {
+		return nil, q.error(err)
+	}
+	defer rows.Close()
+
+	var messages []*queuedNotification
+	var undecodable []int64
+	for rows.Next() {
+		var id int64
+		var payload string
+		if err := rows.Scan(&id, &payload); err != nil {
+			return nil, q.error(err)
+		}
+
+		request := &model.NotificationRequest{}
+		if err := json.Unmarshal([]byte(payload), request); err != nil {
+			log.Printf("Error deserializing message %d, discarding it: %v: %s\n", id, err, payload)
+			undecodable = append(undecodable, id)
+			continue
+		}
+
+		messages = append(messages, &queuedNotification{id: id, request: request})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, q.error(err)
+	}
+	rows.Close()
+
+	for _, id := range undecodable {
+		if err := q.delete(ctx, id); err != nil {
+			log.Printf("%v\n", err)
+		}
+	}
+
+	return messages, nil
+}
+
+func (q *sqliteQueueImpl) retry(ctx context.Context, id int64) *errors.AppError {
+	query := `
+		UPDATE notification_queue
+		SET attempts = attempts + 1, available_at = ?
+		WHERE id = ?
+	`
+
+	availableAt := time.Now().UTC().Add(sqliteQueueRetryDelay).Format(time.RFC3339)
+	if _, err := q.db.ExecContext(ctx, query, availableAt, id); err != nil {
+		return q.error(err)
+	}
+
+	return nil
+}
+
+func (q *sqliteQueueImpl) delete(ctx context.Context, id int64) *errors.AppError {
+	query := `
+		DELETE FROM notification_queue
+		WHERE id = ?
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, id); err != nil {
+		return q.error(err)
+	}
+
+	return nil
+}
+
+func (q *sqliteQueueImpl) Close() error {
+	// The database connection is owned and closed by the database module
+	return nil
+}
+
+func (q *sqliteQueueImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("SQLiteQueue", err)
+}
@@ -0,0 +1,64 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDB returns a SQLite database with every migration applied.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db")+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	goose.SetLogger(goose.NopLogger())
+	if err := goose.SetDialect("sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	if err := goose.Up(db, "../../migrations"); err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestSQLiteQueue_DiscardsUndecodableMessages(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	queue := NewSQLiteQueueImpl(db).(*sqliteQueueImpl)
+
+	// More undecodable rows than a batch, ahead of a valid message
+	availableAt := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	for i := 0; i < sqliteQueueBatchSize+5; i++ {
+		_, err := db.Exec(`INSERT INTO notification_queue (payload, available_at) VALUES (?, ?)`, "not json", availableAt)
+		require.NoError(t, err)
+	}
+	require.Nil(t, queue.Publish(ctx, &model.NotificationRequest{ChatID: "chat1", Run: "12345678-9"}))
+
+	messages, err := queue.getAvailable(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = queue.getAvailable(ctx)
+	require.Nil(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "chat1", messages[0].request.ChatID)
+
+	// Only the valid message is left
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM notification_queue`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
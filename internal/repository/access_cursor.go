@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/errors"
+)
+
+type accessCursorRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAccessCursorRepositoryImpl(db *sql.DB) AccessCursorRepository {
+	return &accessCursorRepositoryImpl{db: db}
+}
+
+// Get returns the last cursor stored for the source, or nil if there is none.
+func (r *accessCursorRepositoryImpl) Get(ctx context.Context, source string) (*string, *errors.AppError) {
+	query := `SELECT cursor FROM access_cursor WHERE source = ?`
+
+	var cursor string
+	err := r.db.QueryRowContext(ctx, query, source).Scan(&cursor)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	return &cursor, nil
+}
+
+func (r *accessCursorRepositoryImpl) Save(ctx context.Context, source string, cursor string) *errors.AppError {
+	query := `
+		INSERT INTO access_cursor (source, cursor) VALUES (?, ?)
+		ON CONFLICT(source) DO UPDATE SET
+			cursor = excluded.cursor,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, source, cursor); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *accessCursorRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("AccessCursorRepository", err)
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"time"
+)
+
+const visitTables = `access_event
+		JOIN track ON track.external_id = access_event.external_id`
+
+type accessEventRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAccessEventRepositoryImpl(db *sql.DB) AccessEventRepository {
+	return &accessEventRepositoryImpl{db: db}
+}
+
+// GetVisits returns the visits of the person followed by the track that
+// started once it was followed, the events recorded before belong to other
+// followers.
+func (r *accessEventRepositoryImpl) GetVisits(
+	ctx context.Context,
+	trackId int,
+	from *time.Time,
+	to *time.Time,
+	limit int,
+	offset int,
+) ([]*model.Visit, int, *errors.AppError) {
+	// created_at is stored as CURRENT_TIMESTAMP, compared in the RFC 3339
+	// format of the events
+	where := `
+		WHERE track.id = ?
+			AND access_event.entry_at >= strftime('%Y-%m-%dT%H:%M:%SZ', track.created_at)`
+	args := []interface{}{trackId}
+
+	if from != nil {
+		where += " AND access_event.entry_at >= ?"
+		args = append(args, from.UTC().Format(time.RFC3339))
+	}
+	if to != nil {
+		where += " AND access_event.entry_at <= ?"
+		args = append(args, to.UTC().Format(time.RFC3339))
+	}
+
+	var total int
+	countQuery := `SELECT COUNT(DISTINCT access_event.entry_at) FROM ` + visitTables + where
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, r.error(err)
+	}
+
+	query := `
+		SELECT
+			access_event.entry_at,
+			MAX(CASE WHEN access_event.type = ? THEN access_event.event_date END) AS exit_at,
+			MAX(access_event.location) AS location
+		FROM ` + visitTables + where + `
+		GROUP BY access_event.entry_at
+		ORDER BY access_event.entry_at DESC
+		LIMIT ? OFFSET ?
+	`
+	queryArgs := append([]interface{}{model.NotificationTypeExit}, args...)
+	queryArgs = append(queryArgs, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, 0, r.error(err)
+	}
+	defer rows.Close()
+
+	var visits []*model.Visit
+	for rows.Next() {
+		visit := &model.Visit{}
+
+		var entryAtStr string
+		var exitAtStr sql.NullString
+		err := rows.Scan(&entryAtStr, &exitAtStr, &visit.Location)
+		if err != nil {
+			return nil, 0, r.error(err)
+		}
+
+		visit.EntryAt, err = time.Parse(time.RFC3339, entryAtStr)
+		if err != nil {
+			return nil, 0, r.error(err)
+		}
+
+		if exitAtStr.Valid {
+			exitAt, err := time.Parse(time.RFC3339, exitAtStr.String)
+			if err != nil {
+				return nil, 0, r.error(err)
+			}
+			visit.ExitAt = &exitAt
+
+			duration := int64(exitAt.Sub(visit.EntryAt).Minutes())
+			visit.DurationMinutes = &duration
+		}
+
+		visits = append(visits, visit)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, r.error(err)
+	}
+
+	return visits, total, nil
+}
+
+func (r *accessEventRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("AccessEventRepository", err)
+}
+
+// insertAccessEvents records the detected entries and exits inside the
+// caller's transaction. Every event keeps the EntryAt of its visit so entries
+// and exits can be paired later.
+func insertAccessEvents(ctx context.Context, tx *sql.Tx, entryAccesses []*model.Access, exitAccesses []*model.Access) error {
+	if len(entryAccesses) == 0 && len(exitAccesses) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO access_event (
+			external_id, run, type, location, event_date, entry_at
+		) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(external_id, type, event_date) DO NOTHING
+	`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, access := range entryAccesses {
+		entryAt := access.EntryAt.UTC().Format(time.RFC3339)
+		_, err := stmt.ExecContext(ctx, access.ExternalID, access.Run, model.NotificationTypeEntry, access.Location, entryAt, entryAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, access := range exitAccesses {
+		entryAt := access.EntryAt.UTC().Format(time.RFC3339)
+		exitAt := access.ExitAt.UTC().Format(time.RFC3339)
+		_, err := stmt.ExecContext(ctx, access.ExternalID, access.Run, model.NotificationTypeExit, access.Location, exitAt, entryAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
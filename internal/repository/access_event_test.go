@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func insertTestAccessEvents(t *testing.T, db *sql.DB, entryAccesses []*model.Access, exitAccesses []*model.Access) {
+	t.Helper()
+
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	require.NoError(t, insertAccessEvents(context.Background(), tx, entryAccesses, exitAccesses))
+	require.NoError(t, tx.Commit())
+}
+
+// createTestTrack follows the person since the given time.
+func createTestTrack(t *testing.T, db *sql.DB, chatID string, externalID int32, createdAt time.Time) int {
+	t.Helper()
+
+	track, err := NewTrackRepositoryImpl(db).Create(context.Background(), &request.CreateTrackDTO{
+		ChatID: chatID, ExternalID: externalID, Run: "12345678-9", FullName: "John Doe",
+	})
+	require.Nil(t, err)
+	_, dbErr := db.Exec(`UPDATE track SET created_at = ? WHERE id = ?`, createdAt.UTC().Format(time.DateTime), track.ID)
+	require.NoError(t, dbErr)
+	return track.ID
+}
+
+func TestAccessEventRepository_GetVisits(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewAccessEventRepositoryImpl(db)
+
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2025, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+	access := func(externalID int32, location int8, entryAt time.Time, exitAt *time.Time) *model.Access {
+		return &model.Access{ExternalID: externalID, Run: "12345678-9", Location: location, EntryAt: entryAt, ExitAt: exitAt}
+	}
+	firstExit, secondExit := at(1, 9, 30), at(2, 10, 15)
+
+	// Two finished visits, an open one and a visit of another person
+	insertTestAccessEvents(t, db, []*model.Access{
+		access(1, 102, at(1, 8, 0), nil),
+		access(1, 104, at(2, 8, 0), nil),
+		access(1, 102, at(3, 8, 0), nil),
+		access(2, 106, at(2, 9, 0), nil),
+	}, nil)
+	insertTestAccessEvents(t, db, nil, []*model.Access{
+		access(1, 102, at(1, 8, 0), &firstExit),
+		access(1, 104, at(2, 8, 0), &secondExit),
+	})
+
+	trackID := createTestTrack(t, db, "chat1", 1, at(1, 0, 0))
+	visits, total, err := repo.GetVisits(ctx, trackID, nil, nil, 20, 0)
+	require.Nil(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, visits, 3)
+
+	// Newest first, the open visit has no exit nor duration
+	assert.True(t, visits[0].EntryAt.Equal(at(3, 8, 0)))
+	assert.Nil(t, visits[0].ExitAt)
+	assert.Nil(t, visits[0].DurationMinutes)
+	assert.Equal(t, int8(102), visits[0].Location)
+
+	assert.True(t, visits[1].EntryAt.Equal(at(2, 8, 0)))
+	require.NotNil(t, visits[1].ExitAt)
+	assert.True(t, visits[1].ExitAt.Equal(secondExit))
+	require.NotNil(t, visits[1].DurationMinutes)
+	assert.Equal(t, int64(135), *visits[1].DurationMinutes)
+	assert.Equal(t, int8(104), visits[1].Location)
+
+	assert.True(t, visits[2].EntryAt.Equal(at(1, 8, 0)))
+	require.NotNil(t, visits[2].DurationMinutes)
+	assert.Equal(t, int64(90), *visits[2].DurationMinutes)
+}
+
+func TestAccessEventRepository_GetVisitsBoundsAndPages(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewAccessEventRepositoryImpl(db)
+
+	at := func(day int) time.Time {
+		return time.Date(2025, 10, day, 8, 0, 0, 0, time.UTC)
+	}
+	var entries []*model.Access
+	for day := 1; day <= 5; day++ {
+		entries = append(entries, &model.Access{ExternalID: 1, Run: "12345678-9", Location: 102, EntryAt: at(day)})
+	}
+	insertTestAccessEvents(t, db, entries, nil)
+	trackID := createTestTrack(t, db, "chat1", 1, at(1))
+
+	from, to := at(2), at(4)
+	afterLast := at(6)
+
+	tests := []struct {
+		name     string
+		from     *time.Time
+		to       *time.Time
+		limit    int
+		offset   int
+		total    int
+		expected []time.Time
+	}{
+		{"inclusive bounds", &from, &to, 20, 0, 3, []time.Time{at(4), at(3), at(2)}},
+		{"from only", &to, nil, 20, 0, 2, []time.Time{at(5), at(4)}},
+		{"to only", nil, &from, 20, 0, 2, []time.Time{at(2), at(1)}},
+		{"first page", nil, nil, 2, 0, 5, []time.Time{at(5), at(4)}},
+		{"last page", nil, nil, 2, 4, 5, []time.Time{at(1)}},
+		{"page inside bounds", &from, &to, 2, 2, 3, []time.Time{at(2)}},
+		{"past the last page", nil, nil, 2, 6, 5, nil},
+		{"no visits in bounds", &afterLast, nil, 20, 0, 0, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			visits, total, err := repo.GetVisits(ctx, trackID, test.from, test.to, test.limit, test.offset)
+			require.Nil(t, err)
+			assert.Equal(t, test.total, total)
+
+			var entryDates []time.Time
+			for _, visit := range visits {
+				entryDates = append(entryDates, visit.EntryAt)
+			}
+			assert.Equal(t, test.expected, entryDates)
+		})
+	}
+}
+
+func TestAccessEventRepository_GetVisitsSinceFollowed(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewAccessEventRepositoryImpl(db)
+
+	at := func(day int) time.Time {
+		return time.Date(2025, 10, day, 8, 0, 0, 0, time.UTC)
+	}
+	var entries []*model.Access
+	for day := 1; day <= 4; day++ {
+		entries = append(entries, &model.Access{ExternalID: 1, Run: "12345678-9", Location: 102, EntryAt: at(day)})
+	}
+	insertTestAccessEvents(t, db, entries, nil)
+
+	// Recorded while another chat followed the person
+	earlyID := createTestTrack(t, db, "chat1", 1, at(1))
+	lateID := createTestTrack(t, db, "chat2", 1, at(3).Add(-time.Hour))
+
+	_, total, err := repo.GetVisits(ctx, earlyID, nil, nil, 20, 0)
+	require.Nil(t, err)
+	assert.Equal(t, 4, total)
+
+	visits, total, err := repo.GetVisits(ctx, lateID, nil, nil, 20, 0)
+	require.Nil(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, visits, 2)
+	assert.True(t, visits[0].EntryAt.Equal(at(4)))
+	assert.True(t, visits[1].EntryAt.Equal(at(3)))
+
+	// An unknown track has no visits
+	_, total, err = repo.GetVisits(ctx, lateID+1, nil, nil, 20, 0)
+	require.Nil(t, err)
+	assert.Equal(t, 0, total)
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"strings"
+	"time"
+)
+
+type apiKeyRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewApiKeyRepositoryImpl(db *sql.DB) ApiKeyRepository {
+	return &apiKeyRepositoryImpl{db: db}
+}
+
+const apiKeyColumns = `
+			id,
+			name,
+			key_prefix,
+			key_hash,
+			scopes,
+			chat_ids,
+			expires_at,
+			last_used_at,
+			revoked_at,
+			created_at`
+
+func (r *apiKeyRepositoryImpl) Create(ctx context.Context, apiKey *model.ApiKey) *errors.AppError {
+	query := `
+		INSERT INTO api_key (
+			name, key_prefix, key_hash, scopes, chat_ids, expires_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	var chatIDs *string
+	if apiKey.ChatIDs != nil {
+		joined := strings.Join(apiKey.ChatIDs, ",")
+		chatIDs = &joined
+	}
+
+	var expiresAt *string
+	if apiKey.ExpiresAt != nil {
+		formatted := apiKey.ExpiresAt.UTC().Format(time.RFC3339)
+		expiresAt = &formatted
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		apiKey.Name,
+		apiKey.Prefix,
+		apiKey.Hash,
+		strings.Join(apiKey.Scopes, ","),
+		chatIDs,
+		expiresAt,
+		apiKey.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return r.error(err)
+	}
+
+	if apiKey.ID, err = result.LastInsertId(); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepositoryImpl) GetAll(ctx context.Context) ([]*model.ApiKey, *errors.AppError) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_key
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer rows.Close()
+
+	var apiKeys []*model.ApiKey
+	for rows.Next() {
+		apiKey, err := scanApiKey(rows)
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return apiKeys, nil
+}
+
+func (r *apiKeyRepositoryImpl) GetById(ctx context.Context, id int64) (*model.ApiKey, *errors.AppError) {
+	return r.getBy(ctx, "id", id)
+}
+
+func (r *apiKeyRepositoryImpl) GetByHash(ctx context.Context, hash string) (*model.ApiKey, *errors.AppError) {
+	return r.getBy(ctx, "key_hash", hash)
+}
+
+func (r *apiKeyRepositoryImpl) getBy(ctx context.Context, column string, value any) (*model.ApiKey, *errors.AppError) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_key
+		WHERE ` + column + ` = ?
+	`
+
+	apiKey, err := scanApiKey(r.db.QueryRowContext(ctx, query, value))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	return apiKey, nil
+}
+
+func (r *apiKeyRepositoryImpl) Revoke(ctx context.Context, id int64, revokedAt time.Time) *errors.AppError {
+	query := `
+		UPDATE api_key
+		SET revoked_at = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, revokedAt.UTC().Format(time.RFC3339), id); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *apiKeyRepositoryImpl) UpdateLastUsed(ctx context.Context, id int64, usedAt time.Time) *errors.AppError {
+	query := `
+		UPDATE api_key
+		SET last_used_at = ?
+		WHERE id = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, usedAt.UTC().Format(time.RFC3339), id); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func scanApiKey(row rowScanner) (*model.ApiKey, error) {
+	apiKey := &model.ApiKey{}
+
+	var scopes, createdAt string
+	var chatIDs, expiresAt, lastUsedAt, revokedAt sql.NullString
+	err := row.Scan(
+		&apiKey.ID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		&apiKey.Hash,
+		&scopes,
+		&chatIDs,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.Scopes = strings.Split(scopes, ",")
+	if chatIDs.Valid {
+		apiKey.ChatIDs = strings.Split(chatIDs.String, ",")
+	}
+	if apiKey.CreatedAt, err = parseTimestamp(createdAt); err != nil {
+		return nil, err
+	}
+	if apiKey.ExpiresAt, err = parseNullTimestamp(expiresAt); err != nil {
+		return nil, err
+	}
+	if apiKey.LastUsedAt, err = parseNullTimestamp(lastUsedAt); err != nil {
+		return nil, err
+	}
+	if apiKey.RevokedAt, err = parseNullTimestamp(revokedAt); err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *apiKeyRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("ApiKeyRepository", err)
+}
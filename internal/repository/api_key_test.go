@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiKeyRepository_CreateAndGet(t *testing.T) {
+	ctx := context.Background()
+	repo := NewApiKeyRepositoryImpl(newTestDB(t))
+
+	createdAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	expiresAt := time.Date(2026, 12, 31, 21, 0, 0, 0, time.FixedZone("CLT", -3*60*60))
+	bot := &model.ApiKey{
+		Name:      "bot",
+		Prefix:    "spl_abcd",
+		Hash:      "hash-bot",
+		Scopes:    []string{model.ScopeTracksRead, model.ScopeTracksWrite},
+		ChatIDs:   []string{"chat1", "chat2"},
+		ExpiresAt: &expiresAt,
+		CreatedAt: createdAt,
+	}
+	admin := &model.ApiKey{
+		Name:      "admin",
+		Prefix:    "spl_efgh",
+		Hash:      "hash-admin",
+		Scopes:    []string{model.ScopeAdmin},
+		CreatedAt: createdAt,
+	}
+	require.Nil(t, repo.Create(ctx, bot))
+	require.Nil(t, repo.Create(ctx, admin))
+	assert.NotZero(t, bot.ID)
+	assert.NotEqual(t, bot.ID, admin.ID)
+
+	stored, err := repo.GetByHash(ctx, "hash-bot")
+	require.Nil(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, bot.ID, stored.ID)
+	assert.Equal(t, "spl_abcd", stored.Prefix)
+	assert.Equal(t, []string{model.ScopeTracksRead, model.ScopeTracksWrite}, stored.Scopes)
+	assert.Equal(t, []string{"chat1", "chat2"}, stored.ChatIDs)
+	require.NotNil(t, stored.ExpiresAt)
+	assert.True(t, stored.ExpiresAt.Equal(expiresAt))
+	assert.True(t, stored.CreatedAt.Equal(createdAt))
+	assert.Nil(t, stored.LastUsedAt)
+	assert.Nil(t, stored.RevokedAt)
+
+	// A key without chats is not restricted to any
+	stored, err = repo.GetById(ctx, admin.ID)
+	require.Nil(t, err)
+	require.NotNil(t, stored)
+	assert.Nil(t, stored.ChatIDs)
+	assert.Nil(t, stored.ExpiresAt)
+
+	all, err := repo.GetAll(ctx)
+	require.Nil(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "bot", all[0].Name)
+	assert.Equal(t, "admin", all[1].Name)
+
+	stored, err = repo.GetByHash(ctx, "unknown")
+	require.Nil(t, err)
+	assert.Nil(t, stored)
+
+	stored, err = repo.GetById(ctx, 999)
+	require.Nil(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestApiKeyRepository_DuplicateHashIsRejected(t *testing.T) {
+	ctx := context.Background()
+	repo := NewApiKeyRepositoryImpl(newTestDB(t))
+
+	key := model.ApiKey{Name: "bot", Prefix: "spl_abcd", Hash: "hash-bot", Scopes: []string{model.ScopeTracksRead}, CreatedAt: time.Now()}
+	first, second := key, key
+	require.Nil(t, repo.Create(ctx, &first))
+	assert.NotNil(t, repo.Create(ctx, &second))
+}
+
+func TestApiKeyRepository_RevokeAndUpdateLastUsed(t *testing.T) {
+	ctx := context.Background()
+	repo := NewApiKeyRepositoryImpl(newTestDB(t))
+
+	apiKey := &model.ApiKey{Name: "bot", Prefix: "spl_abcd", Hash: "hash-bot", Scopes: []string{model.ScopeTracksRead}, CreatedAt: time.Now()}
+	require.Nil(t, repo.Create(ctx, apiKey))
+
+	usedAt := time.Date(2026, 10, 17, 13, 0, 0, 0, time.UTC)
+	require.Nil(t, repo.UpdateLastUsed(ctx, apiKey.ID, usedAt))
+
+	revokedAt := time.Date(2026, 10, 17, 14, 0, 0, 0, time.UTC)
+	require.Nil(t, repo.Revoke(ctx, apiKey.ID, revokedAt))
+	// Revoking again keeps the first revocation
+	require.Nil(t, repo.Revoke(ctx, apiKey.ID, revokedAt.Add(time.Hour)))
+
+	stored, err := repo.GetById(ctx, apiKey.ID)
+	require.Nil(t, err)
+	require.NotNil(t, stored.LastUsedAt)
+	assert.True(t, stored.LastUsedAt.Equal(usedAt))
+	require.NotNil(t, stored.RevokedAt)
+	assert.True(t, stored.RevokedAt.Equal(revokedAt))
+	assert.False(t, stored.IsActive(revokedAt.Add(time.Minute)))
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"time"
+)
+
+type auditEventRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAuditEventRepositoryImpl(db *sql.DB) AuditEventRepository {
+	return &auditEventRepositoryImpl{db: db}
+}
+
+// Create stores the event. created_at is written in RFC3339 so the filters
+// on it compare as strings.
+func (r *auditEventRepositoryImpl) Create(ctx context.Context, event *model.AuditEvent) *errors.AppError {
+	query := `
+		INSERT INTO audit_event (
+			actor, api_key_id, action, chat_id, run, before_payload, after_payload, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		event.Actor,
+		event.ApiKeyID,
+		event.Action,
+		event.ChatID,
+		event.Run,
+		nullablePayload(event.Before),
+		nullablePayload(event.After),
+		event.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return r.error(err)
+	}
+
+	if event.ID, err = result.LastInsertId(); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+// GetAll returns the newest events first.
+func (r *auditEventRepositoryImpl) GetAll(ctx context.Context, filter *request.AuditFilterDTO) ([]*model.AuditEvent, *errors.AppError) {
+	query := `
+		SELECT
+			id,
+			actor,
+			api_key_id,
+			action,
+			chat_id,
+			run,
+			before_payload,
+			after_payload,
+			created_at
+		FROM audit_event
+		WHERE 1 = 1
+	`
+	args := []interface{}{}
+
+	if filter.ChatID != nil {
+		query += " AND chat_id = ?"
+		args = append(args, *filter.ChatID)
+	}
+	if filter.From != nil {
+		query += " AND created_at >= ?"
+		args = append(args, filter.From.UTC().Format(time.RFC3339))
+	}
+	if filter.To != nil {
+		query += " AND created_at <= ?"
+		args = append(args, filter.To.UTC().Format(time.RFC3339))
+	}
+
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer rows.Close()
+
+	var events []*model.AuditEvent
+	for rows.Next() {
+		event := &model.AuditEvent{}
+
+		var apiKeyID sql.NullInt64
+		var chatID, run, before, after sql.NullString
+		var createdAt string
+		err := rows.Scan(
+			&event.ID,
+			&event.Actor,
+			&apiKeyID,
+			&event.Action,
+			&chatID,
+			&run,
+			&before,
+			&after,
+			&createdAt,
+		)
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		if apiKeyID.Valid {
+			event.ApiKeyID = &apiKeyID.Int64
+		}
+		if chatID.Valid {
+			event.ChatID = &chatID.String
+		}
+		if run.Valid {
+			event.Run = &run.String
+		}
+		if before.Valid {
+			event.Before = []byte(before.String)
+		}
+		if after.Valid {
+			event.After = []byte(after.String)
+		}
+		if event.CreatedAt, err = parseTimestamp(createdAt); err != nil {
+			return nil, r.error(err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return events, nil
+}
+
+// nullablePayload stores NULL for a missing payload instead of an empty text.
+func nullablePayload(payload []byte) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+	return string(payload)
+}
+
+func (r *auditEventRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("AuditEventRepository", err)
+}
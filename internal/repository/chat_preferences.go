@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+)
+
+type chatPreferencesRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewChatPreferencesRepositoryImpl(db *sql.DB) ChatPreferencesRepository {
+	return &chatPreferencesRepositoryImpl{db: db}
+}
+
+func (r *chatPreferencesRepositoryImpl) GetByChatId(ctx context.Context, chatId string) (*model.ChatPreferences, *errors.AppError) {
+	query := `
+		SELECT
+			chat_id,
+			notify_mode,
+			quiet_hours_start,
+			quiet_hours_end,
+			time_zone,
+			quiet_mode
+		FROM chat_preferences
+		WHERE chat_id = ?
+	`
+
+	preferences := &model.ChatPreferences{}
+
+	var quietHoursStart, quietHoursEnd, timeZone sql.NullString
+	err := r.db.QueryRowContext(ctx, query, chatId).Scan(
+		&preferences.ChatID,
+		&preferences.NotifyMode,
+		&quietHoursStart,
+		&quietHoursEnd,
+		&timeZone,
+		&preferences.QuietMode,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	if quietHoursStart.Valid {
+		preferences.QuietHoursStart = &quietHoursStart.String
+	}
+	if quietHoursEnd.Valid {
+		preferences.QuietHoursEnd = &quietHoursEnd.String
+	}
+	if timeZone.Valid {
+		preferences.TimeZone = &timeZone.String
+	}
+
+	return preferences, nil
+}
+
+func (r *chatPreferencesRepositoryImpl) Upsert(ctx context.Context, preferences *model.ChatPreferences) *errors.AppError {
+	query := `
+		INSERT INTO chat_preferences (
+			chat_id, notify_mode, quiet_hours_start, quiet_hours_end, time_zone, quiet_mode
+		) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			notify_mode = excluded.notify_mode,
+			quiet_hours_start = excluded.quiet_hours_start,
+			quiet_hours_end = excluded.quiet_hours_end,
+			time_zone = excluded.time_zone,
+			quiet_mode = excluded.quiet_mode,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		preferences.ChatID,
+		preferences.NotifyMode,
+		preferences.QuietHoursStart,
+		preferences.QuietHoursEnd,
+		preferences.TimeZone,
+		preferences.QuietMode,
+	)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *chatPreferencesRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("ChatPreferencesRepository", err)
+}
@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"time"
+)
+
+type deadLetterRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewDeadLetterRepositoryImpl(db *sql.DB) DeadLetterRepository {
+	return &deadLetterRepositoryImpl{db: db}
+}
+
+const deadLetterColumns = `
+			id,
+			payload,
+			attempts,
+			last_error,
+			status_code,
+			created_at,
+			replayed_at`
+
+func (r *deadLetterRepositoryImpl) Create(ctx context.Context, deadLetter *model.DeadLetter) *errors.AppError {
+	payload, err := json.Marshal(deadLetter.Request)
+	if err != nil {
+		return r.error(err)
+	}
+
+	query := `
+		INSERT INTO notification_dead_letter (
+			notification_id, payload, attempts, last_error, status_code
+		) VALUES (?, ?, ?, ?, ?)
+	`
+
+	_, err = r.db.ExecContext(
+		ctx,
+		query,
+		deadLetter.Request.EnsureID(),
+		string(payload),
+		deadLetter.Attempts,
+		deadLetter.LastError,
+		deadLetter.StatusCode,
+	)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+// GetAll returns the newest dead letters first, only the pending ones unless
+// includeReplayed is set.
+func (r *deadLetterRepositoryImpl) GetAll(ctx context.Context, includeReplayed bool, limit int) ([]*model.DeadLetter, *errors.AppError) {
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM notification_dead_letter
+	`
+	if !includeReplayed {
+		query += " WHERE replayed_at IS NULL"
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer rows.Close()
+
+	var deadLetters []*model.DeadLetter
+	for rows.Next() {
+		deadLetter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		deadLetters = append(deadLetters, deadLetter)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return deadLetters, nil
+}
+
+func (r *deadLetterRepositoryImpl) GetById(ctx context.Context, id int64) (*model.DeadLetter, *errors.AppError) {
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM notification_dead_letter
+		WHERE id = ?
+	`
+
+	deadLetter, err := scanDeadLetter(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	return deadLetter, nil
+}
+
+func (r *deadLetterRepositoryImpl) MarkReplayed(ctx context.Context, id int64, replayedAt time.Time) (bool, *errors.AppError) {
+	query := `
+		UPDATE notification_dead_letter
+		SET replayed_at = ?
+		WHERE id = ? AND replayed_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, replayedAt.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return false, r.error(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.error(err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *deadLetterRepositoryImpl) UnmarkReplayed(ctx context.Context, id int64, replayedAt time.Time) *errors.AppError {
+	query := `
+		UPDATE notification_dead_letter
+		SET replayed_at = NULL
+		WHERE id = ? AND replayed_at = ?
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, replayedAt.UTC().Format(time.RFC3339)); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func scanDeadLetter(row rowScanner) (*model.DeadLetter, error) {
+	deadLetter := &model.DeadLetter{}
+
+	var payload, createdAt string
+	var statusCode sql.NullInt64
+	var replayedAt sql.NullString
+	err := row.Scan(
+		&deadLetter.ID,
+		&payload,
+		&deadLetter.Attempts,
+		&deadLetter.LastError,
+		&statusCode,
+		&createdAt,
+		&replayedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetter.Request = &model.NotificationRequest{}
+	if err := json.Unmarshal([]byte(payload), deadLetter.Request); err != nil {
+		return nil, err
+	}
+	if statusCode.Valid {
+		code := int(statusCode.Int64)
+		deadLetter.StatusCode = &code
+	}
+	if deadLetter.CreatedAt, err = parseTimestamp(createdAt); err != nil {
+		return nil, err
+	}
+	if replayedAt.Valid {
+		parsed, err := parseTimestamp(replayedAt.String)
+		if err != nil {
+			return nil, err
+		}
+		deadLetter.ReplayedAt = &parsed
+	}
+
+	return deadLetter, nil
+}
+
+func (r *deadLetterRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("DeadLetterRepository", err)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"spl-notification/internal/model"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterRepository_MarkReplayedClaimsOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := NewDeadLetterRepositoryImpl(newTestDB(t))
+
+	deadLetter := &model.DeadLetter{
+		Request:   &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat1", Run: "12345678-9", Date: time.Now()},
+		Attempts:  3,
+		LastError: "webhook answered 502",
+	}
+	require.Nil(t, repo.Create(ctx, deadLetter))
+	deadLetters, err := repo.GetAll(ctx, false, 10)
+	require.Nil(t, err)
+	require.Len(t, deadLetters, 1)
+	deadLetter = deadLetters[0]
+
+	// Concurrent replays, a single one wins the claim
+	replayedAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	var claims atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := repo.MarkReplayed(ctx, deadLetter.ID, replayedAt)
+			assert.Nil(t, err)
+			if claimed {
+				claims.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), claims.Load())
+
+	// Only the claim taken at replayedAt is released
+	require.Nil(t, repo.UnmarkReplayed(ctx, deadLetter.ID, replayedAt.Add(time.Second)))
+	stored, err := repo.GetById(ctx, deadLetter.ID)
+	require.Nil(t, err)
+	require.NotNil(t, stored.ReplayedAt)
+	assert.True(t, stored.ReplayedAt.Equal(replayedAt))
+
+	require.Nil(t, repo.UnmarkReplayed(ctx, deadLetter.ID, replayedAt))
+	claimed, err := repo.MarkReplayed(ctx, deadLetter.ID, replayedAt.Add(time.Minute))
+	require.Nil(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = repo.MarkReplayed(ctx, 999, replayedAt)
+	require.Nil(t, err)
+	assert.False(t, claimed)
+}
@@ -1,16 +1,124 @@
 package repository
 
 import (
+	"context"
 	"spl-notification/internal/dto/request"
 	"spl-notification/internal/errors"
 	"spl-notification/internal/model"
+	"time"
 )
 
 type TrackRepository interface {
-	GetAll() ([]*model.Track, *errors.AppError)
-	GetTracksByChatId(chatId string) ([]*model.Track, *errors.AppError)
-	UpdateEntryAt(accessArray []*model.Access) *errors.AppError
-	UpdateExitAt(accessArray []*model.Access) *errors.AppError
-	Create(trackDTO *request.CreateTrackDTO) *errors.AppError
-	Delete(trackDTO *request.DeleteTrackDTO) *errors.AppError
+	GetAll(ctx context.Context) ([]*model.Track, *errors.AppError)
+	GetTracksByChatId(ctx context.Context, chatId string) ([]*model.Track, *errors.AppError)
+	GetTrackByChatIdAndRun(ctx context.Context, chatId string, run string) (*model.Track, *errors.AppError)
+	// UpdateAccess stores the new last entry/exit timestamps, records them as
+	// access events and enqueues the resulting notifications in the outbox
+	// within a single transaction.
+	UpdateAccess(
+		ctx context.Context,
+		entryAccesses []*model.Access,
+		exitAccesses []*model.Access,
+		notifications []*model.NotificationRequest,
+	) *errors.AppError
+	// Create returns the stored track, or a conflict error when the chat
+	// already follows the RUN.
+	Create(ctx context.Context, trackDTO *request.CreateTrackDTO) (*model.Track, *errors.AppError)
+	UpdateFilters(ctx context.Context, track *model.Track) *errors.AppError
+	GetLongStayCandidates(ctx context.Context, defaultHours int) ([]*model.LongStayCandidate, *errors.AppError)
+	MarkLongStay(ctx context.Context, tracks []*model.Track, notifications []*model.NotificationRequest) *errors.AppError
+	GetInactiveCandidates(ctx context.Context) ([]*model.InactiveCandidate, *errors.AppError)
+	MarkInactive(ctx context.Context, tracks []*model.Track, alertedAt time.Time, notifications []*model.NotificationRequest) *errors.AppError
+	// Delete marks the track as deleted, it is no longer returned but can be
+	// brought back with Restore. It fails with TypeNotFound when the chat
+	// doesn't follow the RUN.
+	Delete(ctx context.Context, trackDTO *request.DeleteTrackDTO) *errors.AppError
+	GetDeletedTrack(ctx context.Context, chatId string, run string) (*model.Track, *errors.AppError)
+	// Restore brings back a deleted track, its LastEntry, LastEntryLocation
+	// and LastExit seed the person state like Create does. It returns the restored track.
+	Restore(ctx context.Context, track *model.Track) (*model.Track, *errors.AppError)
+}
+
+// CachedTrackRepository is a TrackRepository served from memory.
+type CachedTrackRepository interface {
+	TrackRepository
+	CacheStats() model.CacheStats
+}
+
+type OutboxRepository interface {
+	// GetPending returns the pending rows that are due. A row whose payload
+	// can't be decoded comes back with PayloadError set instead of Request.
+	GetPending(ctx context.Context, limit int) ([]*model.OutboxMessage, *errors.AppError)
+	MarkSent(ctx context.Context, ids []int64) *errors.AppError
+	// MarkFailed keeps the row pending but skips it until nextAttemptAt.
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) *errors.AppError
+	// MarkUndeliverable takes the row out of the relay for good.
+	MarkUndeliverable(ctx context.Context, id int64, lastError string) *errors.AppError
+}
+
+type NotificationLogRepository interface {
+	Create(ctx context.Context, notification *model.NotificationRequest) *errors.AppError
+	// UpdateStatus records the outcome of a delivery along with the webhook
+	// calls it took.
+	UpdateStatus(ctx context.Context, notification *model.NotificationRequest, status model.NotificationStatus, attempts int, lastError *string) *errors.AppError
+	SetStatus(ctx context.Context, notification *model.NotificationRequest, status model.NotificationStatus) *errors.AppError
+	UpdateStatusByIds(ctx context.Context, ids []int64, status model.NotificationStatus) *errors.AppError
+	GetByChatId(ctx context.Context, filter *request.NotificationHistoryDTO) ([]*model.NotificationLog, *errors.AppError)
+	GetByStatus(ctx context.Context, status model.NotificationStatus) ([]*model.NotificationLog, *errors.AppError)
+}
+
+type AccessEventRepository interface {
+	// GetVisits only returns the visits started since the track was created.
+	GetVisits(ctx context.Context, trackId int, from *time.Time, to *time.Time, limit int, offset int) ([]*model.Visit, int, *errors.AppError)
+}
+
+type ChatPreferencesRepository interface {
+	GetByChatId(ctx context.Context, chatId string) (*model.ChatPreferences, *errors.AppError)
+	Upsert(ctx context.Context, preferences *model.ChatPreferences) *errors.AppError
+}
+
+type LocationRepository interface {
+	GetAll(ctx context.Context) ([]*model.Location, *errors.AppError)
+	// Create fails with TypeConflict when the code is taken.
+	Create(ctx context.Context, locationDTO *request.LocationDTO) *errors.AppError
+	Update(ctx context.Context, locationDTO *request.LocationDTO) (bool, *errors.AppError)
+	Delete(ctx context.Context, code int8) (bool, *errors.AppError)
+}
+
+type AccessCursorRepository interface {
+	Get(ctx context.Context, source string) (*string, *errors.AppError)
+	Save(ctx context.Context, source string, cursor string) *errors.AppError
+}
+
+// NotificationDeliveryRepository records the IDs of the delivered
+// notifications so redelivered messages are not sent twice.
+type NotificationDeliveryRepository interface {
+	IsDelivered(ctx context.Context, id string, since time.Time) (bool, *errors.AppError)
+	MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) *errors.AppError
+	DeleteBefore(ctx context.Context, before time.Time) *errors.AppError
+}
+
+type DeadLetterRepository interface {
+	Create(ctx context.Context, deadLetter *model.DeadLetter) *errors.AppError
+	GetAll(ctx context.Context, includeReplayed bool, limit int) ([]*model.DeadLetter, *errors.AppError)
+	GetById(ctx context.Context, id int64) (*model.DeadLetter, *errors.AppError)
+	// MarkReplayed claims the dead letter for a replay, it returns false when
+	// it was already replayed.
+	MarkReplayed(ctx context.Context, id int64, replayedAt time.Time) (bool, *errors.AppError)
+	// UnmarkReplayed releases the claim taken at replayedAt.
+	UnmarkReplayed(ctx context.Context, id int64, replayedAt time.Time) *errors.AppError
+}
+
+type ApiKeyRepository interface {
+	Create(ctx context.Context, apiKey *model.ApiKey) *errors.AppError
+	GetAll(ctx context.Context) ([]*model.ApiKey, *errors.AppError)
+	GetById(ctx context.Context, id int64) (*model.ApiKey, *errors.AppError)
+	GetByHash(ctx context.Context, hash string) (*model.ApiKey, *errors.AppError)
+	Revoke(ctx context.Context, id int64, revokedAt time.Time) *errors.AppError
+	UpdateLastUsed(ctx context.Context, id int64, usedAt time.Time) *errors.AppError
+}
+
+type AuditEventRepository interface {
+	Create(ctx context.Context, event *model.AuditEvent) *errors.AppError
+	GetAll(ctx context.Context, filter *request.AuditFilterDTO) ([]*model.AuditEvent, *errors.AppError)
 }
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+)
+
+type locationRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewLocationRepositoryImpl(db *sql.DB) LocationRepository {
+	return &locationRepositoryImpl{db: db}
+}
+
+func (r *locationRepositoryImpl) GetAll(ctx context.Context) ([]*model.Location, *errors.AppError) {
+	query := `
+		SELECT
+			code,
+			name,
+			address,
+			time_zone,
+			active
+		FROM location
+		ORDER BY code
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer rows.Close()
+
+	var locations []*model.Location
+	for rows.Next() {
+		location := &model.Location{}
+
+		var address, timeZone sql.NullString
+		err := rows.Scan(
+			&location.Code,
+			&location.Name,
+			&address,
+			&timeZone,
+			&location.Active,
+		)
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		if address.Valid {
+			location.Address = &address.String
+		}
+		if timeZone.Valid {
+			location.TimeZone = &timeZone.String
+		}
+
+		locations = append(locations, location)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return locations, nil
+}
+
+func (r *locationRepositoryImpl) Create(ctx context.Context, locationDTO *request.LocationDTO) *errors.AppError {
+	query := `
+		INSERT INTO location (code, name, address, time_zone, active)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(code) DO NOTHING
+	`
+
+	active := true
+	if locationDTO.Active != nil {
+		active = *locationDTO.Active
+	}
+
+	result, err := r.db.ExecContext(ctx, query, locationDTO.Code, locationDTO.Name, locationDTO.Address, locationDTO.TimeZone, active)
+	if err != nil {
+		return r.error(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return r.error(err)
+	}
+	if affected == 0 {
+		return errors.NewAppErrorWithType("LocationRepository", errors.TypeConflict,
+			fmt.Errorf("location %d already exists", locationDTO.Code))
+	}
+
+	return nil
+}
+
+func (r *locationRepositoryImpl) Update(ctx context.Context, locationDTO *request.LocationDTO) (bool, *errors.AppError) {
+	query := `
+		UPDATE location
+		SET name = ?, address = ?, time_zone = ?, active = COALESCE(?, active), updated_at = CURRENT_TIMESTAMP
+		WHERE code = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, locationDTO.Name, locationDTO.Address, locationDTO.TimeZone, locationDTO.Active, locationDTO.Code)
+	if err != nil {
+		return false, r.error(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.error(err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *locationRepositoryImpl) Delete(ctx context.Context, code int8) (bool, *errors.AppError) {
+	query := `
+		DELETE FROM location
+		WHERE code = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, code)
+	if err != nil {
+		return false, r.error(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, r.error(err)
+	}
+
+	return affected > 0, nil
+}
+
+func (r *locationRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("LocationRepository", err)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationRepository_CreateDuplicateIsConflict(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLocationRepositoryImpl(newTestDB(t))
+
+	// 102 is seeded by the migration
+	err := repo.Create(ctx, &request.LocationDTO{Code: 102, Name: "Other"})
+	require.NotNil(t, err)
+	assert.True(t, err.HasType(errors.TypeConflict))
+
+	inactive := false
+	require.Nil(t, repo.Create(ctx, &request.LocationDTO{Code: 110, Name: "Antofagasta", Active: &inactive}))
+
+	locations, err := repo.GetAll(ctx)
+	require.Nil(t, err)
+	byCode := make(map[int8]string, len(locations))
+	for _, location := range locations {
+		byCode[location.Code] = location.Name
+		if location.Code == 110 {
+			assert.False(t, location.Active)
+		}
+	}
+	assert.Equal(t, "Espacio Urbano", byCode[102])
+	assert.Equal(t, "Antofagasta", byCode[110])
+}
+
+func TestLocationRepository_UpdateAndDeleteReportMissingCodes(t *testing.T) {
+	ctx := context.Background()
+	repo := NewLocationRepositoryImpl(newTestDB(t))
+
+	updated, err := repo.Update(ctx, &request.LocationDTO{Code: 110, Name: "Antofagasta"})
+	require.Nil(t, err)
+	assert.False(t, updated)
+
+	deleted, err := repo.Delete(ctx, 110)
+	require.Nil(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = repo.Delete(ctx, 102)
+	require.Nil(t, err)
+	assert.True(t, deleted)
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/errors"
+	"time"
+)
+
+type notificationDeliveryRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewNotificationDeliveryRepositoryImpl(db *sql.DB) NotificationDeliveryRepository {
+	return &notificationDeliveryRepositoryImpl{db: db}
+}
+
+// IsDelivered reports whether the notification was delivered at or after since.
+func (r *notificationDeliveryRepositoryImpl) IsDelivered(ctx context.Context, id string, since time.Time) (bool, *errors.AppError) {
+	query := `SELECT 1 FROM notification_delivery WHERE id = ? AND delivered_at >= ?`
+
+	var found int
+	err := r.db.QueryRowContext(ctx, query, id, since.UTC().Format(time.RFC3339)).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, r.error(err)
+	}
+
+	return true, nil
+}
+
+func (r *notificationDeliveryRepositoryImpl) MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) *errors.AppError {
+	query := `
+		INSERT INTO notification_delivery (id, delivered_at) VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET delivered_at = excluded.delivered_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, deliveredAt.UTC().Format(time.RFC3339)); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+// DeleteBefore removes the deliveries older than the retention window.
+func (r *notificationDeliveryRepositoryImpl) DeleteBefore(ctx context.Context, before time.Time) *errors.AppError {
+	query := `DELETE FROM notification_delivery WHERE delivered_at < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, before.UTC().Format(time.RFC3339)); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *notificationDeliveryRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("NotificationDeliveryRepository", err)
+}
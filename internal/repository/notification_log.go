@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"strings"
+	"time"
+)
+
+type notificationLogRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewNotificationLogRepositoryImpl(db *sql.DB) NotificationLogRepository {
+	return &notificationLogRepositoryImpl{db: db}
+}
+
+func (r *notificationLogRepositoryImpl) Create(ctx context.Context, notification *model.NotificationRequest) *errors.AppError {
+	query := `
+		INSERT INTO notification_log (
+			type, chat_id, run, full_name, alias, location, event_date, status
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id, run, type, event_date) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		notification.Type,
+		notification.ChatID,
+		notification.Run,
+		notification.FullName,
+		notification.Alias,
+		notification.Location,
+		notification.Date.UTC().Format(time.RFC3339),
+		model.NotificationStatusQueued,
+	)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *notificationLogRepositoryImpl) UpdateStatus(
+	ctx context.Context,
+	notification *model.NotificationRequest,
+	status model.NotificationStatus,
+	attempts int,
+	lastError *string,
+) *errors.AppError {
+	query := `
+		UPDATE notification_log
+		SET status = ?, attempts = attempts + ?, last_error = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE chat_id = ? AND run = ? AND type = ? AND event_date = ?
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		status,
+		attempts,
+		lastError,
+		notification.ChatID,
+		notification.Run,
+		notification.Type,
+		notification.Date.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *notificationLogRepositoryImpl) GetByChatId(ctx context.Context, filter *request.NotificationHistoryDTO) ([]*model.NotificationLog, *errors.AppError) {
+	query := `
+		SELECT
+			id,
+			type,
+			chat_id,
+			run,
+			full_name,
+			alias,
+			location,
+			event_date,
+			status,
+			attempts,
+			last_error,
+			created_at,
+			updated_at
+		FROM notification_log
+		WHERE chat_id = ?
+	`
+	args := []interface{}{filter.ChatID}
+
+	if filter.From != nil {
+		query += " AND event_date >= ?"
+		args = append(args, filter.From.UTC().Format(time.RFC3339))
+	}
+	if filter.To != nil {
+		query += " AND event_date <= ?"
+		args = append(args, filter.To.UTC().Format(time.RFC3339))
+	}
+	if filter.Type != nil {
+		query += " AND type = ?"
+		args = append(args, *filter.Type)
+	}
+
+	query += " ORDER BY event_date DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *notificationLogRepositoryImpl) GetByStatus(ctx context.Context, status model.NotificationStatus) ([]*model.NotificationLog, *errors.AppError) {
+	query := `
+		SELECT
+			id,
+			type,
+			chat_id,
+			run,
+			full_name,
+			alias,
+			location,
+			event_date,
+			status,
+			attempts,
+			last_error,
+			created_at,
+			updated_at
+		FROM notification_log
+		WHERE status = ?
+		ORDER BY chat_id, event_date
+	`
+
+	return r.query(ctx, query, status)
+}
+
+func (r *notificationLogRepositoryImpl) SetStatus(ctx context.Context, notification *model.NotificationRequest, status model.NotificationStatus) *errors.AppError {
+	query := `
+		UPDATE notification_log
+		SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE chat_id = ? AND run = ? AND type = ? AND event_date = ?
+	`
+
+	_, err := r.db.ExecContext(
+		ctx,
+		query,
+		status,
+		notification.ChatID,
+		notification.Run,
+		notification.Type,
+		notification.Date.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *notificationLogRepositoryImpl) UpdateStatusByIds(ctx context.Context, ids []int64, status model.NotificationStatus) *errors.AppError {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	query := `
+		UPDATE notification_log
+		SET status = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id IN (` + placeholders + `)
+	`
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, status)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, args...); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *notificationLogRepositoryImpl) query(ctx context.Context, query string, args ...interface{}) ([]*model.NotificationLog, *errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer rows.Close()
+
+	var logs []*model.NotificationLog
+	for rows.Next() {
+		notificationLog := &model.NotificationLog{}
+
+		var eventDateStr, createdAtStr, updatedAtStr string
+		var alias, lastError sql.NullString
+		err := rows.Scan(
+			&notificationLog.ID,
+			&notificationLog.Type,
+			&notificationLog.ChatID,
+			&notificationLog.Run,
+			&notificationLog.FullName,
+			&alias,
+			&notificationLog.Location,
+			&eventDateStr,
+			&notificationLog.Status,
+			&notificationLog.Attempts,
+			&lastError,
+			&createdAtStr,
+			&updatedAtStr,
+		)
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		if alias.Valid {
+			notificationLog.Alias = &alias.String
+		}
+		if lastError.Valid {
+			notificationLog.LastError = &lastError.String
+		}
+
+		notificationLog.Date, err = time.Parse(time.RFC3339, eventDateStr)
+		if err != nil {
+			return nil, r.error(err)
+		}
+		notificationLog.CreatedAt, err = parseTimestamp(createdAtStr)
+		if err != nil {
+			return nil, r.error(err)
+		}
+		notificationLog.UpdatedAt, err = parseTimestamp(updatedAtStr)
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		logs = append(logs, notificationLog)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return logs, nil
+}
+
+func (r *notificationLogRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("NotificationLogRepository", err)
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationLogRepository_GetByChatId(t *testing.T) {
+	ctx := context.Background()
+	repo := NewNotificationLogRepositoryImpl(newTestDB(t))
+
+	day := func(d int, hour int) time.Time {
+		return time.Date(2025, 10, d, hour, 0, 0, 0, time.UTC)
+	}
+	notifications := []*model.NotificationRequest{
+		{Type: model.NotificationTypeEntry, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Location: 102, Date: day(1, 8)},
+		{Type: model.NotificationTypeExit, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Location: 102, Date: day(1, 10)},
+		{Type: model.NotificationTypeEntry, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Location: 104, Date: day(2, 8)},
+		{Type: model.NotificationTypeExit, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Location: 104, Date: day(2, 10)},
+		{Type: model.NotificationTypeEntry, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Location: 102, Date: day(3, 8)},
+		{Type: model.NotificationTypeEntry, ChatID: "chat2", Run: "12345678-9", FullName: "John Doe", Location: 102, Date: day(2, 9)},
+	}
+	for _, notification := range notifications {
+		require.Nil(t, repo.Create(ctx, notification))
+	}
+
+	entry := model.NotificationType(model.NotificationTypeEntry)
+	from, to := day(2, 8), day(2, 10)
+
+	tests := []struct {
+		name     string
+		filter   *request.NotificationHistoryDTO
+		expected []time.Time
+	}{
+		{"newest first", &request.NotificationHistoryDTO{ChatID: "chat1", Limit: 20}, []time.Time{day(3, 8), day(2, 10), day(2, 8), day(1, 10), day(1, 8)}},
+		{"limit", &request.NotificationHistoryDTO{ChatID: "chat1", Limit: 2}, []time.Time{day(3, 8), day(2, 10)}},
+		{"type", &request.NotificationHistoryDTO{ChatID: "chat1", Type: &entry, Limit: 20}, []time.Time{day(3, 8), day(2, 8), day(1, 8)}},
+		{"inclusive bounds", &request.NotificationHistoryDTO{ChatID: "chat1", From: &from, To: &to, Limit: 20}, []time.Time{day(2, 10), day(2, 8)}},
+		{"from only", &request.NotificationHistoryDTO{ChatID: "chat1", From: &to, Limit: 20}, []time.Time{day(3, 8), day(2, 10)}},
+		{"all filters", &request.NotificationHistoryDTO{ChatID: "chat1", From: &from, To: &to, Type: &entry, Limit: 20}, []time.Time{day(2, 8)}},
+		{"other chat", &request.NotificationHistoryDTO{ChatID: "chat2", Limit: 20}, []time.Time{day(2, 9)}},
+		{"unknown chat", &request.NotificationHistoryDTO{ChatID: "chat3", Limit: 20}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			logs, err := repo.GetByChatId(ctx, test.filter)
+			require.Nil(t, err)
+
+			var dates []time.Time
+			for _, notificationLog := range logs {
+				assert.Equal(t, test.filter.ChatID, notificationLog.ChatID)
+				assert.Equal(t, model.NotificationStatusQueued, notificationLog.Status)
+				dates = append(dates, notificationLog.Date)
+			}
+			assert.Equal(t, test.expected, dates)
+		})
+	}
+}
+
+func TestNotificationLogRepository_UpdateStatusAddsAttempts(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewNotificationLogRepositoryImpl(db)
+
+	notification := &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Location: 102, Date: time.Now()}
+	require.Nil(t, repo.Create(ctx, notification))
+
+	// Dead-lettered after three webhook calls, then replayed and delivered
+	lastError := "webhook answered 502"
+	require.Nil(t, repo.UpdateStatus(ctx, notification, model.NotificationStatusFailed, 3, &lastError))
+	require.Nil(t, repo.UpdateStatus(ctx, notification, model.NotificationStatusDelivered, 1, nil))
+
+	var attempts int
+	var status string
+	require.NoError(t, db.QueryRow(`SELECT attempts, status FROM notification_log WHERE chat_id = 'chat1'`).Scan(&attempts, &status))
+	assert.Equal(t, 4, attempts)
+	assert.Equal(t, string(model.NotificationStatusDelivered), status)
+}
+
+func TestNotificationLogRepository_KeepsAlias(t *testing.T) {
+	ctx := context.Background()
+	repo := NewNotificationLogRepositoryImpl(newTestDB(t))
+
+	alias := "Johnny"
+	date := time.Date(2025, 10, 1, 8, 0, 0, 0, time.UTC)
+	require.Nil(t, repo.Create(ctx, &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Alias: &alias, Location: 102, Date: date}))
+	require.Nil(t, repo.Create(ctx, &model.NotificationRequest{Type: model.NotificationTypeExit, ChatID: "chat1", Run: "12345678-9", FullName: "John Doe", Location: 102, Date: date.Add(time.Hour)}))
+
+	logs, err := repo.GetByStatus(ctx, model.NotificationStatusQueued)
+	require.Nil(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, &alias, logs[0].Alias)
+	assert.Nil(t, logs[1].Alias)
+}
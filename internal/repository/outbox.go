@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"strings"
+	"time"
+)
+
+type outboxRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewOutboxRepositoryImpl(db *sql.DB) OutboxRepository {
+	return &outboxRepositoryImpl{db: db}
+}
+
+func (r *outboxRepositoryImpl) GetPending(ctx context.Context, limit int) ([]*model.OutboxMessage, *errors.AppError) {
+	query := `
+		SELECT id, payload, attempts
+		FROM notification_outbox
+		WHERE status = ? AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+		ORDER BY id
+		LIMIT ?
+	`
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	rows, err := r.db.QueryContext(ctx, query, model.OutboxStatusPending, now, limit)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer rows.Close()
+
+	var messages []*model.OutboxMessage
+	for rows.Next() {
+		message := &model.OutboxMessage{}
+
+		var payload string
+		err := rows.Scan(&message.ID, &payload, &message.Attempts)
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		// A payload that can't be decoded is handed back on its own, so it
+		// doesn't hold back the rest of the batch
+		request := &model.NotificationRequest{}
+		if err := json.Unmarshal([]byte(payload), request); err != nil {
+			message.PayloadError = err
+		} else {
+			message.Request = request
+		}
+
+		messages = append(messages, message)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return messages, nil
+}
+
+func (r *outboxRepositoryImpl) MarkSent(ctx context.Context, ids []int64) *errors.AppError {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	query := `
+		UPDATE notification_outbox
+		SET status = ?, sent_at = ?
+		WHERE id IN (` + placeholders + `)
+	`
+
+	args := make([]interface{}, 0, len(ids)+2)
+	args = append(args, model.OutboxStatusSent, time.Now().UTC().Format(time.RFC3339))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepositoryImpl) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) *errors.AppError {
+	query := `
+		UPDATE notification_outbox
+		SET attempts = attempts + 1, last_error = ?, next_attempt_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, lastError, nextAttemptAt.UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepositoryImpl) MarkUndeliverable(ctx context.Context, id int64, lastError string) *errors.AppError {
+	query := `
+		UPDATE notification_outbox
+		SET status = ?, attempts = attempts + 1, last_error = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, model.OutboxStatusFailed, lastError, id)
+	if err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+func (r *outboxRepositoryImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("OutboxRepository", err)
+}
+
+// insertOutbox writes the notifications inside the caller's transaction, so
+// they are only persisted together with the state change that produced them.
+func insertOutbox(ctx context.Context, tx *sql.Tx, notifications []*model.NotificationRequest) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO notification_outbox (payload, status)
+		VALUES (?, ?)
+	`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, notification := range notifications {
+		payload, err := json.Marshal(notification)
+		if err != nil {
+			return err
+		}
+
+		if _, err := stmt.ExecContext(ctx, string(payload), model.OutboxStatusPending); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRepository_InvalidPayloadIsReturnedWithTheBatch(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewOutboxRepositoryImpl(db)
+
+	_, err := db.Exec(`INSERT INTO notification_outbox (payload, status) VALUES (?, ?), (?, ?)`,
+		`{"type":`, model.OutboxStatusPending,
+		`{"type":1,"chatId":"chat1"}`, model.OutboxStatusPending,
+	)
+	require.NoError(t, err)
+
+	messages, appErr := repo.GetPending(ctx, 10)
+	require.Nil(t, appErr)
+	require.Len(t, messages, 2)
+	assert.Error(t, messages[0].PayloadError)
+	assert.Nil(t, messages[0].Request)
+	assert.Nil(t, messages[1].PayloadError)
+	require.NotNil(t, messages[1].Request)
+	assert.Equal(t, "chat1", messages[1].Request.ChatID)
+
+	// An undeliverable row is not relayed again
+	require.Nil(t, repo.MarkUndeliverable(ctx, messages[0].ID, messages[0].PayloadError.Error()))
+
+	messages, appErr = repo.GetPending(ctx, 10)
+	require.Nil(t, appErr)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "chat1", messages[0].Request.ChatID)
+}
+
+func TestOutboxRepository_FailedRowWaitsForNextAttempt(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewOutboxRepositoryImpl(db)
+
+	_, err := db.Exec(`INSERT INTO notification_outbox (payload, status) VALUES (?, ?)`,
+		`{"type":1,"chatId":"chat1"}`, model.OutboxStatusPending,
+	)
+	require.NoError(t, err)
+
+	messages, appErr := repo.GetPending(ctx, 10)
+	require.Nil(t, appErr)
+	require.Len(t, messages, 1)
+	id := messages[0].ID
+
+	require.Nil(t, repo.MarkFailed(ctx, id, "queue unavailable", time.Now().Add(time.Minute)))
+
+	messages, appErr = repo.GetPending(ctx, 10)
+	require.Nil(t, appErr)
+	assert.Empty(t, messages)
+
+	// Once the backoff has passed the row is due again
+	require.Nil(t, repo.MarkFailed(ctx, id, "queue unavailable", time.Now().Add(-time.Second)))
+
+	messages, appErr = repo.GetPending(ctx, 10)
+	require.Nil(t, appErr)
+	require.Len(t, messages, 1)
+	assert.Equal(t, 2, messages[0].Attempts)
+}
@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"spl-notification/internal/dto/request"
 	"spl-notification/internal/errors"
 	"spl-notification/internal/model"
@@ -17,21 +20,83 @@ func NewTrackRepositoryImpl(db *sql.DB) TrackRepository {
 	return &trackRepositoryImpl{db: db}
 }
 
-func (r *trackRepositoryImpl) GetAll() ([]*model.Track, *errors.AppError) {
+// trackColumns must be selected FROM trackTables, the last entry, its
+// location and the last exit are stored once per person and shared by every
+// follower.
+const trackColumns = `
+			track.id, 
+			track.chat_id, 
+			track.external_id, 
+			track.run, 
+			track.full_name, 
+			track.alias, 
+			person_state.last_entry, 
+			person_state.last_exit,
+			person_state.last_entry_location,
+			track.notify_type,
+			track.locations,
+			track.schedule,
+			track.long_stay_hours,
+			track.inactive_days`
+
+const trackTables = `track
+		LEFT JOIN person_state ON person_state.external_id = track.external_id`
+
+// activeTrack filters out the deleted tracks.
+const activeTrack = `track.deleted_at IS NULL`
+
+func (r *trackRepositoryImpl) GetAll(ctx context.Context) ([]*model.Track, *errors.AppError) {
 	query := `
-		SELECT 
-			id, 
-			chat_id, 
-			external_id, 
-			run, 
-			full_name, 
-			alias, 
-			last_entry, 
-			last_exit
-		FROM track
+		SELECT ` + trackColumns + `
+		FROM ` + trackTables + `
+		WHERE ` + activeTrack + `
 	`
 
-	rows, err := r.db.Query(query)
+	return r.queryTracks(ctx, query)
+}
+
+func (r *trackRepositoryImpl) GetTracksByChatId(ctx context.Context, chatId string) ([]*model.Track, *errors.AppError) {
+	query := `
+		SELECT ` + trackColumns + `
+		FROM ` + trackTables + `
+		WHERE track.chat_id = ? AND ` + activeTrack + `
+	`
+
+	return r.queryTracks(ctx, query, chatId)
+}
+
+func (r *trackRepositoryImpl) GetTrackByChatIdAndRun(ctx context.Context, chatId string, run string) (*model.Track, *errors.AppError) {
+	track, err := getActiveTrack(ctx, r.db, chatId, run)
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	return track, nil
+}
+
+// rowQuerier is either the database or a transaction.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// getActiveTrack returns the active track of the chat for the RUN, nil when
+// there is none.
+func getActiveTrack(ctx context.Context, db rowQuerier, chatId string, run string) (*model.Track, error) {
+	query := `
+		SELECT ` + trackColumns + `
+		FROM ` + trackTables + `
+		WHERE track.chat_id = ? AND track.run = ? AND ` + activeTrack + `
+	`
+
+	track, err := scanTrack(db.QueryRowContext(ctx, query, chatId, strings.ToUpper(run)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return track, err
+}
+
+func (r *trackRepositoryImpl) queryTracks(ctx context.Context, query string, args ...interface{}) ([]*model.Track, *errors.AppError) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, r.error(err)
 	}
@@ -39,155 +104,318 @@ func (r *trackRepositoryImpl) GetAll() ([]*model.Track, *errors.AppError) {
 
 	var tracks []*model.Track
 	for rows.Next() {
-		track := &model.Track{}
-
-		var lastEntryStr sql.NullString
-		var lastExitStr sql.NullString
-		var alias sql.NullString
-		err := rows.Scan(
-			&track.ID,
-			&track.ChatID,
-			&track.ExternalID,
-			&track.Run,
-			&track.FullName,
-			&alias,
-			&lastEntryStr,
-			&lastExitStr,
-		)
+		track, err := scanTrack(rows)
 		if err != nil {
 			return nil, r.error(err)
 		}
 
-		if alias.Valid {
-			track.Alias = &alias.String
+		tracks = append(tracks, track)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return tracks, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraColumnsScanner scans a row selected with trackColumns followed by
+// additional columns, which are written into extra.
+type extraColumnsScanner struct {
+	row   rowScanner
+	extra []interface{}
+}
+
+func (s *extraColumnsScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// scanTrack reads a row selected with trackColumns.
+func scanTrack(row rowScanner) (*model.Track, error) {
+	track := &model.Track{}
+
+	var lastEntryStr sql.NullString
+	var lastExitStr sql.NullString
+	var lastEntryLocation sql.NullInt64
+	var alias sql.NullString
+	var notifyType sql.NullString
+	var locations sql.NullString
+	var schedule sql.NullString
+	var longStayHours sql.NullInt64
+	var inactiveDays sql.NullInt64
+	err := row.Scan(
+		&track.ID,
+		&track.ChatID,
+		&track.ExternalID,
+		&track.Run,
+		&track.FullName,
+		&alias,
+		&lastEntryStr,
+		&lastExitStr,
+		&lastEntryLocation,
+		&notifyType,
+		&locations,
+		&schedule,
+		&longStayHours,
+		&inactiveDays,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if alias.Valid {
+		track.Alias = &alias.String
+	}
+
+	if lastEntryStr.Valid {
+		lastEntry, err := time.Parse(time.RFC3339, lastEntryStr.String)
+		if err != nil {
+			return nil, err
+		}
+		track.LastEntry = &lastEntry
+	}
+	if lastExitStr.Valid {
+		lastExit, err := time.Parse(time.RFC3339, lastExitStr.String)
+		if err != nil {
+			return nil, err
 		}
+		track.LastExit = &lastExit
+	}
+	if lastEntryLocation.Valid {
+		location := int8(lastEntryLocation.Int64)
+		track.LastEntryLocation = &location
+	}
 
-		if lastEntryStr.Valid {
-			lastEntry, err := time.Parse(time.RFC3339, lastEntryStr.String)
-			if err != nil {
-				return nil, r.error(err)
-			}
-			track.LastEntry = &lastEntry
+	if notifyType.Valid {
+		track.NotifyType = &notifyType.String
+	}
+	if locations.Valid {
+		if err := json.Unmarshal([]byte(locations.String), &track.Locations); err != nil {
+			return nil, err
 		}
-		if lastExitStr.Valid {
-			lastExit, err := time.Parse(time.RFC3339, lastExitStr.String)
-			if err != nil {
-				return nil, r.error(err)
-			}
-			track.LastExit = &lastExit
+	}
+	if schedule.Valid {
+		if err := json.Unmarshal([]byte(schedule.String), &track.Schedule); err != nil {
+			return nil, err
 		}
+	}
+	if longStayHours.Valid {
+		hours := int(longStayHours.Int64)
+		track.LongStayHours = &hours
+	}
+	if inactiveDays.Valid {
+		days := int(inactiveDays.Int64)
+		track.InactiveDays = &days
+	}
 
-		tracks = append(tracks, track)
+	return track, nil
+}
+
+// encodeFilter serializes the list filters as JSON, storing NULL when empty.
+func encodeFilter[T any](values []T) (interface{}, error) {
+	if len(values) == 0 {
+		return nil, nil
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, r.error(err)
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
 	}
+	return string(data), nil
+}
 
-	return tracks, nil
+func (r *trackRepositoryImpl) UpdateAccess(
+	ctx context.Context,
+	entryAccesses []*model.Access,
+	exitAccesses []*model.Access,
+	notifications []*model.NotificationRequest,
+) *errors.AppError {
+	if len(entryAccesses) == 0 && len(exitAccesses) == 0 && len(notifications) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return r.error(err)
+	}
+	defer tx.Rollback()
+
+	if err := r.updateEntryAt(ctx, tx, entryAccesses); err != nil {
+		return r.error(err)
+	}
+
+	if err := r.updateExitAt(ctx, tx, exitAccesses); err != nil {
+		return r.error(err)
+	}
+
+	if err := insertAccessEvents(ctx, tx, entryAccesses, exitAccesses); err != nil {
+		return r.error(err)
+	}
+
+	if err := insertOutbox(ctx, tx, notifications); err != nil {
+		return r.error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return r.error(err)
+	}
+
+	return nil
+}
+
+// updateEntryAt stores the entry once per person, every follower reads it
+// through the join with person_state.
+func (r *trackRepositoryImpl) updateEntryAt(ctx context.Context, tx *sql.Tx, accessArray []*model.Access) error {
+	if len(accessArray) == 0 {
+		return nil
+	}
+
+	query := `
+        INSERT INTO person_state (external_id, last_entry, last_entry_location)
+        VALUES (?, ?, ?)
+        ON CONFLICT(external_id) DO UPDATE SET
+            last_entry = excluded.last_entry,
+            last_entry_location = excluded.last_entry_location,
+            updated_at = CURRENT_TIMESTAMP
+    `
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, access := range accessArray {
+		_, err := stmt.ExecContext(ctx, access.ExternalID, access.EntryAt.UTC().Format(time.RFC3339), access.Location)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (r *trackRepositoryImpl) GetTracksByChatId(chatId string) ([]*model.Track, *errors.AppError) {
+func (r *trackRepositoryImpl) updateExitAt(ctx context.Context, tx *sql.Tx, accessArray []*model.Access) error {
+	if len(accessArray) == 0 {
+		return nil
+	}
+
 	query := `
-		SELECT 
-			id, 
-			chat_id, 
-			external_id, 
-			run, 
-			full_name, 
-			alias, 
-			last_entry, 
-			last_exit
-		FROM track 
-		WHERE chat_id = ?
+        INSERT INTO person_state (external_id, last_exit)
+        VALUES (?, ?)
+        ON CONFLICT(external_id) DO UPDATE SET
+            last_exit = excluded.last_exit,
+            updated_at = CURRENT_TIMESTAMP
+    `
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, access := range accessArray {
+		_, err := stmt.ExecContext(ctx, access.ExternalID, access.ExitAt.UTC().Format(time.RFC3339))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetLongStayCandidates returns the tracks with an open visit (last entry
+// newer than last exit) that have a long stay threshold and were not alerted
+// for that entry yet. The entry location is the one stored with the entry.
+func (r *trackRepositoryImpl) GetLongStayCandidates(ctx context.Context, defaultHours int) ([]*model.LongStayCandidate, *errors.AppError) {
+	query := `
+		SELECT ` + trackColumns + `,
+			COALESCE(track.long_stay_hours, ?) AS threshold
+		FROM ` + trackTables + `
+		WHERE ` + activeTrack + `
+			AND person_state.last_entry IS NOT NULL
+			AND COALESCE(track.long_stay_hours, ?) > 0
+			AND (track.long_stay_alerted_entry IS NULL OR track.long_stay_alerted_entry != person_state.last_entry)
 	`
 
-	rows, err := r.db.Query(query, chatId)
+	rows, err := r.db.QueryContext(ctx, query, defaultHours, defaultHours)
 	if err != nil {
 		return nil, r.error(err)
 	}
 	defer rows.Close()
 
-	var tracks []*model.Track
+	var candidates []*model.LongStayCandidate
 	for rows.Next() {
-		track := &model.Track{}
-
-		var lastEntryStr sql.NullString
-		var lastExitStr sql.NullString
-		var alias sql.NullString
-		err := rows.Scan(
-			&track.ID,
-			&track.ChatID,
-			&track.ExternalID,
-			&track.Run,
-			&track.FullName,
-			&alias,
-			&lastEntryStr,
-			&lastExitStr,
-		)
+		candidate := &model.LongStayCandidate{}
+
+		track, err := scanTrack(&extraColumnsScanner{row: rows, extra: []interface{}{&candidate.ThresholdHours}})
 		if err != nil {
 			return nil, r.error(err)
 		}
 
-		if alias.Valid {
-			track.Alias = &alias.String
+		candidate.Track = track
+		if track.LastEntryLocation != nil {
+			candidate.Location = *track.LastEntryLocation
 		}
 
-		if lastEntryStr.Valid {
-			lastEntry, err := time.Parse(time.RFC3339, lastEntryStr.String)
-			if err != nil {
-				return nil, r.error(err)
-			}
-			track.LastEntry = &lastEntry
-		}
-		if lastExitStr.Valid {
-			lastExit, err := time.Parse(time.RFC3339, lastExitStr.String)
-			if err != nil {
-				return nil, r.error(err)
-			}
-			track.LastExit = &lastExit
+		// The visit is still open when there is no exit after the last entry
+		if track.LastExit != nil && !track.LastExit.Before(*track.LastEntry) {
+			continue
 		}
 
-		tracks = append(tracks, track)
+		candidates = append(candidates, candidate)
 	}
 
 	if err = rows.Err(); err != nil {
 		return nil, r.error(err)
 	}
 
-	return tracks, nil
+	return candidates, nil
 }
 
-func (r *trackRepositoryImpl) UpdateEntryAt(accessArray []*model.Access) *errors.AppError {
-	if len(accessArray) == 0 {
+// MarkLongStay stores that the current visit of each track was alerted and
+// enqueues the notifications in the outbox within a single transaction.
+func (r *trackRepositoryImpl) MarkLongStay(ctx context.Context, tracks []*model.Track, notifications []*model.NotificationRequest) *errors.AppError {
+	if len(tracks) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return r.error(err)
 	}
 	defer tx.Rollback()
 
 	query := `
-        UPDATE track
-        SET last_entry = ?, updated_at = CURRENT_TIMESTAMP
-        WHERE external_id = ?
-    `
+		UPDATE track
+		SET long_stay_alerted_entry = (
+				SELECT last_entry FROM person_state
+				WHERE person_state.external_id = track.external_id
+			),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
 
-	stmt, err := tx.Prepare(query)
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
 		return r.error(err)
 	}
 	defer stmt.Close()
 
-	for _, access := range accessArray {
-		_, err := stmt.Exec(access.EntryAt.Format(time.RFC3339), access.ExternalID)
-		if err != nil {
+	for _, track := range tracks {
+		if _, err := stmt.ExecContext(ctx, track.ID); err != nil {
 			return r.error(err)
 		}
 	}
 
+	if err := insertOutbox(ctx, tx, notifications); err != nil {
+		return r.error(err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return r.error(err)
 	}
@@ -195,36 +423,96 @@ func (r *trackRepositoryImpl) UpdateEntryAt(accessArray []*model.Access) *errors
 	return nil
 }
 
-func (r *trackRepositoryImpl) UpdateExitAt(accessArray []*model.Access) *errors.AppError {
-	if len(accessArray) == 0 {
+// GetInactiveCandidates returns the tracks with inactivity reminders enabled,
+// along with the start of their inactivity period, the last reminder and the
+// location of the last entry stored with the person state.
+func (r *trackRepositoryImpl) GetInactiveCandidates(ctx context.Context) ([]*model.InactiveCandidate, *errors.AppError) {
+	query := `
+		SELECT ` + trackColumns + `,
+			track.created_at,
+			track.inactive_alerted_at
+		FROM ` + trackTables + `
+		WHERE ` + activeTrack + `
+			AND track.inactive_days > 0
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer rows.Close()
+
+	var candidates []*model.InactiveCandidate
+	for rows.Next() {
+		var createdAt string
+		var alertedAt sql.NullString
+		track, err := scanTrack(&extraColumnsScanner{row: rows, extra: []interface{}{&createdAt, &alertedAt}})
+		if err != nil {
+			return nil, r.error(err)
+		}
+
+		candidate := &model.InactiveCandidate{Track: track}
+		if track.LastEntry != nil {
+			candidate.Since = *track.LastEntry
+		} else if candidate.Since, err = parseTimestamp(createdAt); err != nil {
+			return nil, r.error(err)
+		}
+		if alertedAt.Valid {
+			parsed, err := parseTimestamp(alertedAt.String)
+			if err != nil {
+				return nil, r.error(err)
+			}
+			candidate.AlertedAt = &parsed
+		}
+		if track.LastEntryLocation != nil {
+			candidate.Location = *track.LastEntryLocation
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return candidates, nil
+}
+
+// MarkInactive stores when the tracks were reminded and enqueues the
+// notifications in the outbox within a single transaction.
+func (r *trackRepositoryImpl) MarkInactive(ctx context.Context, tracks []*model.Track, alertedAt time.Time, notifications []*model.NotificationRequest) *errors.AppError {
+	if len(tracks) == 0 {
 		return nil
 	}
 
-	tx, err := r.db.Begin()
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return r.error(err)
 	}
 	defer tx.Rollback()
 
 	query := `
-        UPDATE track
-        SET last_exit = ?, updated_at = CURRENT_TIMESTAMP
-        WHERE external_id = ?
-    `
+		UPDATE track
+		SET inactive_alerted_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`
 
-	stmt, err := tx.Prepare(query)
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
 		return r.error(err)
 	}
 	defer stmt.Close()
 
-	for _, access := range accessArray {
-		_, err := stmt.Exec(access.ExitAt.Format(time.RFC3339), access.ExternalID)
-		if err != nil {
+	for _, track := range tracks {
+		if _, err := stmt.ExecContext(ctx, alertedAt.UTC().Format(time.RFC3339), track.ID); err != nil {
 			return r.error(err)
 		}
 	}
 
+	if err := insertOutbox(ctx, tx, notifications); err != nil {
+		return r.error(err)
+	}
+
 	if err := tx.Commit(); err != nil {
 		return r.error(err)
 	}
@@ -232,33 +520,132 @@ func (r *trackRepositoryImpl) UpdateExitAt(accessArray []*model.Access) *errors.
 	return nil
 }
 
-func (r *trackRepositoryImpl) Create(trackDTO *request.CreateTrackDTO) *errors.AppError {
+// Create stores the track and, when nobody follows the person yet, seeds the
+// person state with the last entry and exit of the DTO. A new follower of a
+// person already followed shares the existing state. A deleted track of the
+// same chat and RUN is replaced, starting over as a new follow. An active
+// track of the same chat and RUN is left untouched and a conflict error is
+// returned.
+func (r *trackRepositoryImpl) Create(ctx context.Context, trackDTO *request.CreateTrackDTO) (*model.Track, *errors.AppError) {
 	query := `
 		INSERT INTO track (
-			chat_id, external_id, run, full_name, alias, last_entry, last_exit
-		) VALUES (?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(chat_id, run) DO NOTHING
+			chat_id, external_id, run, full_name, alias,
+			notify_type, locations, schedule, long_stay_hours, inactive_days
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id, run) DO UPDATE SET
+			external_id = excluded.external_id,
+			full_name = excluded.full_name,
+			alias = excluded.alias,
+			notify_type = excluded.notify_type,
+			locations = excluded.locations,
+			schedule = excluded.schedule,
+			long_stay_hours = excluded.long_stay_hours,
+			inactive_days = excluded.inactive_days,
+			long_stay_alerted_entry = NULL,
+			inactive_alerted_at = NULL,
+			deleted_at = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE track.deleted_at IS NOT NULL
 	`
 
-	var lastEntry, lastExit interface{}
-	if trackDTO.LastEntry != nil {
-		lastEntry = trackDTO.LastEntry.Format(time.RFC3339)
+	locations, err := encodeFilter(trackDTO.Locations)
+	if err != nil {
+		return nil, r.error(err)
 	}
-	if trackDTO.LastExit != nil {
-		lastExit = trackDTO.LastExit.Format(time.RFC3339)
+	schedule, err := encodeFilter(trackDTO.Schedule)
+	if err != nil {
+		return nil, r.error(err)
 	}
 
-	_, err := r.db.Exec(
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(
+		ctx,
 		query,
 		trackDTO.ChatID,
 		trackDTO.ExternalID,
 		strings.ToUpper(trackDTO.Run),
 		trackDTO.FullName,
 		trackDTO.Alias,
-		lastEntry,
-		lastExit,
+		trackDTO.NotifyType,
+		locations,
+		schedule,
+		trackDTO.LongStayHours,
+		trackDTO.InactiveDays,
 	)
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	if affected == 0 {
+		return nil, errors.NewAppErrorWithType("TrackRepository", errors.TypeConflict,
+			fmt.Errorf("track %s already exists for chat %s", trackDTO.Run, trackDTO.ChatID))
+	}
+
+	if err := seedPersonState(ctx, tx, trackDTO.ExternalID, trackDTO.LastEntry, trackDTO.LastEntryLocation, trackDTO.LastExit); err != nil {
+		return nil, r.error(err)
+	}
+
+	// Read back within the transaction to get the ID and the shared state
+	track, err := getActiveTrack(ctx, tx, trackDTO.ChatID, trackDTO.Run)
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return track, nil
+}
+
+// seedPersonState stores the last entry, its location and the last exit of a
+// person nobody followed.
+func seedPersonState(ctx context.Context, tx *sql.Tx, externalID int32, lastEntry *time.Time, lastEntryLocation *int8, lastExit *time.Time) error {
+	query := `
+		INSERT INTO person_state (external_id, last_entry, last_entry_location, last_exit)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(external_id) DO NOTHING
+	`
+
+	var entry, exit interface{}
+	if lastEntry != nil {
+		entry = lastEntry.UTC().Format(time.RFC3339)
+	}
+	if lastExit != nil {
+		exit = lastExit.UTC().Format(time.RFC3339)
+	}
+
+	_, err := tx.ExecContext(ctx, query, externalID, entry, lastEntryLocation, exit)
+	return err
+}
+
+func (r *trackRepositoryImpl) UpdateFilters(ctx context.Context, track *model.Track) *errors.AppError {
+	query := `
+		UPDATE track
+		SET notify_type = ?, locations = ?, schedule = ?, long_stay_hours = ?, inactive_days = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE chat_id = ? AND run = ? AND deleted_at IS NULL
+	`
 
+	locations, err := encodeFilter(track.Locations)
+	if err != nil {
+		return r.error(err)
+	}
+	schedule, err := encodeFilter(track.Schedule)
+	if err != nil {
+		return r.error(err)
+	}
+
+	_, err = r.db.ExecContext(ctx, query, track.NotifyType, locations, schedule, track.LongStayHours, track.InactiveDays, track.ChatID, strings.ToUpper(track.Run))
 	if err != nil {
 		return r.error(err)
 	}
@@ -266,20 +653,122 @@ func (r *trackRepositoryImpl) Create(trackDTO *request.CreateTrackDTO) *errors.A
 	return nil
 }
 
-func (r *trackRepositoryImpl) Delete(trackDTO *request.DeleteTrackDTO) *errors.AppError {
+// Delete marks the track as deleted and removes the person state once the
+// person has no active followers left, so a later or restored follower starts
+// from the current accesses.
+func (r *trackRepositoryImpl) Delete(ctx context.Context, trackDTO *request.DeleteTrackDTO) *errors.AppError {
 	query := `
-		DELETE FROM track
-		WHERE chat_id = ? AND run = ?
+		UPDATE track
+		SET deleted_at = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE chat_id = ? AND run = ? AND deleted_at IS NULL
 	`
 
-	_, err := r.db.Exec(query, trackDTO.ChatID, strings.ToUpper(trackDTO.Run))
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return r.error(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, time.Now().UTC().Format(time.RFC3339), trackDTO.ChatID, strings.ToUpper(trackDTO.Run))
 	if err != nil {
 		return r.error(err)
 	}
 
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return r.error(err)
+	}
+	if affected == 0 {
+		return errors.NewAppErrorWithType("TrackRepository", errors.TypeNotFound,
+			fmt.Errorf("track %s not found for chat %s", trackDTO.Run, trackDTO.ChatID))
+	}
+
+	// Only the person of the deleted track may have lost its last follower
+	stateQuery := `
+		DELETE FROM person_state
+		WHERE external_id = (SELECT external_id FROM track WHERE chat_id = ? AND run = ?) AND NOT EXISTS (
+			SELECT 1 FROM track
+			WHERE track.external_id = person_state.external_id AND ` + activeTrack + `
+		)
+	`
+
+	if _, err := tx.ExecContext(ctx, stateQuery, trackDTO.ChatID, strings.ToUpper(trackDTO.Run)); err != nil {
+		return r.error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return r.error(err)
+	}
+
 	return nil
 }
 
+// GetDeletedTrack returns the deleted track of the chat for the RUN, if any.
+func (r *trackRepositoryImpl) GetDeletedTrack(ctx context.Context, chatId string, run string) (*model.Track, *errors.AppError) {
+	query := `
+		SELECT ` + trackColumns + `
+		FROM ` + trackTables + `
+		WHERE track.chat_id = ? AND track.run = ? AND track.deleted_at IS NOT NULL
+	`
+
+	track, err := scanTrack(r.db.QueryRowContext(ctx, query, chatId, strings.ToUpper(run)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	return track, nil
+}
+
+// Restore brings back the deleted track and, like Create, seeds the person
+// state with its last entry, location and exit when nobody else follows the person. It
+// returns the restored track, or a not found error if it is no longer
+// deleted.
+func (r *trackRepositoryImpl) Restore(ctx context.Context, track *model.Track) (*model.Track, *errors.AppError) {
+	query := `
+		UPDATE track
+		SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND deleted_at IS NOT NULL
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, r.error(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, track.ID)
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, r.error(err)
+	}
+	if affected == 0 {
+		return nil, errors.NewAppErrorWithType("TrackRepository", errors.TypeNotFound,
+			fmt.Errorf("deleted track %s not found for chat %s", track.Run, track.ChatID))
+	}
+
+	if err := seedPersonState(ctx, tx, track.ExternalID, track.LastEntry, track.LastEntryLocation, track.LastExit); err != nil {
+		return nil, r.error(err)
+	}
+
+	restored, err := getActiveTrack(ctx, tx, track.ChatID, track.Run)
+	if err != nil {
+		return nil, r.error(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, r.error(err)
+	}
+
+	return restored, nil
+}
+
 func (r *trackRepositoryImpl) error(err error) *errors.AppError {
 	return errors.NewAppError("TrackRepository", err)
 }
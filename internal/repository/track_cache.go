@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"spl-notification/internal/config"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cachedTrackRepositoryImpl keeps every track in memory in front of another
+// TrackRepository. Writes go through to the database and then update the
+// cache, and the whole set is reloaded once the reconcile interval passes so
+// changes made by other instances are eventually picked up. Reads return
+// copies so callers can't modify the cached tracks.
+type cachedTrackRepositoryImpl struct {
+	TrackRepository
+
+	reconcileInterval time.Duration
+
+	mu       sync.RWMutex
+	tracks   map[int]*model.Track
+	loadedAt time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func NewCachedTrackRepositoryImpl(
+	trackRepository TrackRepository,
+	enviromentConfig *config.EnvironmentConfig,
+) CachedTrackRepository {
+	return &cachedTrackRepositoryImpl{
+		TrackRepository:   trackRepository,
+		reconcileInterval: enviromentConfig.TrackCacheReconcileInterval,
+	}
+}
+
+func (r *cachedTrackRepositoryImpl) GetAll(ctx context.Context) ([]*model.Track, *errors.AppError) {
+	return r.find(ctx, func(*model.Track) bool { return true })
+}
+
+func (r *cachedTrackRepositoryImpl) GetTracksByChatId(ctx context.Context, chatId string) ([]*model.Track, *errors.AppError) {
+	return r.find(ctx, func(track *model.Track) bool { return track.ChatID == chatId })
+}
+
+func (r *cachedTrackRepositoryImpl) GetTrackByChatIdAndRun(ctx context.Context, chatId string, run string) (*model.Track, *errors.AppError) {
+	tracks, err := r.find(ctx, func(track *model.Track) bool {
+		return track.ChatID == chatId && strings.EqualFold(track.Run, run)
+	})
+	if err != nil || len(tracks) == 0 {
+		return nil, err
+	}
+	return tracks[0], nil
+}
+
+func (r *cachedTrackRepositoryImpl) UpdateAccess(
+	ctx context.Context,
+	entryAccesses []*model.Access,
+	exitAccesses []*model.Access,
+	notifications []*model.NotificationRequest,
+) *errors.AppError {
+	if err := r.TrackRepository.UpdateAccess(ctx, entryAccesses, exitAccesses, notifications); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The repository updates every track of the person, so does the cache
+	entries := make(map[int32]*model.Access, len(entryAccesses))
+	for _, access := range entryAccesses {
+		entries[access.ExternalID] = access
+	}
+	exits := make(map[int32]time.Time, len(exitAccesses))
+	for _, access := range exitAccesses {
+		exits[access.ExternalID] = *access.ExitAt
+	}
+
+	for id, track := range r.tracks {
+		entry, hasEntry := entries[track.ExternalID]
+		exitAt, hasExit := exits[track.ExternalID]
+		if !hasEntry && !hasExit {
+			continue
+		}
+
+		updated := *track
+		if hasEntry {
+			entryAt, location := entry.EntryAt, entry.Location
+			updated.LastEntry = &entryAt
+			updated.LastEntryLocation = &location
+		}
+		if hasExit {
+			updated.LastExit = &exitAt
+		}
+		r.tracks[id] = &updated
+	}
+
+	return nil
+}
+
+func (r *cachedTrackRepositoryImpl) Create(ctx context.Context, trackDTO *request.CreateTrackDTO) (*model.Track, *errors.AppError) {
+	track, err := r.TrackRepository.Create(ctx, trackDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store(track)
+	return track, nil
+}
+
+func (r *cachedTrackRepositoryImpl) UpdateFilters(ctx context.Context, track *model.Track) *errors.AppError {
+	if err := r.TrackRepository.UpdateFilters(ctx, track); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.tracks[track.ID]; ok {
+		updated := *cached
+		updated.NotifyType = track.NotifyType
+		updated.Locations = track.Locations
+		updated.Schedule = track.Schedule
+		updated.LongStayHours = track.LongStayHours
+		updated.InactiveDays = track.InactiveDays
+		r.tracks[track.ID] = &updated
+	}
+
+	return nil
+}
+
+func (r *cachedTrackRepositoryImpl) Delete(ctx context.Context, trackDTO *request.DeleteTrackDTO) *errors.AppError {
+	if err := r.TrackRepository.Delete(ctx, trackDTO); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, track := range r.tracks {
+		if track.ChatID == trackDTO.ChatID && strings.EqualFold(track.Run, trackDTO.Run) {
+			delete(r.tracks, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *cachedTrackRepositoryImpl) Restore(ctx context.Context, track *model.Track) (*model.Track, *errors.AppError) {
+	restored, err := r.TrackRepository.Restore(ctx, track)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store(restored)
+	return restored, nil
+}
+
+func (r *cachedTrackRepositoryImpl) CacheStats() model.CacheStats {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := model.CacheStats{
+		Hits:   r.hits.Load(),
+		Misses: r.misses.Load(),
+		Size:   len(r.tracks),
+	}
+	if r.tracks != nil {
+		loadedAt := r.loadedAt
+		stats.LoadedAt = &loadedAt
+	}
+	return stats
+}
+
+// find returns copies of the cached tracks matching the filter, ordered by
+// ID, loading the cache first when it is empty or due for reconciliation.
+func (r *cachedTrackRepositoryImpl) find(ctx context.Context, filter func(*model.Track) bool) ([]*model.Track, *errors.AppError) {
+	r.mu.RLock()
+	if r.fresh() {
+		r.hits.Add(1)
+		defer r.mu.RUnlock()
+		return r.collect(filter), nil
+	}
+	r.mu.RUnlock()
+
+	// Loaded under the write lock so no write is applied to a set that is
+	// about to be replaced
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fresh() {
+		r.hits.Add(1)
+		return r.collect(filter), nil
+	}
+
+	r.misses.Add(1)
+	tracks, err := r.TrackRepository.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.tracks = make(map[int]*model.Track, len(tracks))
+	for _, track := range tracks {
+		r.tracks[track.ID] = track
+	}
+	r.loadedAt = time.Now()
+
+	return r.collect(filter), nil
+}
+
+func (r *cachedTrackRepositoryImpl) fresh() bool {
+	return r.tracks != nil && time.Since(r.loadedAt) < r.reconcileInterval
+}
+
+func (r *cachedTrackRepositoryImpl) collect(filter func(*model.Track) bool) []*model.Track {
+	tracks := make([]*model.Track, 0)
+	for _, track := range r.tracks {
+		if filter(track) {
+			copied := *track
+			tracks = append(tracks, &copied)
+		}
+	}
+
+	sort.Slice(tracks, func(i, j int) bool { return tracks[i].ID < tracks[j].ID })
+	return tracks
+}
+
+// store caches a copy of a track read back from the database, the caller
+// keeps its own.
+func (r *cachedTrackRepositoryImpl) store(track *model.Track) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tracks != nil && track != nil {
+		copied := *track
+		r.tracks[track.ID] = &copied
+	}
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/config"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCachedTrackRepository(t *testing.T, db *sql.DB, reconcileInterval time.Duration) CachedTrackRepository {
+	t.Helper()
+
+	envConfig := &config.EnvironmentConfig{TrackCacheReconcileInterval: reconcileInterval}
+	return NewCachedTrackRepositoryImpl(NewTrackRepositoryImpl(db), envConfig)
+}
+
+func TestCachedTrackRepository_ServesReadsFromMemory(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := newTestCachedTrackRepository(t, db, time.Minute)
+
+	_, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe"})
+	require.Nil(t, err)
+	_, err = repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat2", ExternalID: 54321, Run: "87654321-K", FullName: "Jane Doe"})
+	require.Nil(t, err)
+
+	// The first read loads every track
+	tracks, err := repo.GetAll(ctx)
+	require.Nil(t, err)
+	require.Len(t, tracks, 2)
+
+	// A change made outside the repository isn't seen until the next reload
+	_, dbErr := db.Exec(`UPDATE track SET full_name = 'Someone Else'`)
+	require.NoError(t, dbErr)
+
+	tracks, err = repo.GetTracksByChatId(ctx, "chat1")
+	require.Nil(t, err)
+	require.Len(t, tracks, 1)
+	assert.Equal(t, "John Doe", tracks[0].FullName)
+
+	track, err := repo.GetTrackByChatIdAndRun(ctx, "chat2", "87654321-k")
+	require.Nil(t, err)
+	require.NotNil(t, track)
+	assert.Equal(t, "Jane Doe", track.FullName)
+
+	// Callers get copies
+	track.FullName = "Changed"
+	track, err = repo.GetTrackByChatIdAndRun(ctx, "chat2", "87654321-K")
+	require.Nil(t, err)
+	assert.Equal(t, "Jane Doe", track.FullName)
+
+	stats := repo.CacheStats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, 2, stats.Size)
+	assert.NotNil(t, stats.LoadedAt)
+}
+
+func TestCachedTrackRepository_WritesThrough(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := newTestCachedTrackRepository(t, db, time.Minute)
+
+	_, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe"})
+	require.Nil(t, err)
+	_, err = repo.GetAll(ctx)
+	require.Nil(t, err)
+
+	// A second follower of the same person
+	created, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat2", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe"})
+	require.Nil(t, err)
+
+	// Entries and exits are copied to every follower
+	entryAt := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	exitAt := entryAt.Add(2 * time.Hour)
+	require.Nil(t, repo.UpdateAccess(ctx, []*model.Access{{ExternalID: 12345, Location: 102, EntryAt: entryAt}}, nil, nil))
+	require.Nil(t, repo.UpdateAccess(ctx, nil, []*model.Access{{ExternalID: 12345, Location: 102, EntryAt: entryAt, ExitAt: &exitAt}}, nil))
+
+	tracks, err := repo.GetAll(ctx)
+	require.Nil(t, err)
+	require.Len(t, tracks, 2)
+	for _, track := range tracks {
+		require.NotNil(t, track.LastEntry)
+		assert.True(t, track.LastEntry.Equal(entryAt))
+		require.NotNil(t, track.LastExit)
+		assert.True(t, track.LastExit.Equal(exitAt))
+	}
+
+	days := 7
+	created.InactiveDays = &days
+	require.Nil(t, repo.UpdateFilters(ctx, created))
+	track, err := repo.GetTrackByChatIdAndRun(ctx, "chat2", "12345678-9")
+	require.Nil(t, err)
+	require.NotNil(t, track.InactiveDays)
+	assert.Equal(t, 7, *track.InactiveDays)
+
+	require.Nil(t, repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat1", Run: "12345678-9"}))
+	tracks, err = repo.GetAll(ctx)
+	require.Nil(t, err)
+	require.Len(t, tracks, 1)
+	assert.Equal(t, "chat2", tracks[0].ChatID)
+
+	deleted, err := repo.GetDeletedTrack(ctx, "chat1", "12345678-9")
+	require.Nil(t, err)
+	require.NotNil(t, deleted)
+	_, err = repo.Restore(ctx, deleted)
+	require.Nil(t, err)
+	tracks, err = repo.GetTracksByChatId(ctx, "chat1")
+	require.Nil(t, err)
+	assert.Len(t, tracks, 1)
+
+	// Every read after the first load was answered from memory
+	assert.Equal(t, uint64(1), repo.CacheStats().Misses)
+}
+
+func TestCachedTrackRepository_ReconcilesAfterInterval(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := newTestCachedTrackRepository(t, db, 20*time.Millisecond)
+
+	tracks, err := repo.GetAll(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, tracks)
+
+	// Created by another instance
+	_, err = NewTrackRepositoryImpl(db).Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe"})
+	require.Nil(t, err)
+
+	tracks, err = repo.GetAll(ctx)
+	require.Nil(t, err)
+	assert.Empty(t, tracks)
+
+	time.Sleep(25 * time.Millisecond)
+	tracks, err = repo.GetAll(ctx)
+	require.Nil(t, err)
+	assert.Len(t, tracks, 1)
+
+	stats := repo.CacheStats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countPersonStates(t *testing.T, db *sql.DB, externalID int32) int {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM person_state WHERE external_id = ?`, externalID).Scan(&count)
+	require.NoError(t, err)
+	return count
+}
+
+func TestTrackRepository_CreateDuplicateIsRejected(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTrackRepositoryImpl(newTestDB(t))
+
+	alias := "Johnny"
+	created, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe", Alias: &alias})
+	require.Nil(t, err)
+	assert.NotZero(t, created.ID)
+	assert.Equal(t, "12345678-9", created.Run)
+
+	// The active track is left untouched
+	_, err = repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "Someone Else"})
+	require.NotNil(t, err)
+	assert.True(t, err.HasType(errors.TypeConflict))
+
+	track, err := repo.GetTrackByChatIdAndRun(ctx, "chat1", "12345678-9")
+	require.Nil(t, err)
+	assert.Equal(t, created.ID, track.ID)
+	assert.Equal(t, "John Doe", track.FullName)
+	assert.Equal(t, &alias, track.Alias)
+}
+
+func TestTrackRepository_DeleteHidesTrackAndKeepsSharedState(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewTrackRepositoryImpl(db)
+
+	entry := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	for _, chatID := range []string{"chat1", "chat2"} {
+		_, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: chatID, ExternalID: 12345, Run: "12345678-9", FullName: "John Doe", LastEntry: &entry})
+		require.Nil(t, err)
+	}
+
+	require.Nil(t, repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat1", Run: "12345678-9"}))
+
+	// Every read skips the deleted track
+	all, err := repo.GetAll(ctx)
+	require.Nil(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "chat2", all[0].ChatID)
+
+	byChat, err := repo.GetTracksByChatId(ctx, "chat1")
+	require.Nil(t, err)
+	assert.Empty(t, byChat)
+
+	track, err := repo.GetTrackByChatIdAndRun(ctx, "chat1", "12345678-9")
+	require.Nil(t, err)
+	assert.Nil(t, track)
+
+	deleted, err := repo.GetDeletedTrack(ctx, "chat1", "12345678-9")
+	require.Nil(t, err)
+	require.NotNil(t, deleted)
+	assert.Equal(t, "chat1", deleted.ChatID)
+
+	// Filters of a deleted track can't be changed
+	hours := 3
+	deleted.LongStayHours = &hours
+	require.Nil(t, repo.UpdateFilters(ctx, deleted))
+	deleted, err = repo.GetDeletedTrack(ctx, "chat1", "12345678-9")
+	require.Nil(t, err)
+	assert.Nil(t, deleted.LongStayHours)
+
+	// The person state is kept while someone follows the person
+	assert.Equal(t, 1, countPersonStates(t, db, 12345))
+
+	require.Nil(t, repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat2", Run: "12345678-9"}))
+	assert.Equal(t, 0, countPersonStates(t, db, 12345))
+}
+
+func TestTrackRepository_DeleteUnknownTrack(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewTrackRepositoryImpl(db)
+
+	_, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe"})
+	require.Nil(t, err)
+	_, dbErr := db.Exec(`INSERT INTO person_state (external_id) VALUES (?)`, 67890)
+	require.NoError(t, dbErr)
+
+	// Neither a RUN the chat never followed nor one already deleted is found
+	err = repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat2", Run: "12345678-9"})
+	require.NotNil(t, err)
+	assert.True(t, err.HasType(errors.TypeNotFound))
+
+	require.Nil(t, repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat1", Run: "12345678-9"}))
+	err = repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat1", Run: "12345678-9"})
+	require.NotNil(t, err)
+	assert.True(t, err.HasType(errors.TypeNotFound))
+
+	// Only the person of the deleted track loses its state
+	assert.Equal(t, 0, countPersonStates(t, db, 12345))
+	assert.Equal(t, 1, countPersonStates(t, db, 67890))
+}
+
+func TestTrackRepository_CreateRevivesDeletedTrack(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewTrackRepositoryImpl(db)
+
+	oldEntry := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	hours := 4
+	created, err := repo.Create(ctx, &request.CreateTrackDTO{
+		ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe",
+		LastEntry: &oldEntry, Locations: []int8{102}, LongStayHours: &hours,
+	})
+	require.Nil(t, err)
+
+	_, dbErr := db.Exec(`UPDATE track SET long_stay_alerted_entry = ? WHERE id = ?`, oldEntry.Format(time.RFC3339), created.ID)
+	require.NoError(t, dbErr)
+	require.Nil(t, repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat1", Run: "12345678-9"}))
+
+	// Following the RUN again replaces the deleted row and starts over
+	newEntry := time.Date(2025, 10, 6, 9, 30, 0, 0, time.FixedZone("CLT", -3*60*60))
+	revived, err := repo.Create(ctx, &request.CreateTrackDTO{
+		ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John A. Doe", LastEntry: &newEntry,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, created.ID, revived.ID)
+	assert.Equal(t, "John A. Doe", revived.FullName)
+	assert.Empty(t, revived.Locations)
+	assert.Nil(t, revived.LongStayHours)
+	assert.True(t, revived.LastEntry.Equal(newEntry))
+
+	var alertedEntry sql.NullString
+	var lastEntry string
+	dbErr = db.QueryRow(`
+		SELECT track.long_stay_alerted_entry, person_state.last_entry
+		FROM track JOIN person_state ON person_state.external_id = track.external_id
+		WHERE track.id = ?
+	`, revived.ID).Scan(&alertedEntry, &lastEntry)
+	require.NoError(t, dbErr)
+	assert.False(t, alertedEntry.Valid)
+	// Stored in UTC like the access events it is compared with
+	assert.Equal(t, "2025-10-06T12:30:00Z", lastEntry)
+
+	deleted, err := repo.GetDeletedTrack(ctx, "chat1", "12345678-9")
+	require.Nil(t, err)
+	assert.Nil(t, deleted)
+}
+
+func TestTrackRepository_RestoreSeedsPersonState(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	repo := NewTrackRepositoryImpl(db)
+
+	_, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe"})
+	require.Nil(t, err)
+	require.Nil(t, repo.Delete(ctx, &request.DeleteTrackDTO{ChatID: "chat1", Run: "12345678-9"}))
+	assert.Equal(t, 0, countPersonStates(t, db, 12345))
+
+	deleted, err := repo.GetDeletedTrack(ctx, "chat1", "12345678-9")
+	require.Nil(t, err)
+
+	entry := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	deleted.LastEntry = &entry
+	restored, err := repo.Restore(ctx, deleted)
+	require.Nil(t, err)
+	assert.Equal(t, deleted.ID, restored.ID)
+	require.NotNil(t, restored.LastEntry)
+	assert.True(t, restored.LastEntry.Equal(entry))
+
+	// Restoring it again finds no deleted track
+	_, err = repo.Restore(ctx, deleted)
+	require.NotNil(t, err)
+	assert.True(t, err.HasType(errors.TypeNotFound))
+
+	all, err := repo.GetAll(ctx)
+	require.Nil(t, err)
+	assert.Equal(t, []*model.Track{restored}, all)
+}
+
+func TestTrackRepository_LongStayCandidateKeepsSeededLocation(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTrackRepositoryImpl(newTestDB(t))
+
+	// The person is already inside when followed, no access event is stored
+	entry := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	location := int8(3)
+	_, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe", LastEntry: &entry, LastEntryLocation: &location})
+	require.Nil(t, err)
+
+	candidates, err := repo.GetLongStayCandidates(ctx, 4)
+	require.Nil(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, location, candidates[0].Location)
+
+	// A later entry replaces the location
+	nextEntry := entry.Add(24 * time.Hour)
+	exit := entry.Add(time.Hour)
+	require.Nil(t, repo.UpdateAccess(ctx,
+		[]*model.Access{{ExternalID: 12345, Location: 5, EntryAt: nextEntry}},
+		[]*model.Access{{ExternalID: 12345, Location: 3, EntryAt: entry, ExitAt: &exit}},
+		nil))
+
+	candidates, err = repo.GetLongStayCandidates(ctx, 4)
+	require.Nil(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, int8(5), candidates[0].Location)
+}
+
+func TestTrackRepository_InactiveCandidateKeepsSeededLocation(t *testing.T) {
+	ctx := context.Background()
+	repo := NewTrackRepositoryImpl(newTestDB(t))
+
+	entry := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	exit := entry.Add(time.Hour)
+	location := int8(3)
+	days := 7
+	_, err := repo.Create(ctx, &request.CreateTrackDTO{ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe", LastEntry: &entry, LastEntryLocation: &location, LastExit: &exit, InactiveDays: &days})
+	require.Nil(t, err)
+
+	candidates, err := repo.GetInactiveCandidates(ctx)
+	require.Nil(t, err)
+	require.Len(t, candidates, 1)
+	assert.True(t, candidates[0].Since.Equal(entry))
+	assert.Equal(t, location, candidates[0].Location)
+}
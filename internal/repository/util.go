@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// parseTimestamp reads columns filled either by the application (RFC3339) or
+// by SQLite's CURRENT_TIMESTAMP default ("2006-01-02 15:04:05", UTC).
+func parseTimestamp(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateTime, value)
+}
+
+// parseNullTimestamp is parseTimestamp for nullable columns.
+func parseNullTimestamp(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	parsed, err := parseTimestamp(value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
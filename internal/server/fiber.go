@@ -5,6 +5,8 @@ import (
 	"spl-notification/internal/api/controller"
 	"spl-notification/internal/api/middleware"
 	"spl-notification/internal/config"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -16,21 +18,40 @@ func CreateFiberServer(
 	lc fx.Lifecycle,
 	mainController *controller.MainController,
 	trackController *controller.TrackController,
+	notificationController *controller.NotificationController,
+	statsController *controller.StatsController,
+	preferencesController *controller.PreferencesController,
+	locationController *controller.LocationController,
+	deadLetterController *controller.DeadLetterController,
+	accessController *controller.AccessController,
+	apiKeyController *controller.ApiKeyController,
+	auditController *controller.AuditController,
 	authMiddleware *middleware.AuthMiddleware,
 	config *config.EnvironmentConfig,
 ) {
 	app := fiber.New()
 
 	app.Use(cors.New())
-	app.Use(logger.New())
+	app.Use(logger.New(logger.Config{
+		// The caller is attached by the auth middleware once the route runs
+		Format: "${time} | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${locals:caller} | ${error}\n",
+	}))
 
-	// Setup routes
-	app.Get("/health", mainController.Health)
-	// Track
-	app.Get("/track/:chatId", authMiddleware.ValidateAuthHeader, trackController.GetAllFollowTracks)
-	app.Get("/track/send/:chatId", authMiddleware.ValidateAuthHeader, trackController.SendAllFollowTracks)
-	app.Post("/track", authMiddleware.ValidateAuthHeader, trackController.CreateTrack)
-	app.Delete("/track", authMiddleware.ValidateAuthHeader, trackController.DeleteTrack)
+	registerRoutes(
+		app,
+		mainController,
+		trackController,
+		notificationController,
+		statsController,
+		preferencesController,
+		locationController,
+		deadLetterController,
+		accessController,
+		apiKeyController,
+		auditController,
+		authMiddleware,
+		config,
+	)
 
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
@@ -39,7 +60,58 @@ func CreateFiberServer(
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			return app.Shutdown()
+			return app.ShutdownWithContext(ctx)
 		},
 	})
 }
+
+// registerRoutes sets up the routes along with the scope each one requires.
+func registerRoutes(
+	app *fiber.App,
+	mainController *controller.MainController,
+	trackController *controller.TrackController,
+	notificationController *controller.NotificationController,
+	statsController *controller.StatsController,
+	preferencesController *controller.PreferencesController,
+	locationController *controller.LocationController,
+	deadLetterController *controller.DeadLetterController,
+	accessController *controller.AccessController,
+	apiKeyController *controller.ApiKeyController,
+	auditController *controller.AuditController,
+	authMiddleware *middleware.AuthMiddleware,
+	config *config.EnvironmentConfig,
+) {
+	// Setup routes
+	app.Get("/health", mainController.Health)
+	// Track
+	app.Get("/track/:chatId", authMiddleware.Authorize(model.ScopeTracksRead), trackController.GetAllFollowTracks)
+	app.Get("/track/send/:chatId", authMiddleware.Authorize(model.ScopeMessagesSend), trackController.SendAllFollowTracks)
+	app.Get("/track/:chatId/stats", authMiddleware.Authorize(model.ScopeTracksRead), statsController.GetStats)
+	app.Get("/track/:chatId/:run/history", authMiddleware.Authorize(model.ScopeTracksRead), trackController.GetVisitHistory)
+	app.Post("/track", authMiddleware.Authorize(model.ScopeTracksWrite), trackController.CreateTrack)
+	app.Patch("/track", authMiddleware.Authorize(model.ScopeTracksWrite), trackController.UpdateTrackFilters)
+	app.Delete("/track", authMiddleware.Authorize(model.ScopeTracksWrite), trackController.DeleteTrack)
+	app.Post("/track/restore", authMiddleware.Authorize(model.ScopeTracksWrite), trackController.RestoreTrack)
+	// Preferences
+	app.Get("/preferences/:chatId", authMiddleware.Authorize(model.ScopeTracksRead), preferencesController.GetPreferences)
+	app.Put("/preferences/:chatId", authMiddleware.Authorize(model.ScopeTracksWrite), preferencesController.UpdatePreferences)
+	// Location
+	app.Get("/location", authMiddleware.Authorize(model.ScopeTracksRead), locationController.GetAllLocations)
+	app.Post("/location", authMiddleware.Authorize(model.ScopeAdmin), locationController.CreateLocation)
+	app.Put("/location/:code", authMiddleware.Authorize(model.ScopeAdmin), locationController.UpdateLocation)
+	app.Delete("/location/:code", authMiddleware.Authorize(model.ScopeAdmin), locationController.DeleteLocation)
+	// Notification
+	app.Get("/notification/:chatId", authMiddleware.Authorize(model.ScopeTracksRead), notificationController.GetNotificationHistory)
+	// Access
+	if config.AccessIngestMode != service.AccessIngestModePoll {
+		app.Post("/access/events", authMiddleware.Authorize(model.ScopeAdmin), accessController.IngestEvents)
+	}
+	// Admin
+	app.Get("/admin/dead-letters", authMiddleware.Authorize(model.ScopeAdmin), deadLetterController.GetDeadLetters)
+	app.Post("/admin/dead-letters/:id/replay", authMiddleware.Authorize(model.ScopeAdmin), deadLetterController.ReplayDeadLetter)
+	app.Get("/admin/api-keys", authMiddleware.Authorize(model.ScopeAdmin), apiKeyController.GetApiKeys)
+	app.Post("/admin/api-keys", authMiddleware.Authorize(model.ScopeAdmin), apiKeyController.CreateApiKey)
+	app.Delete("/admin/api-keys/:id", authMiddleware.Authorize(model.ScopeAdmin), apiKeyController.RevokeApiKey)
+	// Audit
+	app.Get("/audit", authMiddleware.Authorize(model.ScopeAdmin), auditController.GetAuditEvents)
+}
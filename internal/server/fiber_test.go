@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"spl-notification/internal/api/controller"
+	"spl-notification/internal/api/middleware"
+	"spl-notification/internal/config"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/service"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scopeApiKeyService authenticates every key as a caller holding the scopes
+// listed in the key, separated by commas.
+type scopeApiKeyService struct {
+	service.ApiKeyService
+}
+
+func (s scopeApiKeyService) Authenticate(ctx context.Context, key string) (*model.Caller, *errors.AppError) {
+	return &model.Caller{Name: key, Scopes: strings.Split(key, ",")}, nil
+}
+
+// newTestApp registers the routes with controllers that have no services,
+// a handler reached by a request panics and answers 500.
+func newTestApp(ingestMode string) *fiber.App {
+	envConfig := &config.EnvironmentConfig{AccessIngestMode: ingestMode}
+	authMiddleware := middleware.NewAuthMiddleware(envConfig, service.NewRequestVerifier(envConfig), scopeApiKeyService{})
+
+	app := fiber.New()
+	app.Use(recover.New())
+	registerRoutes(
+		app,
+		&controller.MainController{},
+		&controller.TrackController{},
+		&controller.NotificationController{},
+		&controller.StatsController{},
+		&controller.PreferencesController{},
+		&controller.LocationController{},
+		&controller.DeadLetterController{},
+		&controller.AccessController{},
+		&controller.ApiKeyController{},
+		&controller.AuditController{},
+		authMiddleware,
+		envConfig,
+	)
+	return app
+}
+
+func TestRegisterRoutes_Scopes(t *testing.T) {
+	routes := []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{fiber.MethodGet, "/track/chat1", model.ScopeTracksRead},
+		{fiber.MethodGet, "/track/send/chat1", model.ScopeMessagesSend},
+		{fiber.MethodGet, "/track/chat1/stats", model.ScopeTracksRead},
+		{fiber.MethodGet, "/track/chat1/12345678-9/history", model.ScopeTracksRead},
+		{fiber.MethodPost, "/track", model.ScopeTracksWrite},
+		{fiber.MethodPatch, "/track", model.ScopeTracksWrite},
+		{fiber.MethodDelete, "/track", model.ScopeTracksWrite},
+		{fiber.MethodPost, "/track/restore", model.ScopeTracksWrite},
+		{fiber.MethodGet, "/preferences/chat1", model.ScopeTracksRead},
+		{fiber.MethodPut, "/preferences/chat1", model.ScopeTracksWrite},
+		{fiber.MethodGet, "/location", model.ScopeTracksRead},
+		{fiber.MethodPost, "/location", model.ScopeAdmin},
+		{fiber.MethodPut, "/location/102", model.ScopeAdmin},
+		{fiber.MethodDelete, "/location/102", model.ScopeAdmin},
+		{fiber.MethodGet, "/notification/chat1", model.ScopeTracksRead},
+		{fiber.MethodPost, "/access/events", model.ScopeAdmin},
+		{fiber.MethodGet, "/admin/dead-letters", model.ScopeAdmin},
+		{fiber.MethodPost, "/admin/dead-letters/1/replay", model.ScopeAdmin},
+		{fiber.MethodGet, "/admin/api-keys", model.ScopeAdmin},
+		{fiber.MethodPost, "/admin/api-keys", model.ScopeAdmin},
+		{fiber.MethodDelete, "/admin/api-keys/1", model.ScopeAdmin},
+		{fiber.MethodGet, "/audit", model.ScopeAdmin},
+	}
+	scopes := []string{model.ScopeTracksRead, model.ScopeTracksWrite, model.ScopeMessagesSend}
+
+	app := newTestApp(service.AccessIngestModePush)
+	status := func(method string, path string, key string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if key != "" {
+			req.Header.Set("X-Api-Key", key)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			var others []string
+			for _, scope := range scopes {
+				if scope != route.scope {
+					others = append(others, scope)
+				}
+			}
+
+			assert.Equal(t, fiber.StatusUnauthorized, status(route.method, route.path, ""))
+			assert.Equal(t, fiber.StatusForbidden, status(route.method, route.path, strings.Join(others, ",")))
+
+			granted := status(route.method, route.path, route.scope)
+			assert.NotEqual(t, fiber.StatusUnauthorized, granted)
+			assert.NotEqual(t, fiber.StatusForbidden, granted)
+		})
+	}
+
+	// Every route but the health check is listed above
+	registered := 0
+	for _, route := range app.GetRoutes(true) {
+		if route.Method != fiber.MethodHead && route.Path != "/health" {
+			registered++
+		}
+	}
+	assert.Equal(t, len(routes), registered)
+
+	// The health check needs no credentials
+	assert.NotEqual(t, fiber.StatusUnauthorized, status(fiber.MethodGet, "/health", ""))
+}
+
+func TestRegisterRoutes_PollModeHasNoIngestRoute(t *testing.T) {
+	app := newTestApp(service.AccessIngestModePoll)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/access/events", nil)
+	req.Header.Set("X-Api-Key", model.ScopeAdmin)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
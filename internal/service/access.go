@@ -1,85 +1,341 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	neturl "net/url"
 	"spl-notification/internal/config"
 	"spl-notification/internal/dto/response"
 	"spl-notification/internal/errors"
 	"spl-notification/internal/model"
 	"spl-notification/internal/repository"
 	"strconv"
+	"sync"
 	"time"
 )
 
+const (
+	// AccessFetchModeSnapshot downloads the whole access list on every poll
+	AccessFetchModeSnapshot = "snapshot"
+	// AccessFetchModeIncremental only asks for the changes since the stored cursor
+	AccessFetchModeIncremental = "incremental"
+
+	accessCursorSource = "access-service"
+
+	// AccessIngestModePoll learns the accesses by polling the access service
+	AccessIngestModePoll = "poll"
+	// AccessIngestModePush receives them on POST /access/events
+	AccessIngestModePush = "push"
+	// AccessIngestModeHybrid receives them pushed and polls to reconcile
+	AccessIngestModeHybrid = "hybrid"
+)
+
 type accessServiceImpl struct {
-	trackRepository     repository.TrackRepository
-	notificationService NotificationService
-	enviromentConfig    *config.EnvironmentConfig
+	trackRepository        repository.TrackRepository
+	accessCursorRepository repository.AccessCursorRepository
+	locationService        LocationService
+	circuitBreaker         *circuitBreaker
+	signer                 *requestSigner
+	enviromentConfig       *config.EnvironmentConfig
+
+	// checkMu serializes the checks, pushed and polled events would otherwise
+	// detect the same changes before either is stored
+	checkMu          sync.Mutex
+	snapshotFallback sync.Once
 }
 
 func NewAccessServiceImpl(
 	trackRepository repository.TrackRepository,
-	notificationService NotificationService,
+	accessCursorRepository repository.AccessCursorRepository,
+	locationService LocationService,
+	circuitBreakers *CircuitBreakers,
 	enviromentConfig *config.EnvironmentConfig,
 ) AccessService {
 	return &accessServiceImpl{
-		trackRepository:     trackRepository,
-		notificationService: notificationService,
-		enviromentConfig:    enviromentConfig,
+		trackRepository:        trackRepository,
+		accessCursorRepository: accessCursorRepository,
+		locationService:        locationService,
+		circuitBreaker:         circuitBreakers.Access,
+		signer:                 newRequestSigner(enviromentConfig.AccessServiceSigningKey),
+		enviromentConfig:       enviromentConfig,
 	}
 }
 
-func (a *accessServiceImpl) CheckAccess(accessArray []*model.Access) *errors.AppError {
-	allTracks, err := a.trackRepository.GetAll()
+// PollAccesses fetches the accesses and checks them against the tracks. In
+// incremental mode only the changes since the stored cursor are requested,
+// and the new cursor is stored once they are processed so a restart resumes
+// from there. When the access service returns no cursor the response is a
+// full snapshot and is processed as such.
+func (a *accessServiceImpl) PollAccesses(ctx context.Context) (int, *errors.AppError) {
+	if a.enviromentConfig.AccessFetchMode != AccessFetchModeIncremental {
+		accesses, err := a.GetCompleteAccess(ctx)
+		if err != nil || len(accesses) == 0 {
+			return 0, err
+		}
+		return a.CheckAccess(ctx, accesses)
+	}
+
+	cursor, err := a.accessCursorRepository.Get(ctx, accessCursorSource)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	matchEntryAtTracks, matchExitAtTracks, err := a.compareTrackAndAccess(accessArray, allTracks)
+	accesses, nextCursor, err := a.fetchAccesses(ctx, cursor)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	// A delta may hold several events of a person, oldest first
+	accesses = latestAccesses(accesses)
+
+	changes := 0
+	if len(accesses) > 0 {
+		changes, err = a.CheckAccess(ctx, accesses)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if nextCursor == nil {
+		a.snapshotFallback.Do(func() {
+			log.Println("[AccessService] Access service returned no cursor, using full snapshots")
+		})
+		return changes, nil
+	}
+
+	if cursor == nil || *cursor != *nextCursor {
+		if err := a.accessCursorRepository.Save(ctx, accessCursorSource, *nextCursor); err != nil {
+			return changes, err
+		}
 	}
 
+	return changes, nil
+}
+
+func (a *accessServiceImpl) CheckAccess(ctx context.Context, accessArray []*model.Access) (int, *errors.AppError) {
+	a.checkMu.Lock()
+	defer a.checkMu.Unlock()
+
+	entryAccesses, exitAccesses, notificationRequests, err := a.detectChanges(ctx, accessArray)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := len(entryAccesses) + len(exitAccesses)
+	if changes == 0 {
+		return 0, nil
+	}
+
+	// Track state and outbox rows are committed together, the outbox relay
+	// publishes them afterwards so a failed publish never loses a notification.
+	// The state is stored even when the track filters discard every notification.
+	if err := a.trackRepository.UpdateAccess(ctx, entryAccesses, exitAccesses, notificationRequests); err != nil {
+		return 0, err
+	}
+
+	return changes, nil
+}
+
+// detectChanges compares the accesses with the stored state and returns the
+// accesses with a new entry or exit along with their notifications. The
+// tracks are read through the track cache, which holds the state stored by
+// the previous checks.
+func (a *accessServiceImpl) detectChanges(ctx context.Context, accessArray []*model.Access) ([]*model.Access, []*model.Access, []*model.NotificationRequest, *errors.AppError) {
+	allTracks, err := a.trackRepository.GetAll(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	matchEntryAtTracks, matchExitAtTracks, entryAccesses, exitAccesses := a.compareTrackAndAccess(accessArray, indexTracks(allTracks))
+	if len(entryAccesses) == 0 && len(exitAccesses) == 0 {
+		return nil, nil, nil, nil
+	}
+
+	accessByExternalID := indexAccesses(accessArray)
 	notificationRequests := make([]*model.NotificationRequest, 0)
 	if len(matchEntryAtTracks) > 0 {
 		notificationRequests = append(
 			notificationRequests,
-			a.createNotificationRequest(model.NotificationTypeEntry, accessArray, matchEntryAtTracks)...,
+			a.createNotificationRequest(model.NotificationTypeEntry, accessByExternalID, matchEntryAtTracks)...,
 		)
 	}
 
 	if len(matchExitAtTracks) > 0 {
 		notificationRequests = append(
 			notificationRequests,
-			a.createNotificationRequest(model.NotificationTypeExit, accessArray, matchExitAtTracks)...,
+			a.createNotificationRequest(model.NotificationTypeExit, accessByExternalID, matchExitAtTracks)...,
 		)
 	}
 
-	if len(notificationRequests) == 0 {
-		return nil
+	return entryAccesses, exitAccesses, notificationRequests, nil
+}
+
+// IngestAccesses checks pushed access events through the same diff as the
+// polled ones. A batch may hold several events of a person, only the latest
+// is kept, and events already applied or older than the stored state don't
+// produce changes. It returns the events kept after de-duplication along
+// with the changes detected.
+func (a *accessServiceImpl) IngestAccesses(ctx context.Context, events []*response.AccessDTO) (int, int, *errors.AppError) {
+	accesses := make([]*model.Access, 0, len(events))
+	for i, event := range events {
+		access, err := a.toAccess(event)
+		if err != nil {
+			return 0, 0, errors.NewAppErrorWithType("AccessService", errors.TypeValidation,
+				fmt.Errorf("event %d: %w", i, err))
+		}
+		if access.ExitAt != nil && access.ExitAt.Before(access.EntryAt) {
+			return 0, 0, errors.NewAppErrorWithType("AccessService", errors.TypeValidation,
+				fmt.Errorf("event %d: exitAt is before entryAt", i))
+		}
+		accesses = append(accesses, access)
+	}
+
+	accesses = latestAccesses(accesses)
+	if len(accesses) == 0 {
+		return 0, 0, nil
+	}
+
+	changes, err := a.CheckAccess(ctx, accesses)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(accesses), changes, nil
+}
+
+// latestAccesses keeps the latest access of each person, in the order the
+// persons first appear. The matcher reads a single access per person, an
+// older one would hide the newer entry or exit.
+func latestAccesses(accesses []*model.Access) []*model.Access {
+	latest := make(map[int32]*model.Access, len(accesses))
+	order := make([]int32, 0, len(accesses))
+	for _, access := range accesses {
+		current, ok := latest[access.ExternalID]
+		if !ok {
+			order = append(order, access.ExternalID)
+		}
+		if !ok || isLaterAccess(access, current) {
+			latest[access.ExternalID] = access
+		}
+	}
+
+	reduced := make([]*model.Access, 0, len(order))
+	for _, externalID := range order {
+		reduced = append(reduced, latest[externalID])
+	}
+	return reduced
+}
+
+// isLaterAccess reports whether access is a newer state of the person than
+// current: a later visit, or the exit of the same visit.
+func isLaterAccess(access *model.Access, current *model.Access) bool {
+	if !access.EntryAt.Equal(current.EntryAt) {
+		return access.EntryAt.After(current.EntryAt)
+	}
+	if current.ExitAt == nil {
+		return access.ExitAt != nil
+	}
+	return access.ExitAt != nil && access.ExitAt.After(*current.ExitAt)
+}
+
+// CheckLongStays notifies, once per visit, the tracks that have been inside
+// for longer than their threshold without an exit.
+func (a *accessServiceImpl) CheckLongStays(ctx context.Context) *errors.AppError {
+	candidates, err := a.trackRepository.GetLongStayCandidates(ctx, a.enviromentConfig.LongStayHours)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	alertedTracks := make([]*model.Track, 0)
+	notificationRequests := make([]*model.NotificationRequest, 0)
+	for _, candidate := range candidates {
+		track := candidate.Track
+		threshold := time.Duration(candidate.ThresholdHours) * time.Hour
+		inside := now.Sub(*track.LastEntry)
+		if inside < threshold {
+			continue
+		}
+
+		// The visit is marked even when the filters discard the alert, so it
+		// is not evaluated again until the next entry
+		alertedTracks = append(alertedTracks, track)
+
+		timeZone := a.locationService.TimeZone(candidate.Location)
+		if timeZone == nil {
+			timeZone = a.enviromentConfig.Location()
+		}
+
+		if !track.Matches(model.NotificationTypeLongStay, candidate.Location, now.In(timeZone)) {
+			continue
+		}
+
+		notificationRequests = append(notificationRequests, &model.NotificationRequest{
+			Type:     model.NotificationTypeLongStay,
+			Date:     *track.LastEntry,
+			ChatID:   track.ChatID,
+			Run:      track.Run,
+			FullName: track.FullName,
+			Alias:    track.Alias,
+			Location: candidate.Location,
+			Hours:    int(inside.Hours()),
+		})
+	}
+
+	return a.trackRepository.MarkLongStay(ctx, alertedTracks, notificationRequests)
+}
+
+// CheckInactivity reminds the followers of the tracks without an entry for
+// at least their configured days. Days are counted in the configured Zone and
+// a reminder is sent once per inactivity period, a new entry starts another.
+func (a *accessServiceImpl) CheckInactivity(ctx context.Context) *errors.AppError {
+	candidates, err := a.trackRepository.GetInactiveCandidates(ctx)
+	if err != nil {
+		return err
 	}
 
-	a.notificationService.SendNotification(notificationRequests)
+	now := time.Now()
+	location := a.enviromentConfig.Location()
+	today := startOfDay(now.In(location))
+
+	alertedTracks := make([]*model.Track, 0)
+	notificationRequests := make([]*model.NotificationRequest, 0)
+	for _, candidate := range candidates {
+		track := candidate.Track
+		if candidate.AlertedAt != nil && !candidate.AlertedAt.Before(candidate.Since) {
+			continue
+		}
+
+		days := int(today.Sub(startOfDay(candidate.Since.In(location))).Hours() / 24)
+		if days < *track.InactiveDays {
+			continue
+		}
+
+		alertedTracks = append(alertedTracks, track)
+		notificationRequests = append(notificationRequests, &model.NotificationRequest{
+			Type:     model.NotificationTypeInactive,
+			Date:     candidate.Since,
+			ChatID:   track.ChatID,
+			Run:      track.Run,
+			FullName: track.FullName,
+			Alias:    track.Alias,
+			Location: candidate.Location,
+			Days:     days,
+		})
+	}
 
-	return nil
+	return a.trackRepository.MarkInactive(ctx, alertedTracks, now, notificationRequests)
 }
 
 func (a *accessServiceImpl) createNotificationRequest(
 	notificationType model.NotificationType,
-	accesses []*model.Access,
+	accessByExternalID map[int32]*model.Access,
 	tracks []*model.Track) []*model.NotificationRequest {
 	notificationRequests := make([]*model.NotificationRequest, 0, len(tracks))
 	for _, track := range tracks {
-		var access *model.Access
-		for _, a := range accesses {
-			if a.ExternalID == track.ExternalID {
-				access = a
-				break
-			}
-		}
+		access := accessByExternalID[track.ExternalID]
 		var date time.Time
 		if notificationType == model.NotificationTypeEntry {
 			date = access.EntryAt
@@ -87,6 +343,15 @@ func (a *accessServiceImpl) createNotificationRequest(
 			date = *access.ExitAt
 		}
 
+		timeZone := a.locationService.TimeZone(access.Location)
+		if timeZone == nil {
+			timeZone = a.enviromentConfig.Location()
+		}
+
+		if !track.Matches(notificationType, access.Location, date.In(timeZone)) {
+			continue
+		}
+
 		notificationRequests = append(notificationRequests, &model.NotificationRequest{
 			Type:     notificationType,
 			Date:     date,
@@ -101,151 +366,165 @@ func (a *accessServiceImpl) createNotificationRequest(
 	return notificationRequests
 }
 
-func (a *accessServiceImpl) compareTrackAndAccess(accessArray []*model.Access, tracks []*model.Track) ([]*model.Track, []*model.Track, *errors.AppError) {
+// indexAccesses maps every ExternalID to its first access in the list.
+func indexAccesses(accessArray []*model.Access) map[int32]*model.Access {
+	accessByExternalID := make(map[int32]*model.Access, len(accessArray))
+	for _, access := range accessArray {
+		if _, ok := accessByExternalID[access.ExternalID]; !ok {
+			accessByExternalID[access.ExternalID] = access
+		}
+	}
+	return accessByExternalID
+}
+
+// compareTrackAndAccess compares every access with the state of its person,
+// once per person, and fans the changes out to all its followers. It returns
+// the tracks with a new entry or exit, along with the accesses (one per
+// ExternalID) whose timestamps must be persisted.
+func (a *accessServiceImpl) compareTrackAndAccess(accessArray []*model.Access, index map[int32]*indexedPerson) ([]*model.Track, []*model.Track, []*model.Access, []*model.Access) {
 	matchEntryAtTracks := make([]*model.Track, 0)
 	matchExitAtTracks := make([]*model.Track, 0)
-	trackToUpdateEntry := make(map[int32]*model.Access)
-	trackToUpdateExit := make(map[int32]*model.Access)
+	entryAccesses := make([]*model.Access, 0)
+	exitAccesses := make([]*model.Access, 0)
+	compared := make(map[int32]bool)
 
 	for _, access := range accessArray {
-		for _, track := range tracks {
-			if access.ExternalID == track.ExternalID {
-				// EntryAt comparison
-				if track.LastEntry == nil || !access.EntryAt.Equal(*track.LastEntry) {
-					matchEntryAtTracks = append(matchEntryAtTracks, track)
-
-					_, exist := trackToUpdateEntry[access.ExternalID]
-					if !exist {
-						trackToUpdateEntry[access.ExternalID] = access
-					}
-				}
-
-				// ExitAt comparison
-				bothNil := track.LastExit == nil && access.ExitAt == nil
-				if bothNil || access.ExitAt == nil {
-					continue
-				}
-
-				notEqual := (track.LastExit == nil && access.ExitAt != nil)
-				if !notEqual {
-					notEqual = !access.ExitAt.Equal(*track.LastExit)
-				}
-
-				if !notEqual {
-					continue
-				}
-
-				matchExitAtTracks = append(matchExitAtTracks, track)
-				_, exist := trackToUpdateExit[access.ExternalID]
-				if !exist {
-					trackToUpdateExit[access.ExternalID] = access
-				}
-
-			}
-
+		if compared[access.ExternalID] {
+			continue
 		}
-	}
+		compared[access.ExternalID] = true
 
-	trackToUpdateEntryArray := make([]*model.Access, 0, len(trackToUpdateEntry))
-	for _, access := range trackToUpdateEntry {
-		trackToUpdateEntryArray = append(trackToUpdateEntryArray, access)
-	}
+		person := index[access.ExternalID]
+		if person == nil {
+			continue
+		}
+		state := person.state
 
-	trackToUpdateExitArray := make([]*model.Access, 0, len(trackToUpdateExit))
-	for _, access := range trackToUpdateExit {
-		trackToUpdateExitArray = append(trackToUpdateExitArray, access)
-	}
+		// A late or replayed event of a previous visit must not move the
+		// state back
+		if state.LastEntry != nil && access.EntryAt.Before(*state.LastEntry) {
+			continue
+		}
 
-	if len(trackToUpdateEntryArray) > 0 {
-		err := a.trackRepository.UpdateEntryAt(trackToUpdateEntryArray)
-		if err != nil {
-			return nil, nil, err
+		if state.LastEntry == nil || !access.EntryAt.Equal(*state.LastEntry) {
+			matchEntryAtTracks = append(matchEntryAtTracks, person.tracks...)
+			entryAccesses = append(entryAccesses, access)
 		}
-	}
-	if len(trackToUpdateExitArray) > 0 {
-		err := a.trackRepository.UpdateExitAt(trackToUpdateExitArray)
-		if err != nil {
-			return nil, nil, err
+
+		if access.ExitAt != nil && (state.LastExit == nil || !access.ExitAt.Equal(*state.LastExit)) {
+			matchExitAtTracks = append(matchExitAtTracks, person.tracks...)
+			exitAccesses = append(exitAccesses, access)
 		}
 	}
 
-	return matchEntryAtTracks, matchExitAtTracks, nil
+	return matchEntryAtTracks, matchExitAtTracks, entryAccesses, exitAccesses
 }
 
-func (a *accessServiceImpl) GetCompleteAccess() ([]*model.Access, *errors.AppError) {
+func (a *accessServiceImpl) GetCompleteAccess(ctx context.Context) ([]*model.Access, *errors.AppError) {
+	accesses, _, err := a.fetchAccesses(ctx, nil)
+	return accesses, err
+}
+
+// fetchAccesses requests the accesses, only the ones changed after since when
+// it is set, and returns them along with the cursor of the response if any.
+func (a *accessServiceImpl) fetchAccesses(ctx context.Context, since *string) ([]*model.Access, *string, *errors.AppError) {
 	url := a.enviromentConfig.AccessServiceBaseUrl + "/api/access/complete"
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, a.error(err)
+	if since != nil {
+		url += "?since=" + neturl.QueryEscape(*since)
 	}
-	req.Header.Set("X-Auth-Token", a.enviromentConfig.AccessServiceAuthToken)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, a.error(err)
+		return nil, nil, a.error(err)
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, a.error(fmt.Errorf("error fetching recently access: %s", resp.Status))
+	if a.signer != nil {
+		a.signer.sign(req, nil)
+	} else {
+		req.Header.Set("X-Auth-Token", a.enviromentConfig.AccessServiceAuthToken)
 	}
 
+	var resp *http.Response
+	appErr := a.circuitBreaker.execute(func() *errors.AppError {
+		client := &http.Client{}
+		resp, err = client.Do(req)
+		if err != nil {
+			return a.error(err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return a.error(&httpStatusError{operation: "error fetching recently access", statusCode: resp.StatusCode, status: resp.Status})
+		}
+		return nil
+	})
+	if appErr != nil {
+		return nil, nil, appErr
+	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, a.error(err)
+		return nil, nil, a.error(err)
 	}
 
 	var response struct {
-		Data []*response.AccessDTO `json:"data"`
+		Data   []*response.AccessDTO `json:"data"`
+		Cursor *string               `json:"cursor"`
 	}
 
 	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, a.error(err)
+		return nil, nil, a.error(err)
 	}
 
 	// Convert response.AccessDTO to model.Access
 	accesses := make([]*model.Access, 0, len(response.Data))
 	for _, dto := range response.Data {
-		externalID, err := strconv.ParseInt(dto.ExternalID, 10, 32)
+		access, err := a.toAccess(dto)
 		if err != nil {
-			return nil, a.error(err)
+			return nil, nil, a.error(err)
 		}
 
-		location, err := strconv.ParseInt(dto.Location, 10, 8)
-		if err != nil {
-			return nil, a.error(err)
-		}
+		accesses = append(accesses, access)
+	}
+
+	return accesses, response.Cursor, nil
+}
 
-		// Parsear EntryAt de string a time.Time
-		entryAt, err := time.Parse(time.RFC3339, dto.EntryAt)
+func (a *accessServiceImpl) toAccess(dto *response.AccessDTO) (*model.Access, error) {
+	externalID, err := strconv.ParseInt(dto.ExternalID, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	location, err := strconv.ParseInt(dto.Location, 10, 8)
+	if err != nil {
+		return nil, err
+	}
+	a.locationService.CheckCode(int8(location))
+
+	// Parsear EntryAt de string a time.Time
+	entryAt, err := time.Parse(time.RFC3339, dto.EntryAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parsear ExitAt si no es nil
+	var exitAt *time.Time
+	if dto.ExitAt != nil {
+		parsed, err := time.Parse(time.RFC3339, *dto.ExitAt)
 		if err != nil {
-			return nil, a.error(err)
-		}
-
-		// Parsear ExitAt si no es nil
-		var exitAt *time.Time
-		if dto.ExitAt != nil {
-			parsed, err := time.Parse(time.RFC3339, *dto.ExitAt)
-			if err != nil {
-				return nil, a.error(err)
-			}
-			exitAt = &parsed
-		}
-
-		accesses = append(accesses, &model.Access{
-			ExternalID: int32(externalID),
-			Run:        dto.Run,
-			FullName:   dto.FullName,
-			Location:   int8(location),
-			EntryAt:    entryAt,
-			ExitAt:     exitAt,
-		})
+			return nil, err
+		}
+		exitAt = &parsed
 	}
 
-	return accesses, nil
+	return &model.Access{
+		ExternalID: int32(externalID),
+		Run:        dto.Run,
+		FullName:   dto.FullName,
+		Location:   int8(location),
+		EntryAt:    entryAt,
+		ExitAt:     exitAt,
+	}, nil
 }
 
 func (a *accessServiceImpl) error(err error) *errors.AppError {
@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"spl-notification/internal/config"
@@ -10,6 +12,7 @@ import (
 	"spl-notification/internal/dto/response"
 	apperrors "spl-notification/internal/errors"
 	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
 	"testing"
 	"time"
 
@@ -21,7 +24,7 @@ type MockTrackRepository struct {
 	mock.Mock
 }
 
-func (m *MockTrackRepository) GetAll() ([]*model.Track, *apperrors.AppError) {
+func (m *MockTrackRepository) GetAll(ctx context.Context) ([]*model.Track, *apperrors.AppError) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		if args.Get(1) == nil {
@@ -35,7 +38,7 @@ func (m *MockTrackRepository) GetAll() ([]*model.Track, *apperrors.AppError) {
 	return args.Get(0).([]*model.Track), args.Get(1).(*apperrors.AppError)
 }
 
-func (m *MockTrackRepository) GetTracksByChatId(chatId string) ([]*model.Track, *apperrors.AppError) {
+func (m *MockTrackRepository) GetTracksByChatId(ctx context.Context, chatId string) ([]*model.Track, *apperrors.AppError) {
 	args := m.Called(chatId)
 	if args.Get(0) == nil {
 		if args.Get(1) == nil {
@@ -49,31 +52,79 @@ func (m *MockTrackRepository) GetTracksByChatId(chatId string) ([]*model.Track,
 	return args.Get(0).([]*model.Track), args.Get(1).(*apperrors.AppError)
 }
 
-func (m *MockTrackRepository) UpdateEntryAt(accesses []*model.Access) *apperrors.AppError {
-	args := m.Called(accesses)
+func (m *MockTrackRepository) GetTrackByChatIdAndRun(ctx context.Context, chatId string, run string) (*model.Track, *apperrors.AppError) {
+	args := m.Called(chatId, run)
+	if args.Get(1) != nil {
+		return nil, args.Get(1).(*apperrors.AppError)
+	}
+	if args.Get(0) == nil {
+		return nil, nil
+	}
+	return args.Get(0).(*model.Track), nil
+}
+
+func (m *MockTrackRepository) UpdateAccess(
+	ctx context.Context,
+	entryAccesses []*model.Access,
+	exitAccesses []*model.Access,
+	notifications []*model.NotificationRequest,
+) *apperrors.AppError {
+	args := m.Called(entryAccesses, exitAccesses, notifications)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockTrackRepository) Create(ctx context.Context, trackDTO *request.CreateTrackDTO) (*model.Track, *apperrors.AppError) {
+	args := m.Called(trackDTO)
+	if args.Get(1) == nil {
+		return args.Get(0).(*model.Track), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockTrackRepository) UpdateFilters(ctx context.Context, track *model.Track) *apperrors.AppError {
+	args := m.Called(track)
 	if args.Get(0) == nil {
 		return nil
 	}
 	return args.Get(0).(*apperrors.AppError)
 }
 
-func (m *MockTrackRepository) UpdateExitAt(accesses []*model.Access) *apperrors.AppError {
-	args := m.Called(accesses)
+func (m *MockTrackRepository) GetLongStayCandidates(ctx context.Context, defaultHours int) ([]*model.LongStayCandidate, *apperrors.AppError) {
+	args := m.Called(defaultHours)
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.LongStayCandidate), nil
+	}
+	return args.Get(0).([]*model.LongStayCandidate), args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockTrackRepository) MarkLongStay(ctx context.Context, tracks []*model.Track, notifications []*model.NotificationRequest) *apperrors.AppError {
+	args := m.Called(tracks, notifications)
 	if args.Get(0) == nil {
 		return nil
 	}
 	return args.Get(0).(*apperrors.AppError)
 }
 
-func (m *MockTrackRepository) Create(trackDTO *request.CreateTrackDTO) *apperrors.AppError {
-	args := m.Called(trackDTO)
+func (m *MockTrackRepository) GetInactiveCandidates(ctx context.Context) ([]*model.InactiveCandidate, *apperrors.AppError) {
+	args := m.Called()
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.InactiveCandidate), nil
+	}
+	return args.Get(0).([]*model.InactiveCandidate), args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockTrackRepository) MarkInactive(ctx context.Context, tracks []*model.Track, alertedAt time.Time, notifications []*model.NotificationRequest) *apperrors.AppError {
+	args := m.Called(tracks, alertedAt, notifications)
 	if args.Get(0) == nil {
 		return nil
 	}
 	return args.Get(0).(*apperrors.AppError)
 }
 
-func (m *MockTrackRepository) Delete(trackDTO *request.DeleteTrackDTO) *apperrors.AppError {
+func (m *MockTrackRepository) Delete(ctx context.Context, trackDTO *request.DeleteTrackDTO) *apperrors.AppError {
 	args := m.Called(trackDTO)
 	if args.Get(0) == nil {
 		return nil
@@ -81,11 +132,47 @@ func (m *MockTrackRepository) Delete(trackDTO *request.DeleteTrackDTO) *apperror
 	return args.Get(0).(*apperrors.AppError)
 }
 
+func (m *MockTrackRepository) GetDeletedTrack(ctx context.Context, chatId string, run string) (*model.Track, *apperrors.AppError) {
+	args := m.Called(chatId, run)
+	if args.Get(1) == nil {
+		return args.Get(0).(*model.Track), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockTrackRepository) Restore(ctx context.Context, track *model.Track) (*model.Track, *apperrors.AppError) {
+	args := m.Called(track)
+	if args.Get(1) == nil {
+		return args.Get(0).(*model.Track), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+type MockAccessCursorRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccessCursorRepository) Get(ctx context.Context, source string) (*string, *apperrors.AppError) {
+	args := m.Called(source)
+	if args.Get(1) == nil {
+		return args.Get(0).(*string), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockAccessCursorRepository) Save(ctx context.Context, source string, cursor string) *apperrors.AppError {
+	args := m.Called(source, cursor)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
 type MockNotificationService struct {
 	mock.Mock
 }
 
-func (m *MockNotificationService) SendNotification(tracks []*model.NotificationRequest) *apperrors.AppError {
+func (m *MockNotificationService) SendNotification(ctx context.Context, tracks []*model.NotificationRequest) *apperrors.AppError {
 	args := m.Called(tracks)
 	if args.Get(0) == nil {
 		return nil
@@ -93,11 +180,11 @@ func (m *MockNotificationService) SendNotification(tracks []*model.NotificationR
 	return args.Get(0).(*apperrors.AppError)
 }
 
-func (m *MockNotificationService) HandleNotification() {
+func (m *MockNotificationService) HandleNotification(ctx context.Context) {
 	m.Called()
 }
 
-func (m *MockNotificationService) SendTracks(chatID string, tracks []*model.Track) *apperrors.AppError {
+func (m *MockNotificationService) SendTracks(ctx context.Context, chatID string, tracks []*model.Track) *apperrors.AppError {
 	args := m.Called(chatID, tracks)
 	if args.Get(0) == nil {
 		return nil
@@ -105,7 +192,7 @@ func (m *MockNotificationService) SendTracks(chatID string, tracks []*model.Trac
 	return args.Get(0).(*apperrors.AppError)
 }
 
-func (m *MockNotificationService) SendMessage(chatID string, message string) *apperrors.AppError {
+func (m *MockNotificationService) SendMessage(ctx context.Context, chatID string, message string) *apperrors.AppError {
 	args := m.Called(chatID, message)
 	if args.Get(0) == nil {
 		return nil
@@ -113,6 +200,46 @@ func (m *MockNotificationService) SendMessage(chatID string, message string) *ap
 	return args.Get(0).(*apperrors.AppError)
 }
 
+func (m *MockNotificationService) GetNotificationHistory(ctx context.Context, filter *request.NotificationHistoryDTO) ([]*model.NotificationLog, *apperrors.AppError) {
+	args := m.Called(filter)
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.NotificationLog), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockNotificationService) SendQuietHoursSummaries(ctx context.Context) *apperrors.AppError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockNotificationService) PurgeDeliveries(ctx context.Context) *apperrors.AppError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockNotificationService) GetDeadLetters(ctx context.Context, includeReplayed bool, limit int) ([]*model.DeadLetter, *apperrors.AppError) {
+	args := m.Called(includeReplayed, limit)
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.DeadLetter), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockNotificationService) ReplayDeadLetter(ctx context.Context, id int64) *apperrors.AppError {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
 func (m *MockNotificationService) Close() error {
 	args := m.Called()
 	if args.Get(0) == nil {
@@ -121,6 +248,108 @@ func (m *MockNotificationService) Close() error {
 	return args.Get(0).(*apperrors.AppError)
 }
 
+type MockLocationService struct {
+	mock.Mock
+}
+
+// newMockLocationService returns a location service with an empty catalogue
+// that accepts any location code.
+func newMockLocationService() *MockLocationService {
+	m := new(MockLocationService)
+	m.On("CheckCode", mock.Anything).Maybe()
+	m.On("TimeZone", mock.Anything).Return(nil).Maybe()
+	m.On("Name", mock.Anything).Return(model.UnknownLocationName).Maybe()
+	m.On("ActiveCodes").Return([]int8{}).Maybe()
+	return m
+}
+
+func (m *MockLocationService) Load(ctx context.Context) *apperrors.AppError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockLocationService) GetAll(ctx context.Context) ([]*model.Location, *apperrors.AppError) {
+	args := m.Called()
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.Location), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockLocationService) Create(ctx context.Context, locationDTO *request.LocationDTO) *apperrors.AppError {
+	args := m.Called(locationDTO)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockLocationService) Update(ctx context.Context, locationDTO *request.LocationDTO) *apperrors.AppError {
+	args := m.Called(locationDTO)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockLocationService) Delete(ctx context.Context, code int8) *apperrors.AppError {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockLocationService) CheckCode(code int8) {
+	m.Called(code)
+}
+
+func (m *MockLocationService) UnknownCodes() []int8 {
+	args := m.Called()
+	return args.Get(0).([]int8)
+}
+
+func (m *MockLocationService) ActiveCodes() []int8 {
+	args := m.Called()
+	return args.Get(0).([]int8)
+}
+
+func (m *MockLocationService) Name(code int8) string {
+	args := m.Called(code)
+	return args.String(0)
+}
+
+func (m *MockLocationService) TimeZone(code int8) *time.Location {
+	args := m.Called(code)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*time.Location)
+}
+
+// newTestAccessService builds the service with mocked dependencies. Tests
+// that expect calls on the cursor repository or the location service replace
+// them on the returned service.
+func newTestAccessService(trackRepository repository.TrackRepository, envConfig *config.EnvironmentConfig) *accessServiceImpl {
+	return NewAccessServiceImpl(
+		trackRepository,
+		new(MockAccessCursorRepository),
+		newMockLocationService(),
+		NewCircuitBreakers(envConfig),
+		envConfig,
+	).(*accessServiceImpl)
+}
+
+// newCachedTestAccessService builds the service over the track cache in front
+// of the mocked repository, as it runs, so the stored state is read back.
+func newCachedTestAccessService(trackRepository repository.TrackRepository, envConfig *config.EnvironmentConfig) (*accessServiceImpl, repository.CachedTrackRepository) {
+	cachedRepo := repository.NewCachedTrackRepositoryImpl(trackRepository, envConfig)
+	return newTestAccessService(cachedRepo, envConfig), cachedRepo
+}
+
 // Tests for CheckAccess
 
 func TestCheckAccess_Success_WithEntryMatches(t *testing.T) {
@@ -129,7 +358,6 @@ func TestCheckAccess_Success_WithEntryMatches(t *testing.T) {
 
 	// Setup mocks
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 
 	expectedTracks := []*model.Track{
 		{
@@ -167,20 +395,17 @@ func TestCheckAccess_Success_WithEntryMatches(t *testing.T) {
 	}
 
 	mockRepo.On("GetAll").Return(expectedTracks, nil)
-	mockRepo.On("UpdateEntryAt", mock.Anything).Return(nil)
-	mockNotifyService.On("SendNotification", mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	envConfig := &config.EnvironmentConfig{}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Test: CheckAccess debe completarse sin error
-	err := service.CheckAccess(accesses)
+	_, err := service.CheckAccess(context.Background(), accesses)
 
 	assert.Nil(t, err)
 	mockRepo.AssertCalled(t, "GetAll")
-	mockRepo.AssertCalled(t, "UpdateEntryAt", accesses)
-	mockRepo.AssertNotCalled(t, "UpdateExitAt")
-	mockNotifyService.AssertCalled(t, "SendNotification", expectedNotifications)
+	mockRepo.AssertCalled(t, "UpdateAccess", accesses, []*model.Access{}, expectedNotifications)
 }
 
 func TestCheckAccess_Success_WithExitMatches(t *testing.T) {
@@ -191,7 +416,6 @@ func TestCheckAccess_Success_WithExitMatches(t *testing.T) {
 
 	// Setup mocks
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 
 	expectedTracks := []*model.Track{
 		{
@@ -219,21 +443,17 @@ func TestCheckAccess_Success_WithExitMatches(t *testing.T) {
 
 	// Setup expectations - solo lo mínimo necesario
 	mockRepo.On("GetAll").Return(expectedTracks, nil)
-	mockRepo.On("UpdateEntryAt", mock.Anything).Return(nil)
-	mockRepo.On("UpdateExitAt", mock.Anything).Return(nil)
-	mockNotifyService.On("SendNotification", mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	envConfig := &config.EnvironmentConfig{}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Test: CheckAccess debe completarse sin error
-	err := service.CheckAccess(accesses)
+	_, err := service.CheckAccess(context.Background(), accesses)
 
 	assert.Nil(t, err)
 	mockRepo.AssertCalled(t, "GetAll")
-	mockRepo.AssertNotCalled(t, "UpdateEntryAt")
-	mockRepo.AssertCalled(t, "UpdateExitAt", accesses)
-	mockNotifyService.AssertCalled(t, "SendNotification", mock.Anything)
+	mockRepo.AssertCalled(t, "UpdateAccess", []*model.Access{}, accesses, mock.Anything)
 }
 
 func TestCheckAccess_Success_WithBothEntryAndExit(t *testing.T) {
@@ -245,7 +465,6 @@ func TestCheckAccess_Success_WithBothEntryAndExit(t *testing.T) {
 
 	// Setup mocks
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 
 	expectedTracks := []*model.Track{
 		{
@@ -269,12 +488,10 @@ func TestCheckAccess_Success_WithBothEntryAndExit(t *testing.T) {
 
 	// Setup expectations - solo lo mínimo necesario
 	mockRepo.On("GetAll").Return(expectedTracks, nil)
-	mockRepo.On("UpdateEntryAt", mock.Anything).Return(nil)
-	mockRepo.On("UpdateExitAt", mock.Anything).Return(nil)
-	mockNotifyService.On("SendNotification", mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	envConfig := &config.EnvironmentConfig{}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	accesses := []*model.Access{
 		{
@@ -296,13 +513,11 @@ func TestCheckAccess_Success_WithBothEntryAndExit(t *testing.T) {
 	}
 
 	// Test: CheckAccess debe completarse sin error
-	err := service.CheckAccess(accesses)
+	_, err := service.CheckAccess(context.Background(), accesses)
 
 	assert.Nil(t, err)
 	mockRepo.AssertCalled(t, "GetAll")
-	mockRepo.AssertCalled(t, "UpdateEntryAt", mock.Anything)
-	mockRepo.AssertCalled(t, "UpdateExitAt", mock.Anything)
-	mockNotifyService.AssertCalled(t, "SendNotification", mock.Anything)
+	mockRepo.AssertCalled(t, "UpdateAccess", []*model.Access{accesses[0]}, []*model.Access{accesses[1]}, mock.Anything)
 }
 
 func TestCheckAccess_NoMatches(t *testing.T) {
@@ -310,7 +525,6 @@ func TestCheckAccess_NoMatches(t *testing.T) {
 
 	// Setup mocks
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 
 	expectedTracks := []*model.Track{
 		{
@@ -322,12 +536,10 @@ func TestCheckAccess_NoMatches(t *testing.T) {
 
 	// Setup expectations - solo lo mínimo necesario
 	mockRepo.On("GetAll").Return(expectedTracks, nil)
-	mockRepo.On("UpdateEntryAt", mock.Anything).Return(nil)
-	mockRepo.On("UpdateExitAt", mock.Anything).Return(nil)
-	mockNotifyService.On("SendNotification", mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	envConfig := &config.EnvironmentConfig{}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	accesses := []*model.Access{
 		{
@@ -337,13 +549,11 @@ func TestCheckAccess_NoMatches(t *testing.T) {
 	}
 
 	// Test: CheckAccess debe completarse sin error
-	err := service.CheckAccess(accesses)
+	_, err := service.CheckAccess(context.Background(), accesses)
 
 	assert.Nil(t, err)
 	mockRepo.AssertCalled(t, "GetAll")
-	mockRepo.AssertNotCalled(t, "UpdateEntryAt")
-	mockRepo.AssertNotCalled(t, "UpdateExitAt")
-	mockNotifyService.AssertNotCalled(t, "SendNotification")
+	mockRepo.AssertNotCalled(t, "UpdateAccess")
 }
 
 func TestCheckAccess_GetAllError(t *testing.T) {
@@ -351,13 +561,12 @@ func TestCheckAccess_GetAllError(t *testing.T) {
 
 	// Setup mocks
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 
 	// Setup expectations
 	mockRepo.On("GetAll").Return(nil, expectedError)
 
 	envConfig := &config.EnvironmentConfig{}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	accesses := []*model.Access{
 		{
@@ -369,14 +578,12 @@ func TestCheckAccess_GetAllError(t *testing.T) {
 		},
 	}
 
-	err := service.CheckAccess(accesses)
+	_, err := service.CheckAccess(context.Background(), accesses)
 
 	assert.Error(t, err)
 	assert.Equal(t, expectedError, err)
 	mockRepo.AssertCalled(t, "GetAll")
-	mockRepo.AssertNotCalled(t, "UpdateEntryAt")
-	mockRepo.AssertNotCalled(t, "UpdateExitAt")
-	mockNotifyService.AssertNotCalled(t, "SendNotification")
+	mockRepo.AssertNotCalled(t, "UpdateAccess")
 }
 
 func TestCheckAccess_SyncTrackAndAccessError(t *testing.T) {
@@ -386,7 +593,6 @@ func TestCheckAccess_SyncTrackAndAccessError(t *testing.T) {
 
 	// Setup mocks
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 
 	expectedTracks := []*model.Track{
 		{
@@ -398,10 +604,10 @@ func TestCheckAccess_SyncTrackAndAccessError(t *testing.T) {
 
 	// Setup expectations
 	mockRepo.On("GetAll").Return(expectedTracks, nil)
-	mockRepo.On("UpdateEntryAt", mock.AnythingOfType("[]*model.Access")).Return(expectedError)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(expectedError)
 
 	envConfig := &config.EnvironmentConfig{}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	accesses := []*model.Access{
 		{
@@ -410,38 +616,153 @@ func TestCheckAccess_SyncTrackAndAccessError(t *testing.T) {
 		},
 	}
 
-	err := service.CheckAccess(accesses)
+	_, err := service.CheckAccess(context.Background(), accesses)
 
 	assert.Error(t, err)
 	assert.Equal(t, expectedError, err)
 	mockRepo.AssertCalled(t, "GetAll")
-	mockRepo.AssertCalled(t, "UpdateEntryAt", mock.AnythingOfType("[]*model.Access"))
-	mockRepo.AssertNotCalled(t, "UpdateExitAt")
-	mockNotifyService.AssertNotCalled(t, "SendNotification")
+	mockRepo.AssertCalled(t, "UpdateAccess", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestCheckAccess_EmptyAccessArray(t *testing.T) {
 	// Setup mocks
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 
 	// Setup expectations - solo lo mínimo necesario
 	mockRepo.On("GetAll").Return([]*model.Track{}, nil)
-	mockRepo.On("UpdateEntryAt", mock.Anything).Return(nil)
-	mockRepo.On("UpdateExitAt", mock.Anything).Return(nil)
-	mockNotifyService.On("SendNotification", mock.Anything).Return(nil)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	envConfig := &config.EnvironmentConfig{}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Test: CheckAccess debe completarse sin error con array vacío
-	err := service.CheckAccess([]*model.Access{})
+	_, err := service.CheckAccess(context.Background(), []*model.Access{})
 
 	assert.Nil(t, err)
 	mockRepo.AssertCalled(t, "GetAll")
-	mockRepo.AssertNotCalled(t, "UpdateEntryAt")
-	mockRepo.AssertNotCalled(t, "UpdateExitAt")
-	mockNotifyService.AssertNotCalled(t, "SendNotification")
+	mockRepo.AssertNotCalled(t, "UpdateAccess")
+}
+
+func TestCheckAccess_TrackFiltersSkipNotification(t *testing.T) {
+	now := time.Now()
+	oldEntry := now.Add(-2 * time.Hour)
+	exitOnly := model.NotifyModeExit
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+
+	expectedTracks := []*model.Track{
+		{
+			ID:         1,
+			ChatID:     "chat123",
+			ExternalID: 12345,
+			LastEntry:  &oldEntry,
+			NotifyType: &exitOnly,
+		},
+		{
+			ID:         2,
+			ChatID:     "chat456",
+			ExternalID: 12345,
+			LastEntry:  &oldEntry,
+			Locations:  []int8{102},
+		},
+	}
+
+	accesses := []*model.Access{
+		{
+			ExternalID: 12345,
+			Location:   104,
+			EntryAt:    now,
+		},
+	}
+
+	mockRepo.On("GetAll").Return(expectedTracks, nil)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{}
+	service := newTestAccessService(mockRepo, envConfig)
+
+	_, err := service.CheckAccess(context.Background(), accesses)
+
+	// The state is still updated even though no notification is produced
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "UpdateAccess", accesses, []*model.Access{}, []*model.NotificationRequest{})
+}
+
+func TestCheckLongStays_AlertsOnlyPastThreshold(t *testing.T) {
+	now := time.Now()
+	longEntry := now.Add(-5 * time.Hour)
+	shortEntry := now.Add(-1 * time.Hour)
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+
+	longTrack := &model.Track{ID: 1, ChatID: "chat123", Run: "12345678-9", ExternalID: 12345, LastEntry: &longEntry}
+	shortTrack := &model.Track{ID: 2, ChatID: "chat456", Run: "98765432-1", ExternalID: 54321, LastEntry: &shortEntry}
+	candidates := []*model.LongStayCandidate{
+		{Track: longTrack, ThresholdHours: 4, Location: 102},
+		{Track: shortTrack, ThresholdHours: 4, Location: 102},
+	}
+
+	mockRepo.On("GetLongStayCandidates", 4).Return(candidates, nil)
+	mockRepo.On("MarkLongStay", mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{LongStayHours: 4}
+	service := newTestAccessService(mockRepo, envConfig)
+
+	err := service.CheckLongStays(context.Background())
+
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "MarkLongStay", []*model.Track{longTrack}, []*model.NotificationRequest{
+		{
+			Type:     model.NotificationTypeLongStay,
+			Date:     longEntry,
+			ChatID:   "chat123",
+			Run:      "12345678-9",
+			Location: 102,
+			Hours:    5,
+		},
+	})
+}
+
+func TestCheckInactivity_OncePerPeriod(t *testing.T) {
+	now := time.Now()
+	lastEntry := now.AddDate(0, 0, -10)
+	alertedAt := now.AddDate(0, 0, -2)
+	days := 7
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+
+	inactiveTrack := &model.Track{ID: 1, ChatID: "chat123", Run: "12345678-9", LastEntry: &lastEntry, InactiveDays: &days}
+	alertedTrack := &model.Track{ID: 2, ChatID: "chat456", Run: "98765432-1", LastEntry: &lastEntry, InactiveDays: &days}
+	recentEntry := now.AddDate(0, 0, -3)
+	activeTrack := &model.Track{ID: 3, ChatID: "chat789", Run: "11111111-1", LastEntry: &recentEntry, InactiveDays: &days}
+	candidates := []*model.InactiveCandidate{
+		{Track: inactiveTrack, Since: lastEntry, Location: 102},
+		{Track: alertedTrack, Since: lastEntry, AlertedAt: &alertedAt},
+		{Track: activeTrack, Since: recentEntry},
+	}
+
+	mockRepo.On("GetInactiveCandidates").Return(candidates, nil)
+	mockRepo.On("MarkInactive", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{Zone: "GMT-3"}
+	service := newTestAccessService(mockRepo, envConfig)
+
+	err := service.CheckInactivity(context.Background())
+
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "MarkInactive", []*model.Track{inactiveTrack}, mock.Anything, []*model.NotificationRequest{
+		{
+			Type:     model.NotificationTypeInactive,
+			Date:     lastEntry,
+			ChatID:   "chat123",
+			Run:      "12345678-9",
+			Location: 102,
+			Days:     10,
+		},
+	})
 }
 
 // Tests for GetCompleteAccess
@@ -484,19 +805,22 @@ func TestGetCompleteAccess_Success(t *testing.T) {
 
 	// Setup service with server URL
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
+	mockLocationService := newMockLocationService()
 	envConfig := &config.EnvironmentConfig{
 		AccessServiceBaseUrl: server.URL,
 	}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
+	service.locationService = mockLocationService
 
 	// Execute
-	accesses, err := service.GetCompleteAccess()
+	accesses, err := service.GetCompleteAccess(context.Background())
 
 	// Assert
 	assert.Nil(t, err)
 	assert.NotNil(t, accesses)
 	assert.Len(t, accesses, 2)
+	mockLocationService.AssertCalled(t, "CheckCode", int8(104))
+	mockLocationService.AssertCalled(t, "CheckCode", int8(102))
 
 	// Verify first access
 	assert.Equal(t, int32(95729), accesses[0].ExternalID)
@@ -544,14 +868,13 @@ func TestGetCompleteAccess_ParseIntError_ExternalID(t *testing.T) {
 
 	// Setup service with server URL
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 	envConfig := &config.EnvironmentConfig{
 		AccessServiceBaseUrl: server.URL,
 	}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Execute
-	accesses, err := service.GetCompleteAccess()
+	accesses, err := service.GetCompleteAccess(context.Background())
 
 	// Assert
 	assert.Error(t, err)
@@ -584,14 +907,13 @@ func TestGetCompleteAccess_ParseIntError_Location(t *testing.T) {
 
 	// Setup service with server URL
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 	envConfig := &config.EnvironmentConfig{
 		AccessServiceBaseUrl: server.URL,
 	}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Execute
-	accesses, err := service.GetCompleteAccess()
+	accesses, err := service.GetCompleteAccess(context.Background())
 
 	// Assert
 	assert.Error(t, err)
@@ -624,14 +946,13 @@ func TestGetCompleteAccess_ParseTimeError_EntryAt(t *testing.T) {
 
 	// Setup service with server URL
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 	envConfig := &config.EnvironmentConfig{
 		AccessServiceBaseUrl: server.URL,
 	}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Execute
-	accesses, err := service.GetCompleteAccess()
+	accesses, err := service.GetCompleteAccess(context.Background())
 
 	// Assert
 	assert.Error(t, err)
@@ -664,14 +985,13 @@ func TestGetCompleteAccess_ParseTimeError_ExitAt(t *testing.T) {
 
 	// Setup service with server URL
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 	envConfig := &config.EnvironmentConfig{
 		AccessServiceBaseUrl: server.URL,
 	}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Execute
-	accesses, err := service.GetCompleteAccess()
+	accesses, err := service.GetCompleteAccess(context.Background())
 
 	// Assert
 	assert.Error(t, err)
@@ -688,14 +1008,13 @@ func TestGetCompleteAccess_UnmarshalError(t *testing.T) {
 
 	// Setup service with server URL
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 	envConfig := &config.EnvironmentConfig{
 		AccessServiceBaseUrl: server.URL,
 	}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Execute
-	accesses, err := service.GetCompleteAccess()
+	accesses, err := service.GetCompleteAccess(context.Background())
 
 	// Assert
 	assert.Error(t, err)
@@ -718,14 +1037,13 @@ func TestGetCompleteAccess_EmptyResponse(t *testing.T) {
 
 	// Setup service with server URL
 	mockRepo := new(MockTrackRepository)
-	mockNotifyService := new(MockNotificationService)
 	envConfig := &config.EnvironmentConfig{
 		AccessServiceBaseUrl: server.URL,
 	}
-	service := NewAccessServiceImpl(mockRepo, mockNotifyService, envConfig)
+	service := newTestAccessService(mockRepo, envConfig)
 
 	// Execute
-	accesses, err := service.GetCompleteAccess()
+	accesses, err := service.GetCompleteAccess(context.Background())
 
 	// Assert
 	assert.Nil(t, err)
@@ -733,6 +1051,423 @@ func TestGetCompleteAccess_EmptyResponse(t *testing.T) {
 	assert.Len(t, accesses, 0)
 }
 
+func TestCheckAccess_ReadsTracksThroughCache(t *testing.T) {
+	now := time.Now()
+	oldEntry := now.Add(-2 * time.Hour)
+	createDTO := &request.CreateTrackDTO{ChatID: "chat123", ExternalID: 12345, Run: "12345678-9"}
+	deleteDTO := &request.DeleteTrackDTO{ChatID: "chat123", Run: "12345678-9"}
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{}, nil).Once()
+	mockRepo.On("Create", createDTO).Return(&model.Track{ID: 1, ChatID: "chat123", Run: "12345678-9", ExternalID: 12345, LastEntry: &oldEntry}, nil)
+	mockRepo.On("Delete", deleteDTO).Return(nil)
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{TrackCacheReconcileInterval: time.Minute}
+	service, cachedRepo := newCachedTestAccessService(mockRepo, envConfig)
+
+	accesses := []*model.Access{{ExternalID: 12345, Location: 102, EntryAt: now}}
+
+	// Loads the (empty) cache from the repository
+	changes, err := service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+
+	// A created track is matched without reloading the cache
+	_, err = cachedRepo.Create(context.Background(), createDTO)
+	assert.Nil(t, err)
+	changes, err = service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, changes)
+
+	// The stored entry is kept in the cache, so it is not detected twice
+	changes, err = service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+
+	// A deleted track is no longer matched
+	assert.Nil(t, cachedRepo.Delete(context.Background(), deleteDTO))
+	changes, err = service.CheckAccess(context.Background(), []*model.Access{{ExternalID: 12345, Location: 102, EntryAt: now.Add(time.Minute)}})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+
+	// Every check is answered by the cache once loaded
+	mockRepo.AssertNumberOfCalls(t, "GetAll", 1)
+	stats := cachedRepo.CacheStats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+}
+
+func TestCheckAccess_ReconcilesCacheAfterInterval(t *testing.T) {
+	now := time.Now()
+	oldEntry := now.Add(-2 * time.Hour)
+
+	// Setup mocks, the second load sees a track created by another instance
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", LastEntry: &oldEntry},
+	}, nil).Once()
+	mockRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", LastEntry: &oldEntry},
+		{ID: 2, ChatID: "chat2", ExternalID: 54321, Run: "87654321-K", LastEntry: &oldEntry},
+	}, nil).Once()
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{TrackCacheReconcileInterval: 20 * time.Millisecond}
+	service, _ := newCachedTestAccessService(mockRepo, envConfig)
+
+	accesses := []*model.Access{{ExternalID: 54321, Location: 102, EntryAt: now}}
+
+	// Unknown to the cache while it is fresh
+	changes, err := service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+	changes, err = service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+
+	// Once stale the cache is reloaded and the new track is matched
+	time.Sleep(25 * time.Millisecond)
+	changes, err = service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, changes)
+
+	mockRepo.AssertNumberOfCalls(t, "GetAll", 2)
+}
+
+func TestCheckAccess_FailedWriteIsDetectedAgain(t *testing.T) {
+	now := time.Now()
+	oldEntry := now.Add(-2 * time.Hour)
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "chat123", ExternalID: 12345, Run: "12345678-9", LastEntry: &oldEntry},
+	}, nil).Once()
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(apperrors.NewAppError("TestError", errors.New("write error"))).Once()
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	envConfig := &config.EnvironmentConfig{TrackCacheReconcileInterval: time.Minute}
+	service, _ := newCachedTestAccessService(mockRepo, envConfig)
+
+	accesses := []*model.Access{{ExternalID: 12345, Location: 102, EntryAt: now}}
+
+	// The cache only takes the entry once it is stored
+	_, err := service.CheckAccess(context.Background(), accesses)
+	assert.NotNil(t, err)
+
+	changes, err := service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, changes)
+
+	changes, err = service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+
+	mockRepo.AssertNumberOfCalls(t, "GetAll", 1)
+	mockRepo.AssertNumberOfCalls(t, "UpdateAccess", 2)
+}
+
+func TestCheckAccess_ConcurrentChecksDetectChangesOnce(t *testing.T) {
+	now := time.Now()
+	oldEntry := now.Add(-2 * time.Hour)
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "chat123", ExternalID: 12345, Run: "12345678-9", LastEntry: &oldEntry},
+	}, nil).Once()
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{TrackCacheReconcileInterval: time.Minute}
+	service, _ := newCachedTestAccessService(mockRepo, envConfig)
+
+	// The same event pushed and polled at once
+	accesses := []*model.Access{{ExternalID: 12345, Location: 102, EntryAt: now}}
+	results := make(chan int, 2)
+	for n := 0; n < 2; n++ {
+		go func() {
+			changes, err := service.CheckAccess(context.Background(), accesses)
+			assert.Nil(t, err)
+			results <- changes
+		}()
+	}
+
+	assert.Equal(t, 1, <-results+<-results)
+	mockRepo.AssertNumberOfCalls(t, "UpdateAccess", 1)
+}
+
+func TestCheckAccess_FansOutPersonChangesToFollowers(t *testing.T) {
+	now := time.Now()
+	oldEntry := now.Add(-2 * time.Hour)
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe", LastEntry: &oldEntry},
+		{ID: 2, ChatID: "chat2", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe", LastEntry: &oldEntry},
+	}, nil).Once()
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{TrackCacheReconcileInterval: time.Minute}
+	service, _ := newCachedTestAccessService(mockRepo, envConfig)
+
+	accesses := []*model.Access{{ExternalID: 12345, Location: 102, EntryAt: now}}
+	changes, err := service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, changes)
+
+	// The person state is stored once and every follower is notified
+	call := mockRepo.Calls[len(mockRepo.Calls)-1]
+	assert.Equal(t, accesses, call.Arguments.Get(0))
+	notifications := call.Arguments.Get(2).([]*model.NotificationRequest)
+	assert.Len(t, notifications, 2)
+	assert.Equal(t, "chat1", notifications[0].ChatID)
+	assert.Equal(t, "chat2", notifications[1].ChatID)
+
+	// Both followers share the stored state
+	changes, err = service.CheckAccess(context.Background(), accesses)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+}
+
+func TestIngestAccesses_KeepsLatestEventPerPerson(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	oldEntry := now.Add(-2 * time.Hour)
+	entryAt := now.Add(-time.Hour).Format(time.RFC3339)
+	exitAt := now.Format(time.RFC3339)
+
+	// Setup mocks
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", FullName: "John Doe", LastEntry: &oldEntry},
+	}, nil).Once()
+	mockRepo.On("UpdateAccess", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	envConfig := &config.EnvironmentConfig{TrackCacheReconcileInterval: time.Minute}
+	service, _ := newCachedTestAccessService(mockRepo, envConfig)
+
+	// The entry and the exit of the same visit arrive in one batch
+	accepted, changes, err := service.IngestAccesses(context.Background(), []*response.AccessDTO{
+		{ExternalID: "12345", Run: "12345678-9", Location: "102", EntryAt: entryAt},
+		{ExternalID: "12345", Run: "12345678-9", Location: "102", EntryAt: entryAt, ExitAt: &exitAt},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, accepted)
+	assert.Equal(t, 2, changes)
+
+	entries := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(0).([]*model.Access)
+	assert.Len(t, entries, 1)
+	assert.NotNil(t, entries[0].ExitAt)
+
+	// An event older than the stored entry doesn't produce changes
+	staleEntry := now.Add(-90 * time.Minute).Format(time.RFC3339)
+	accepted, changes, err = service.IngestAccesses(context.Background(), []*response.AccessDTO{
+		{ExternalID: "12345", Run: "12345678-9", Location: "102", EntryAt: staleEntry},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, accepted)
+	assert.Equal(t, 0, changes)
+}
+
+func TestIngestAccesses_InvalidEvent(t *testing.T) {
+	mockRepo := new(MockTrackRepository)
+
+	envConfig := &config.EnvironmentConfig{}
+	service := newTestAccessService(mockRepo, envConfig)
+
+	exitAt := "2026-10-17T09:00:00Z"
+	_, _, err := service.IngestAccesses(context.Background(), []*response.AccessDTO{
+		{ExternalID: "12345", Run: "12345678-9", Location: "102", EntryAt: "2026-10-17T10:00:00Z", ExitAt: &exitAt},
+	})
+	assert.NotNil(t, err)
+	assert.True(t, err.HasType(apperrors.TypeValidation))
+	mockRepo.AssertNotCalled(t, "GetAll")
+}
+
+// Benchmarks for the access matching
+
+func BenchmarkCompareTrackAndAccess(b *testing.B) {
+	sizes := []struct {
+		tracks   int
+		accesses int
+	}{
+		{100, 50},
+		{1000, 500},
+		{10000, 5000},
+	}
+
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("tracks=%d/accesses=%d", size.tracks, size.accesses), func(b *testing.B) {
+			tracks, accesses := benchmarkTracksAndAccesses(size.tracks, size.accesses)
+			service := &accessServiceImpl{
+				locationService:  &stubLocationService{},
+				enviromentConfig: &config.EnvironmentConfig{},
+			}
+
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				index := indexTracks(tracks)
+
+				matchEntry, matchExit, _, _ := service.compareTrackAndAccess(accesses, index)
+				accessByExternalID := indexAccesses(accesses)
+				service.createNotificationRequest(model.NotificationTypeEntry, accessByExternalID, matchEntry)
+				service.createNotificationRequest(model.NotificationTypeExit, accessByExternalID, matchExit)
+			}
+		})
+	}
+}
+
+// stubLocationService avoids the mock call recording in benchmarks.
+type stubLocationService struct {
+	MockLocationService
+}
+
+func (s *stubLocationService) TimeZone(code int8) *time.Location {
+	return nil
+}
+
+// benchmarkTracksAndAccesses returns tracks for distinct people and accesses
+// for half of them, every other one with an exit.
+func benchmarkTracksAndAccesses(trackCount int, accessCount int) ([]*model.Track, []*model.Access) {
+	lastEntry := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+
+	tracks := make([]*model.Track, 0, trackCount)
+	for i := 0; i < trackCount; i++ {
+		tracks = append(tracks, &model.Track{
+			ID:         i + 1,
+			ChatID:     fmt.Sprintf("chat%d", i%100),
+			Run:        fmt.Sprintf("%08d-%d", i, i%10),
+			ExternalID: int32(i),
+			LastEntry:  &lastEntry,
+		})
+	}
+
+	accesses := make([]*model.Access, 0, accessCount)
+	for i := 0; i < accessCount; i++ {
+		access := &model.Access{
+			ExternalID: int32(i * 2),
+			Location:   102,
+			EntryAt:    lastEntry.Add(time.Duration(i) * time.Second),
+		}
+		if i%2 == 0 {
+			exitAt := access.EntryAt.Add(time.Hour)
+			access.ExitAt = &exitAt
+		}
+		accesses = append(accesses, access)
+	}
+
+	return tracks, accesses
+}
+
+// Tests for PollAccesses
+
+func TestPollAccesses_Incremental_SendsAndStoresCursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "cursor-1", r.URL.Query().Get("since"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":[],"cursor":"cursor-2"}`))
+	}))
+	defer server.Close()
+
+	mockCursorRepo := new(MockAccessCursorRepository)
+	mockCursorRepo.On("Get", accessCursorSource).Return(stringPtr("cursor-1"), nil)
+	mockCursorRepo.On("Save", accessCursorSource, "cursor-2").Return(nil)
+
+	envConfig := &config.EnvironmentConfig{
+		AccessServiceBaseUrl: server.URL,
+		AccessFetchMode:      AccessFetchModeIncremental,
+	}
+	service := newTestAccessService(new(MockTrackRepository), envConfig)
+	service.accessCursorRepository = mockCursorRepo
+
+	changes, err := service.PollAccesses(context.Background())
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, changes)
+	mockCursorRepo.AssertExpectations(t)
+}
+
+func TestPollAccesses_Incremental_KeepsLatestEventOfPerson(t *testing.T) {
+	// The delta holds the entry and then the exit of the same visit
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":[
+			{"externalId":"12345","run":"12345678-9","location":"102","entryAt":"2025-10-05T12:00:00Z"},
+			{"externalId":"12345","run":"12345678-9","location":"102","entryAt":"2025-10-05T12:00:00Z","exitAt":"2025-10-05T14:00:00Z"}
+		],"cursor":"cursor-2"}`))
+	}))
+	defer server.Close()
+
+	oldEntry := time.Date(2025, 10, 4, 12, 0, 0, 0, time.UTC)
+	oldExit := oldEntry.Add(time.Hour)
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "chat123", ExternalID: 12345, Run: "12345678-9", LastEntry: &oldEntry, LastExit: &oldExit},
+	}, nil)
+	mockRepo.On("UpdateAccess",
+		mock.MatchedBy(func(entries []*model.Access) bool { return len(entries) == 1 }),
+		mock.MatchedBy(func(exits []*model.Access) bool {
+			return len(exits) == 1 && exits[0].ExitAt.Equal(time.Date(2025, 10, 5, 14, 0, 0, 0, time.UTC))
+		}),
+		mock.Anything,
+	).Return(nil)
+
+	mockCursorRepo := new(MockAccessCursorRepository)
+	mockCursorRepo.On("Get", accessCursorSource).Return((*string)(nil), nil)
+	mockCursorRepo.On("Save", accessCursorSource, "cursor-2").Return(nil)
+
+	envConfig := &config.EnvironmentConfig{
+		AccessServiceBaseUrl: server.URL,
+		AccessFetchMode:      AccessFetchModeIncremental,
+	}
+	service := newTestAccessService(mockRepo, envConfig)
+	service.accessCursorRepository = mockCursorRepo
+
+	changes, err := service.PollAccesses(context.Background())
+
+	// The exit isn't lost behind the entry before the cursor moves past it
+	assert.Nil(t, err)
+	assert.Equal(t, 2, changes)
+	mockRepo.AssertExpectations(t)
+	mockCursorRepo.AssertExpectations(t)
+}
+
+func TestPollAccesses_Incremental_FallsBackToSnapshot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.URL.Query().Get("since"))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":[{"externalId":"12345","run":"12345678-9","location":"102","entryAt":"2025-10-05T16:59:48Z"}]}`))
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockTrackRepository)
+	mockRepo.On("GetAll").Return([]*model.Track{}, nil)
+
+	mockCursorRepo := new(MockAccessCursorRepository)
+	mockCursorRepo.On("Get", accessCursorSource).Return((*string)(nil), nil)
+
+	envConfig := &config.EnvironmentConfig{
+		AccessServiceBaseUrl: server.URL,
+		AccessFetchMode:      AccessFetchModeIncremental,
+	}
+	service := newTestAccessService(mockRepo, envConfig)
+	service.accessCursorRepository = mockCursorRepo
+
+	_, err := service.PollAccesses(context.Background())
+
+	// The snapshot is processed and no cursor is stored
+	assert.Nil(t, err)
+	mockRepo.AssertCalled(t, "GetAll")
+	mockCursorRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+}
+
 // Helper function
 func stringPtr(s string) *string {
 	return &s
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
+	"time"
+)
+
+const (
+	apiKeyPrefix = "spl_"
+	// apiKeyLastUsedResolution limits the writes of last_used_at to one per
+	// key and interval, instead of one per request.
+	apiKeyLastUsedResolution = time.Minute
+)
+
+type apiKeyServiceImpl struct {
+	apiKeyRepository repository.ApiKeyRepository
+	auditService     AuditService
+	now              func() time.Time
+}
+
+func NewApiKeyServiceImpl(
+	apiKeyRepository repository.ApiKeyRepository,
+	auditService AuditService,
+) ApiKeyService {
+	return &apiKeyServiceImpl{
+		apiKeyRepository: apiKeyRepository,
+		auditService:     auditService,
+		now:              time.Now,
+	}
+}
+
+// Create stores a new key and returns it along with its plain value, which
+// is not stored and can't be retrieved afterwards.
+func (s *apiKeyServiceImpl) Create(ctx context.Context, apiKeyDTO *request.CreateApiKeyDTO) (*model.ApiKey, string, *errors.AppError) {
+	now := s.now().UTC().Truncate(time.Second)
+	if apiKeyDTO.ExpiresAt != nil && !apiKeyDTO.ExpiresAt.After(now) {
+		return nil, "", errors.NewAppErrorWithType("ApiKeyService", errors.TypeValidation,
+			fmt.Errorf("expiresAt must be in the future"))
+	}
+
+	if apiKeyDTO.ChatIDs != nil && slices.Contains(apiKeyDTO.Scopes, model.ScopeAdmin) {
+		return nil, "", errors.NewAppErrorWithType("ApiKeyService", errors.TypeValidation,
+			fmt.Errorf("a key restricted to chats can't have the admin scope"))
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", s.error(err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+
+	scopes := slices.Clone(apiKeyDTO.Scopes)
+	slices.Sort(scopes)
+
+	var chatIDs []string
+	if apiKeyDTO.ChatIDs != nil {
+		chatIDs = slices.Clone(apiKeyDTO.ChatIDs)
+		slices.Sort(chatIDs)
+		chatIDs = slices.Compact(chatIDs)
+	}
+
+	apiKey := &model.ApiKey{
+		Name:      apiKeyDTO.Name,
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		Hash:      hashApiKey(key),
+		Scopes:    slices.Compact(scopes),
+		ChatIDs:   chatIDs,
+		ExpiresAt: apiKeyDTO.ExpiresAt,
+		CreatedAt: now,
+	}
+	if err := s.apiKeyRepository.Create(ctx, apiKey); err != nil {
+		return nil, "", err
+	}
+
+	log.Printf("[ApiKeyService] Created API key %d (%s) with scopes %v", apiKey.ID, apiKey.Name, apiKey.Scopes)
+	s.auditService.Record(ctx, model.AuditActionApiKeyCreate, "", "", nil, apiKey)
+	return apiKey, key, nil
+}
+
+func (s *apiKeyServiceImpl) GetAll(ctx context.Context) ([]*model.ApiKey, *errors.AppError) {
+	return s.apiKeyRepository.GetAll(ctx)
+}
+
+func (s *apiKeyServiceImpl) Revoke(ctx context.Context, id int64) *errors.AppError {
+	apiKey, err := s.apiKeyRepository.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if apiKey == nil {
+		return errors.NewAppErrorWithType("ApiKeyService", errors.TypeNotFound,
+			fmt.Errorf("API key %d not found", id))
+	}
+	if apiKey.RevokedAt != nil {
+		return errors.NewAppErrorWithType("ApiKeyService", errors.TypeValidation,
+			fmt.Errorf("API key %d is already revoked", id))
+	}
+
+	if err := s.apiKeyRepository.Revoke(ctx, id, s.now()); err != nil {
+		return err
+	}
+
+	log.Printf("[ApiKeyService] Revoked API key %d (%s)", apiKey.ID, apiKey.Name)
+	s.auditService.Record(ctx, model.AuditActionApiKeyRevoke, "", "", apiKey, nil)
+	return nil
+}
+
+// Authenticate returns the caller owning key, nil when the key is unknown,
+// revoked or expired.
+func (s *apiKeyServiceImpl) Authenticate(ctx context.Context, key string) (*model.Caller, *errors.AppError) {
+	apiKey, err := s.apiKeyRepository.GetByHash(ctx, hashApiKey(key))
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if apiKey == nil || !apiKey.IsActive(now) {
+		return nil, nil
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedResolution {
+		if err := s.apiKeyRepository.UpdateLastUsed(ctx, apiKey.ID, now); err != nil {
+			log.Printf("[ApiKeyService] Error updating last use of API key %d: %s", apiKey.ID, err.Error())
+		}
+	}
+
+	return &model.Caller{
+		Name:     apiKey.Name,
+		ApiKeyID: &apiKey.ID,
+		Scopes:   apiKey.Scopes,
+		ChatIDs:  apiKey.ChatIDs,
+	}, nil
+}
+
+func hashApiKey(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(digest[:])
+}
+
+func (s *apiKeyServiceImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("ApiKeyService", err)
+}
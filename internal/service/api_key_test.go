@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MockApiKeyRepository keeps the API keys in memory.
+type MockApiKeyRepository struct {
+	apiKeys         []*model.ApiKey
+	lastUsedUpdates int
+}
+
+func (m *MockApiKeyRepository) Create(ctx context.Context, apiKey *model.ApiKey) *apperrors.AppError {
+	apiKey.ID = int64(len(m.apiKeys) + 1)
+	m.apiKeys = append(m.apiKeys, apiKey)
+	return nil
+}
+
+func (m *MockApiKeyRepository) GetAll(ctx context.Context) ([]*model.ApiKey, *apperrors.AppError) {
+	return m.apiKeys, nil
+}
+
+func (m *MockApiKeyRepository) GetById(ctx context.Context, id int64) (*model.ApiKey, *apperrors.AppError) {
+	for _, apiKey := range m.apiKeys {
+		if apiKey.ID == id {
+			return apiKey, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockApiKeyRepository) GetByHash(ctx context.Context, hash string) (*model.ApiKey, *apperrors.AppError) {
+	for _, apiKey := range m.apiKeys {
+		if apiKey.Hash == hash {
+			return apiKey, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockApiKeyRepository) Revoke(ctx context.Context, id int64, revokedAt time.Time) *apperrors.AppError {
+	apiKey, _ := m.GetById(ctx, id)
+	apiKey.RevokedAt = &revokedAt
+	return nil
+}
+
+func (m *MockApiKeyRepository) UpdateLastUsed(ctx context.Context, id int64, usedAt time.Time) *apperrors.AppError {
+	apiKey, _ := m.GetById(ctx, id)
+	apiKey.LastUsedAt = &usedAt
+	m.lastUsedUpdates++
+	return nil
+}
+
+func TestApiKeyService_CreateAuthenticateAndRevoke(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	repo := &MockApiKeyRepository{}
+	service := NewApiKeyServiceImpl(repo, &MockAuditService{}).(*apiKeyServiceImpl)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	apiKey, key, err := service.Create(ctx, &request.CreateApiKeyDTO{
+		Name:   "whatsapp-bot",
+		Scopes: []string{model.ScopeTracksWrite, model.ScopeTracksRead, model.ScopeTracksRead},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{model.ScopeTracksRead, model.ScopeTracksWrite}, apiKey.Scopes)
+	assert.NotContains(t, apiKey.Hash, key)
+	assert.Equal(t, key[:len(apiKey.Prefix)], apiKey.Prefix)
+
+	// The key identifies its caller, last use is written once per minute
+	caller, err := service.Authenticate(ctx, key)
+	assert.Nil(t, err)
+	assert.Equal(t, "whatsapp-bot", caller.Name)
+	assert.True(t, caller.HasScope(model.ScopeTracksRead))
+	assert.False(t, caller.HasScope(model.ScopeAdmin))
+	_, _ = service.Authenticate(ctx, key)
+	assert.Equal(t, 1, repo.lastUsedUpdates)
+
+	caller, err = service.Authenticate(ctx, key+"x")
+	assert.Nil(t, err)
+	assert.Nil(t, caller)
+
+	// A revoked key no longer authenticates and can't be revoked again
+	assert.Nil(t, service.Revoke(ctx, apiKey.ID))
+	caller, _ = service.Authenticate(ctx, key)
+	assert.Nil(t, caller)
+	err = service.Revoke(ctx, apiKey.ID)
+	assert.True(t, err.HasType(apperrors.TypeValidation))
+	err = service.Revoke(ctx, 99)
+	assert.True(t, err.HasType(apperrors.TypeNotFound))
+}
+
+func TestApiKeyService_ExpiredKey(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	repo := &MockApiKeyRepository{}
+	service := NewApiKeyServiceImpl(repo, &MockAuditService{}).(*apiKeyServiceImpl)
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	past := now.Add(-time.Hour)
+	_, _, err := service.Create(ctx, &request.CreateApiKeyDTO{Name: "script", Scopes: []string{model.ScopeAdmin}, ExpiresAt: &past})
+	assert.True(t, err.HasType(apperrors.TypeValidation))
+
+	expiresAt := now.Add(time.Hour)
+	_, key, err := service.Create(ctx, &request.CreateApiKeyDTO{Name: "script", Scopes: []string{model.ScopeAdmin}, ExpiresAt: &expiresAt})
+	assert.Nil(t, err)
+
+	caller, _ := service.Authenticate(ctx, key)
+	assert.True(t, caller.HasScope(model.ScopeMessagesSend))
+
+	now = expiresAt
+	caller, _ = service.Authenticate(ctx, key)
+	assert.Nil(t, caller)
+}
+
+func TestApiKeyService_ChatBoundKey(t *testing.T) {
+	repo := &MockApiKeyRepository{}
+	service := NewApiKeyServiceImpl(repo, &MockAuditService{})
+	ctx := context.Background()
+
+	// Admin could create an unrestricted key, it can't be bound to chats
+	_, _, err := service.Create(ctx, &request.CreateApiKeyDTO{Name: "bot", Scopes: []string{model.ScopeAdmin}, ChatIDs: []string{"chatA"}})
+	assert.True(t, err.HasType(apperrors.TypeValidation))
+
+	_, key, err := service.Create(ctx, &request.CreateApiKeyDTO{Name: "bot", Scopes: []string{model.ScopeTracksRead}, ChatIDs: []string{"chatB", "chatA", "chatA"}})
+	assert.Nil(t, err)
+
+	caller, _ := service.Authenticate(ctx, key)
+	assert.Equal(t, []string{"chatA", "chatB"}, caller.ChatIDs)
+	assert.True(t, caller.CanAccessChat("chatA"))
+	assert.False(t, caller.CanAccessChat("chatC"))
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
+	"time"
+)
+
+// systemActor records the changes made without an authenticated caller, such
+// as the scheduled jobs.
+const systemActor = "system"
+
+type callerContextKey struct{}
+
+// ContextWithCaller attaches the authenticated caller to ctx, it is recorded
+// as the actor of the changes made with that context.
+func ContextWithCaller(ctx context.Context, caller *model.Caller) context.Context {
+	return context.WithValue(ctx, callerContextKey{}, caller)
+}
+
+func callerFromContext(ctx context.Context) *model.Caller {
+	caller, _ := ctx.Value(callerContextKey{}).(*model.Caller)
+	return caller
+}
+
+type auditServiceImpl struct {
+	auditEventRepository repository.AuditEventRepository
+	now                  func() time.Time
+}
+
+func NewAuditServiceImpl(auditEventRepository repository.AuditEventRepository) AuditService {
+	return &auditServiceImpl{
+		auditEventRepository: auditEventRepository,
+		now:                  time.Now,
+	}
+}
+
+// Record stores the change made by the caller of ctx. before and after are
+// serialized as JSON, nil when the entity didn't exist on that side. The
+// change has already been applied, so a failure is logged and not returned.
+func (a *auditServiceImpl) Record(ctx context.Context, action string, chatId string, run string, before any, after any) {
+	event := &model.AuditEvent{
+		Actor:     systemActor,
+		Action:    action,
+		Before:    auditPayload(before),
+		After:     auditPayload(after),
+		CreatedAt: a.now(),
+	}
+	if caller := callerFromContext(ctx); caller != nil {
+		event.Actor = caller.Name
+		event.ApiKeyID = caller.ApiKeyID
+	}
+	if chatId != "" {
+		event.ChatID = &chatId
+	}
+	if run != "" {
+		event.Run = &run
+	}
+
+	if err := a.auditEventRepository.Create(ctx, event); err != nil {
+		log.Printf("[AuditService] Error recording %s by %s: %s", action, event.Actor, err.Error())
+	}
+}
+
+func (a *auditServiceImpl) GetEvents(ctx context.Context, filter *request.AuditFilterDTO) ([]*model.AuditEvent, *errors.AppError) {
+	return a.auditEventRepository.GetAll(ctx, filter)
+}
+
+func auditPayload(value any) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil || string(payload) == "null" {
+		return nil
+	}
+	return payload
+}
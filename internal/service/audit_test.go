@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MockAuditService keeps the recorded actions in memory.
+type MockAuditService struct {
+	actions []string
+}
+
+func (m *MockAuditService) Record(ctx context.Context, action string, chatId string, run string, before any, after any) {
+	m.actions = append(m.actions, action)
+}
+
+func (m *MockAuditService) GetEvents(ctx context.Context, filter *request.AuditFilterDTO) ([]*model.AuditEvent, *apperrors.AppError) {
+	return nil, nil
+}
+
+// MockAuditEventRepository keeps the audit events in memory.
+type MockAuditEventRepository struct {
+	events []*model.AuditEvent
+}
+
+func (m *MockAuditEventRepository) Create(ctx context.Context, event *model.AuditEvent) *apperrors.AppError {
+	event.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *MockAuditEventRepository) GetAll(ctx context.Context, filter *request.AuditFilterDTO) ([]*model.AuditEvent, *apperrors.AppError) {
+	return m.events, nil
+}
+
+func TestAuditService_RecordsCallerAndPayloads(t *testing.T) {
+	repo := &MockAuditEventRepository{}
+	service := NewAuditServiceImpl(repo)
+
+	apiKeyID := int64(3)
+	ctx := ContextWithCaller(context.Background(), &model.Caller{Name: "whatsapp-bot", ApiKeyID: &apiKeyID})
+	track := &model.Track{ID: 1, ChatID: "chat1", Run: "12345678-9"}
+	service.Record(ctx, model.AuditActionTrackDelete, "chat1", "12345678-9", track, nil)
+
+	// Without a caller the change is attributed to the system
+	var missing *model.Track
+	service.Record(context.Background(), model.AuditActionLocationCreate, "", "", missing, track)
+
+	assert.Len(t, repo.events, 2)
+
+	deleted := repo.events[0]
+	assert.Equal(t, "whatsapp-bot", deleted.Actor)
+	assert.Equal(t, &apiKeyID, deleted.ApiKeyID)
+	assert.Equal(t, "chat1", *deleted.ChatID)
+	assert.Nil(t, deleted.After)
+	var before model.Track
+	assert.NoError(t, json.Unmarshal(deleted.Before, &before))
+	assert.Equal(t, "12345678-9", before.Run)
+
+	created := repo.events[1]
+	assert.Equal(t, systemActor, created.Actor)
+	assert.Nil(t, created.ChatID)
+	assert.Nil(t, created.Run)
+	assert.Nil(t, created.Before)
+	assert.NotNil(t, created.After)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"spl-notification/internal/config"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"sync"
+	"time"
+)
+
+// CircuitBreakers holds the breakers of the HTTP dependencies, shared by the
+// services that call them and reported on the health endpoint.
+type CircuitBreakers struct {
+	Notification *circuitBreaker
+	Source       *circuitBreaker
+	Access       *circuitBreaker
+}
+
+func NewCircuitBreakers(enviromentConfig *config.EnvironmentConfig) *CircuitBreakers {
+	threshold := enviromentConfig.CircuitBreakerFailureThreshold
+	openTimeout := enviromentConfig.CircuitBreakerOpenTimeout
+
+	return &CircuitBreakers{
+		Notification: newCircuitBreaker("notification", threshold, openTimeout),
+		Source:       newCircuitBreaker("source", threshold, openTimeout),
+		Access:       newCircuitBreaker("access", threshold, openTimeout),
+	}
+}
+
+func (b *CircuitBreakers) Stats() []model.CircuitBreakerStats {
+	return []model.CircuitBreakerStats{b.Notification.stats(), b.Source.stats(), b.Access.stats()}
+}
+
+// errCircuitOpen is returned without calling the dependency while its breaker
+// is open.
+type errCircuitOpen struct {
+	name string
+}
+
+func (e *errCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open", e.name)
+}
+
+// isCircuitOpen reports whether the call was rejected by an open breaker.
+func isCircuitOpen(err *errors.AppError) bool {
+	_, ok := err.Err.(*errCircuitOpen)
+	return ok
+}
+
+// httpStatusError is an unexpected response status of a dependency.
+type httpStatusError struct {
+	operation  string
+	statusCode int
+	status     string
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.operation, e.status)
+}
+
+// isCanceled reports whether the call was abandoned by its caller, as on
+// shutdown, which says nothing about the dependency.
+func isCanceled(err *errors.AppError) bool {
+	return stderrors.Is(err.Err, context.Canceled)
+}
+
+// isDependencyFailure reports whether the error means the dependency is
+// unavailable: network errors, timeouts, rate limits and server errors. Other
+// rejections, such as an unknown chat, come from a working dependency.
+func isDependencyFailure(err *errors.AppError) bool {
+	if isCircuitOpen(err) {
+		return false
+	}
+
+	statusErr, ok := err.Err.(*httpStatusError)
+	if !ok {
+		return true
+	}
+
+	switch {
+	case statusErr.statusCode == http.StatusRequestTimeout,
+		statusErr.statusCode == http.StatusTooManyRequests,
+		statusErr.statusCode >= http.StatusInternalServerError:
+		return true
+	default:
+		return false
+	}
+}
+
+// circuitBreaker opens after threshold consecutive failures and rejects the
+// calls until openTimeout has passed. Then a single probe is let through
+// (half-open), closing the breaker if it succeeds or opening it again if it
+// fails. A threshold of 0 disables the breaker.
+type circuitBreaker struct {
+	name        string
+	threshold   int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	state    model.CircuitState
+	failures int
+	openedAt time.Time
+}
+
+func newCircuitBreaker(name string, threshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		name:        name,
+		threshold:   threshold,
+		openTimeout: openTimeout,
+		state:       model.CircuitStateClosed,
+	}
+}
+
+// execute runs the call unless the breaker is open, and records its result.
+func (b *circuitBreaker) execute(call func() *errors.AppError) *errors.AppError {
+	if !b.allow() {
+		return errors.NewAppError("CircuitBreaker", &errCircuitOpen{name: b.name})
+	}
+
+	return b.record(call())
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case model.CircuitStateOpen:
+		if time.Since(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = model.CircuitStateHalfOpen
+		return true
+	case model.CircuitStateHalfOpen:
+		// Only the probe goes through until it finishes
+		return false
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) record(err *errors.AppError) *errors.AppError {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err != nil && isCanceled(err) {
+		// Neither a failure nor a success, a canceled probe is let through
+		// again on the next call
+		if b.state == model.CircuitStateHalfOpen {
+			b.state = model.CircuitStateOpen
+		}
+		return err
+	}
+
+	if err == nil || !isDependencyFailure(err) {
+		b.state = model.CircuitStateClosed
+		b.failures = 0
+		return err
+	}
+
+	b.failures++
+	if b.threshold > 0 && (b.state == model.CircuitStateHalfOpen || b.failures >= b.threshold) {
+		b.state = model.CircuitStateOpen
+		b.openedAt = time.Now()
+	}
+	return err
+}
+
+func (b *circuitBreaker) stats() model.CircuitBreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := model.CircuitBreakerStats{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+	}
+	if b.state != model.CircuitStateClosed {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+	return stats
+}
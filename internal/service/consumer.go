@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"spl-notification/internal/errors"
+	"sync"
+)
+
+// consumerServiceImpl runs the notification consumer in the background until
+// Stop, which waits for the in-flight messages and closes the queue.
+type consumerServiceImpl struct {
+	notificationService NotificationService
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewConsumerServiceImpl(notificationService NotificationService) ConsumerService {
+	return &consumerServiceImpl{
+		notificationService: notificationService,
+	}
+}
+
+func (c *consumerServiceImpl) Start() *errors.AppError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done != nil {
+		return c.error(fmt.Errorf("consumer already started"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.notificationService.HandleNotification(ctx)
+	}()
+
+	c.cancel = cancel
+	c.done = done
+	return nil
+}
+
+func (c *consumerServiceImpl) Stop(ctx context.Context) *errors.AppError {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.done == nil {
+		return nil
+	}
+
+	c.cancel()
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return c.error(ctx.Err())
+	}
+	c.done = nil
+
+	if err := c.notificationService.Close(); err != nil {
+		return c.error(err)
+	}
+	return nil
+}
+
+func (c *consumerServiceImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("ConsumerService", err)
+}
@@ -1,32 +1,110 @@
 package service
 
 import (
+	"context"
 	"spl-notification/internal/dto/request"
+	"spl-notification/internal/dto/response"
 	"spl-notification/internal/errors"
 	"spl-notification/internal/model"
+	"time"
 )
 
 type AccessService interface {
-	CheckAccess(access []*model.Access) *errors.AppError
-	GetCompleteAccess() ([]*model.Access, *errors.AppError)
+	// CheckAccess returns the number of entries and exits detected.
+	CheckAccess(ctx context.Context, access []*model.Access) (int, *errors.AppError)
+	GetCompleteAccess(ctx context.Context) ([]*model.Access, *errors.AppError)
+	// PollAccesses fetches the accesses (or only their changes) and checks
+	// them, returning the number of entries and exits detected.
+	PollAccesses(ctx context.Context) (int, *errors.AppError)
+	// IngestAccesses checks pushed access events, returning the events kept
+	// after de-duplication and the number of entries and exits detected.
+	IngestAccesses(ctx context.Context, events []*response.AccessDTO) (int, int, *errors.AppError)
+	CheckLongStays(ctx context.Context) *errors.AppError
+	CheckInactivity(ctx context.Context) *errors.AppError
 }
 
 type NotificationService interface {
-	SendNotification(tracks []*model.NotificationRequest) *errors.AppError
-	HandleNotification()
-	SendTracks(chatId string, tracks []*model.Track) *errors.AppError
-	SendMessage(chatID string, message string) *errors.AppError
+	SendNotification(ctx context.Context, tracks []*model.NotificationRequest) *errors.AppError
+	HandleNotification(ctx context.Context)
+	SendTracks(ctx context.Context, chatId string, tracks []*model.Track) *errors.AppError
+	SendMessage(ctx context.Context, chatID string, message string) *errors.AppError
+	GetNotificationHistory(ctx context.Context, filter *request.NotificationHistoryDTO) ([]*model.NotificationLog, *errors.AppError)
+	SendQuietHoursSummaries(ctx context.Context) *errors.AppError
+	PurgeDeliveries(ctx context.Context) *errors.AppError
+	GetDeadLetters(ctx context.Context, includeReplayed bool, limit int) ([]*model.DeadLetter, *errors.AppError)
+	ReplayDeadLetter(ctx context.Context, id int64) *errors.AppError
 	Close() error
 }
 
+type OutboxService interface {
+	RelayNotifications(ctx context.Context) *errors.AppError
+}
+
 type TrackService interface {
-	SendAllFollows(chatId string) *errors.AppError
-	GetFollowTracksByChatId(chatId string) ([]*model.Track, *errors.AppError)
-	Create(trackDTO *request.CreateTrackDTO) *errors.AppError
-	Delete(deleteDTO *request.DeleteTrackDTO) *errors.AppError
+	SendAllFollows(ctx context.Context, chatId string) *errors.AppError
+	GetFollowTracksByChatId(ctx context.Context, chatId string) ([]*model.Track, *errors.AppError)
+	GetVisitHistory(ctx context.Context, historyDTO *request.VisitHistoryDTO) ([]*model.Visit, int, *errors.AppError)
+	Create(ctx context.Context, trackDTO *request.CreateTrackDTO) *errors.AppError
+	UpdateFilters(ctx context.Context, filtersDTO *request.UpdateTrackFiltersDTO) (*model.Track, *errors.AppError)
+	Delete(ctx context.Context, deleteDTO *request.DeleteTrackDTO) *errors.AppError
+	Restore(ctx context.Context, restoreDTO *request.RestoreTrackDTO) (*model.Track, *errors.AppError)
+}
+
+type PreferencesService interface {
+	GetPreferences(ctx context.Context, chatId string) (*model.ChatPreferences, *errors.AppError)
+	UpdatePreferences(ctx context.Context, preferencesDTO *request.UpdatePreferencesDTO) (*model.ChatPreferences, *errors.AppError)
+}
+
+type StatsService interface {
+	GetStatsByChatId(ctx context.Context, chatId string, days int) ([]*model.VisitStats, *errors.AppError)
+	SendWeeklyDigest(ctx context.Context) *errors.AppError
+}
+
+type LocationService interface {
+	Load(ctx context.Context) *errors.AppError
+	GetAll(ctx context.Context) ([]*model.Location, *errors.AppError)
+	Create(ctx context.Context, locationDTO *request.LocationDTO) *errors.AppError
+	Update(ctx context.Context, locationDTO *request.LocationDTO) *errors.AppError
+	Delete(ctx context.Context, code int8) *errors.AppError
+	CheckCode(code int8)
+	UnknownCodes() []int8
+	TimeZone(code int8) *time.Location
+	// Name returns the name of an active location, UnknownLocationName for
+	// the inactive and unknown ones.
+	Name(code int8) string
+	// ActiveCodes returns the codes of the active locations, sorted.
+	ActiveCodes() []int8
+}
+
+// SchedulerService runs the periodic jobs, Stop waits for the running ones.
+type SchedulerService interface {
+	Start() *errors.AppError
+	Stop(ctx context.Context) *errors.AppError
+}
+
+// ConsumerService runs the notification consumer, Stop waits for the
+// in-flight messages and closes the queue.
+type ConsumerService interface {
+	Start() *errors.AppError
+	Stop(ctx context.Context) *errors.AppError
+}
+
+type ApiKeyService interface {
+	// Create returns the stored key along with its plain value, only
+	// available at creation.
+	Create(ctx context.Context, apiKeyDTO *request.CreateApiKeyDTO) (*model.ApiKey, string, *errors.AppError)
+	GetAll(ctx context.Context) ([]*model.ApiKey, *errors.AppError)
+	Revoke(ctx context.Context, id int64) *errors.AppError
+	Authenticate(ctx context.Context, key string) (*model.Caller, *errors.AppError)
+}
+
+// AuditService records the changes along with the caller that made them.
+type AuditService interface {
+	Record(ctx context.Context, action string, chatId string, run string, before any, after any)
+	GetEvents(ctx context.Context, filter *request.AuditFilterDTO) ([]*model.AuditEvent, *errors.AppError)
 }
 
 type SourceService interface {
-	GetABMUserByRun(run string) (*model.ABMUser, *errors.AppError)
-	GetUserByExternalId(externalId int32) (*model.User, *errors.AppError)
+	GetABMUserByRun(ctx context.Context, run string) (*model.ABMUser, *errors.AppError)
+	GetUserByExternalId(ctx context.Context, externalId int32) (*model.User, *errors.AppError)
 }
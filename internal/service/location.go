@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"spl-notification/internal/config"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
+	"sync"
+	"time"
+)
+
+// locationServiceImpl keeps the location catalogue cached in memory. The
+// cache is reloaded on startup and after every change made through the API.
+type locationServiceImpl struct {
+	locationRepository repository.LocationRepository
+	auditService       AuditService
+
+	mu           sync.RWMutex
+	locations    map[int8]*model.Location
+	unknownCodes map[int8]bool
+}
+
+func NewLocationServiceImpl(
+	locationRepository repository.LocationRepository,
+	auditService AuditService,
+) LocationService {
+	return &locationServiceImpl{
+		locationRepository: locationRepository,
+		auditService:       auditService,
+		locations:          make(map[int8]*model.Location),
+		unknownCodes:       make(map[int8]bool),
+	}
+}
+
+func (l *locationServiceImpl) Load(ctx context.Context) *errors.AppError {
+	locations, err := l.locationRepository.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	cache := make(map[int8]*model.Location, len(locations))
+	for _, location := range locations {
+		cache[location.Code] = location
+	}
+
+	l.mu.Lock()
+	l.locations = cache
+	for code := range l.unknownCodes {
+		if _, ok := cache[code]; ok {
+			delete(l.unknownCodes, code)
+		}
+	}
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *locationServiceImpl) GetAll(ctx context.Context) ([]*model.Location, *errors.AppError) {
+	return l.locationRepository.GetAll(ctx)
+}
+
+func (l *locationServiceImpl) Create(ctx context.Context, locationDTO *request.LocationDTO) *errors.AppError {
+	if err := l.validateTimeZone(locationDTO); err != nil {
+		return err
+	}
+
+	if err := l.locationRepository.Create(ctx, locationDTO); err != nil {
+		return err
+	}
+
+	if err := l.Load(ctx); err != nil {
+		return err
+	}
+	l.auditService.Record(ctx, model.AuditActionLocationCreate, "", "", nil, l.location(locationDTO.Code))
+
+	return nil
+}
+
+func (l *locationServiceImpl) Update(ctx context.Context, locationDTO *request.LocationDTO) *errors.AppError {
+	if err := l.validateTimeZone(locationDTO); err != nil {
+		return err
+	}
+
+	before := l.location(locationDTO.Code)
+	updated, err := l.locationRepository.Update(ctx, locationDTO)
+	if err != nil {
+		return err
+	}
+
+	if !updated {
+		return errors.NewAppErrorWithType("LocationService", errors.TypeNotFound,
+			fmt.Errorf("location %d not found", locationDTO.Code))
+	}
+
+	if err := l.Load(ctx); err != nil {
+		return err
+	}
+	l.auditService.Record(ctx, model.AuditActionLocationUpdate, "", "", before, l.location(locationDTO.Code))
+
+	return nil
+}
+
+func (l *locationServiceImpl) Delete(ctx context.Context, code int8) *errors.AppError {
+	before := l.location(code)
+	deleted, err := l.locationRepository.Delete(ctx, code)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return errors.NewAppErrorWithType("LocationService", errors.TypeNotFound,
+			fmt.Errorf("location %d not found", code))
+	}
+
+	if err := l.Load(ctx); err != nil {
+		return err
+	}
+	l.auditService.Record(ctx, model.AuditActionLocationDelete, "", "", before, nil)
+
+	return nil
+}
+
+// location returns a copy of the loaded location, nil when it is unknown.
+func (l *locationServiceImpl) location(code int8) *model.Location {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	location, ok := l.locations[code]
+	if !ok {
+		return nil
+	}
+	copied := *location
+	return &copied
+}
+
+// CheckCode logs, once per code, the locations seen in the accesses that are
+// not in the catalogue, so new venues are noticed right away.
+func (l *locationServiceImpl) CheckCode(code int8) {
+	l.mu.RLock()
+	_, known := l.locations[code]
+	reported := l.unknownCodes[code]
+	l.mu.RUnlock()
+
+	if known || reported {
+		return
+	}
+
+	l.mu.Lock()
+	l.unknownCodes[code] = true
+	l.mu.Unlock()
+
+	log.Printf("[LocationService] Unknown location code %d seen in accesses, add it to the location catalogue\n", code)
+}
+
+func (l *locationServiceImpl) UnknownCodes() []int8 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	codes := make([]int8, 0, len(l.unknownCodes))
+	for code := range l.unknownCodes {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+	return codes
+}
+
+// TimeZone returns the time zone of the location, or nil when it has none.
+func (l *locationServiceImpl) TimeZone(code int8) *time.Location {
+	l.mu.RLock()
+	location, ok := l.locations[code]
+	l.mu.RUnlock()
+
+	if !ok || location.TimeZone == nil {
+		return nil
+	}
+
+	timeZone, err := config.ParseZone(*location.TimeZone)
+	if err != nil {
+		return nil
+	}
+	return timeZone
+}
+
+func (l *locationServiceImpl) Name(code int8) string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	location, ok := l.locations[code]
+	if !ok || !location.Active {
+		return model.UnknownLocationName
+	}
+	return location.Name
+}
+
+func (l *locationServiceImpl) ActiveCodes() []int8 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	codes := make([]int8, 0, len(l.locations))
+	for code, location := range l.locations {
+		if location.Active {
+			codes = append(codes, code)
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+
+	return codes
+}
+
+func (l *locationServiceImpl) validateTimeZone(locationDTO *request.LocationDTO) *errors.AppError {
+	if locationDTO.TimeZone == nil {
+		return nil
+	}
+
+	if _, err := config.ParseZone(*locationDTO.TimeZone); err != nil {
+		return errors.NewAppErrorWithType("LocationService", errors.TypeValidation, err)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockLocationRepository struct {
+	mock.Mock
+}
+
+func (m *MockLocationRepository) GetAll(ctx context.Context) ([]*model.Location, *apperrors.AppError) {
+	args := m.Called()
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.Location), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockLocationRepository) Create(ctx context.Context, locationDTO *request.LocationDTO) *apperrors.AppError {
+	args := m.Called(locationDTO)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockLocationRepository) Update(ctx context.Context, locationDTO *request.LocationDTO) (bool, *apperrors.AppError) {
+	args := m.Called(locationDTO)
+	if args.Get(1) == nil {
+		return args.Bool(0), nil
+	}
+	return false, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockLocationRepository) Delete(ctx context.Context, code int8) (bool, *apperrors.AppError) {
+	args := m.Called(code)
+	if args.Get(1) == nil {
+		return args.Bool(0), nil
+	}
+	return false, args.Get(1).(*apperrors.AppError)
+}
+
+func TestLocationService_Name(t *testing.T) {
+	timeZone := "America/Punta_Arenas"
+	mockRepo := new(MockLocationRepository)
+	mockRepo.On("GetAll").Return([]*model.Location{
+		{Code: 102, Name: "Espacio Urbano", TimeZone: &timeZone, Active: true},
+		{Code: 104, Name: "Calama", Active: false},
+	}, nil)
+
+	service := NewLocationServiceImpl(mockRepo, &MockAuditService{})
+	require.Nil(t, service.Load(context.Background()))
+
+	tests := []struct {
+		name     string
+		code     int8
+		expected string
+	}{
+		{"active location", 102, "Espacio Urbano"},
+		{"inactive location", 104, model.UnknownLocationName},
+		{"unknown location", 110, model.UnknownLocationName},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, service.Name(test.code))
+		})
+	}
+
+	require.NotNil(t, service.TimeZone(102))
+	assert.Equal(t, timeZone, service.TimeZone(102).String())
+	assert.Nil(t, service.TimeZone(104))
+}
+
+func TestLocationService_UnknownCodesUntilCreated(t *testing.T) {
+	mockRepo := new(MockLocationRepository)
+	mockRepo.On("GetAll").Return([]*model.Location{}, nil).Once()
+
+	service := NewLocationServiceImpl(mockRepo, &MockAuditService{})
+	require.Nil(t, service.Load(context.Background()))
+
+	service.CheckCode(110)
+	service.CheckCode(109)
+	service.CheckCode(110)
+	assert.Equal(t, []int8{109, 110}, service.UnknownCodes())
+
+	// Adding the location to the catalogue forgets the code
+	locationDTO := &request.LocationDTO{Code: 110, Name: "Antofagasta"}
+	mockRepo.On("Create", locationDTO).Return(nil)
+	mockRepo.On("GetAll").Return([]*model.Location{{Code: 110, Name: "Antofagasta", Active: true}}, nil)
+
+	require.Nil(t, service.Create(context.Background(), locationDTO))
+	assert.Equal(t, []int8{109}, service.UnknownCodes())
+	assert.Equal(t, "Antofagasta", service.Name(110))
+}
+
+func TestLocationService_Create(t *testing.T) {
+	invalidZone := "Mars/Olympus"
+	conflict := apperrors.NewAppErrorWithType("LocationRepository", apperrors.TypeConflict, errors.New("location 102 already exists"))
+
+	tests := []struct {
+		name        string
+		locationDTO *request.LocationDTO
+		createErr   *apperrors.AppError
+		errType     string
+		audited     bool
+	}{
+		{"new location", &request.LocationDTO{Code: 110, Name: "Antofagasta"}, nil, "", true},
+		{"duplicate code", &request.LocationDTO{Code: 102, Name: "Espacio Urbano"}, conflict, apperrors.TypeConflict, false},
+		{"invalid time zone", &request.LocationDTO{Code: 110, Name: "Antofagasta", TimeZone: &invalidZone}, nil, apperrors.TypeValidation, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mockRepo := new(MockLocationRepository)
+			mockRepo.On("Create", test.locationDTO).Return(test.createErr)
+			mockRepo.On("GetAll").Return([]*model.Location{}, nil)
+			auditService := &MockAuditService{}
+
+			err := NewLocationServiceImpl(mockRepo, auditService).Create(context.Background(), test.locationDTO)
+
+			if test.errType == "" {
+				assert.Nil(t, err)
+			} else {
+				require.NotNil(t, err)
+				assert.True(t, err.HasType(test.errType))
+			}
+			if test.audited {
+				assert.Equal(t, []string{model.AuditActionLocationCreate}, auditService.actions)
+			} else {
+				assert.Empty(t, auditService.actions)
+			}
+		})
+	}
+}
+
+func TestLocationService_UpdateAndDeleteUnknownLocation(t *testing.T) {
+	mockRepo := new(MockLocationRepository)
+	mockRepo.On("Update", mock.Anything).Return(false, nil)
+	mockRepo.On("Delete", int8(110)).Return(false, nil)
+	auditService := &MockAuditService{}
+
+	service := NewLocationServiceImpl(mockRepo, auditService)
+
+	err := service.Update(context.Background(), &request.LocationDTO{Code: 110, Name: "Antofagasta"})
+	require.NotNil(t, err)
+	assert.True(t, err.HasType(apperrors.TypeNotFound))
+
+	err = service.Delete(context.Background(), 110)
+	require.NotNil(t, err)
+	assert.True(t, err.HasType(apperrors.TypeNotFound))
+
+	assert.Empty(t, auditService.actions)
+	mockRepo.AssertNotCalled(t, "GetAll")
+}
+
+func TestLocationService_DeleteReloadsCatalogue(t *testing.T) {
+	mockRepo := new(MockLocationRepository)
+	mockRepo.On("GetAll").Return([]*model.Location{{Code: 102, Name: "Espacio Urbano", Active: true}}, nil).Once()
+	auditService := &MockAuditService{}
+
+	service := NewLocationServiceImpl(mockRepo, auditService)
+	require.Nil(t, service.Load(context.Background()))
+
+	mockRepo.On("Delete", int8(102)).Return(true, nil)
+	mockRepo.On("GetAll").Return([]*model.Location{}, nil)
+
+	require.Nil(t, service.Delete(context.Background(), 102))
+	assert.Equal(t, model.UnknownLocationName, service.Name(102))
+	assert.Equal(t, []string{model.AuditActionLocationDelete}, auditService.actions)
+}
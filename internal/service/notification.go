@@ -8,119 +8,345 @@ import (
 	"log"
 	"net/http"
 	"spl-notification/internal/config"
+	"spl-notification/internal/dto/request"
 	"spl-notification/internal/errors"
 	"spl-notification/internal/model"
+	"spl-notification/internal/queue"
+	"spl-notification/internal/repository"
+	"strconv"
+	"strings"
 	"time"
-
-	"cloud.google.com/go/pubsub"
 )
 
 type notificationServiceImpl struct {
-	enviromentConfig   *config.EnvironmentConfig
-	whatsappClient     *http.Client
-	pubsubClient       *pubsub.Client
-	pubsubTopic        *pubsub.Topic
-	pubsubSubscription *pubsub.Subscription
+	enviromentConfig          *config.EnvironmentConfig
+	whatsappClient            *http.Client
+	notificationQueue         queue.NotificationQueue
+	notificationLogRepository repository.NotificationLogRepository
+	chatPreferencesRepository repository.ChatPreferencesRepository
+	deliveryRepository        repository.NotificationDeliveryRepository
+	deadLetterRepository      repository.DeadLetterRepository
+	auditService              AuditService
+	locationService           LocationService
+	retryPolicy               retryPolicy
+	circuitBreaker            *circuitBreaker
 }
 
 func NewNotificationServiceImpl(
 	enviromentConfig *config.EnvironmentConfig,
+	notificationQueue queue.NotificationQueue,
+	notificationLogRepository repository.NotificationLogRepository,
+	chatPreferencesRepository repository.ChatPreferencesRepository,
+	deliveryRepository repository.NotificationDeliveryRepository,
+	deadLetterRepository repository.DeadLetterRepository,
+	circuitBreakers *CircuitBreakers,
+	auditService AuditService,
+	locationService LocationService,
 ) NotificationService {
-	ctx := context.Background()
-
-	// Inicializar cliente de Pub/Sub
-	pubsubClient, err := pubsub.NewClient(ctx, enviromentConfig.PubSubProjectID)
-	if err != nil {
-		panic(fmt.Sprintf("Error al crear cliente de Pub/Sub: %v", err))
-	}
-
-	// Obtener el topic
-	topic := pubsubClient.Topic(enviromentConfig.PubSubTopicID)
-
-	// Obtener la suscripción
-	subscription := pubsubClient.Subscription(enviromentConfig.PubSubSubscriptionID)
-
 	return &notificationServiceImpl{
 		enviromentConfig: enviromentConfig,
 		whatsappClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
-		pubsubClient:       pubsubClient,
-		pubsubTopic:        topic,
-		pubsubSubscription: subscription,
+		notificationQueue:         notificationQueue,
+		notificationLogRepository: notificationLogRepository,
+		chatPreferencesRepository: chatPreferencesRepository,
+		deliveryRepository:        deliveryRepository,
+		deadLetterRepository:      deadLetterRepository,
+		auditService:              auditService,
+		locationService:           locationService,
+		retryPolicy: retryPolicy{
+			maxAttempts: enviromentConfig.DeliveryMaxAttempts,
+			baseDelay:   enviromentConfig.DeliveryRetryBaseDelay,
+			maxDelay:    enviromentConfig.DeliveryRetryMaxDelay,
+		},
+		circuitBreaker: circuitBreakers.Notification,
 	}
 }
 
-func (n *notificationServiceImpl) SendNotification(requests []*model.NotificationRequest) *errors.AppError {
-	ctx := context.Background()
-
+func (n *notificationServiceImpl) SendNotification(ctx context.Context, requests []*model.NotificationRequest) *errors.AppError {
 	for _, request := range requests {
-		messageData, err := json.Marshal(request)
+		request.EnsureID()
+
+		err := n.notificationLogRepository.Create(ctx, request)
 		if err != nil {
-			return errors.NewAppError("NotificationService",
-				fmt.Errorf("error serializing notification: %w", err))
+			return err
 		}
 
-		msg := &pubsub.Message{
-			Data: messageData,
-			Attributes: map[string]string{
-				"type":     request.Type.String(),
-				"chatId":   request.ChatID,
-				"run":      request.Run,
-				"location": fmt.Sprintf("%d", request.Location),
-			},
+		err = n.notificationQueue.Publish(ctx, request)
+		if err != nil {
+			return err
 		}
+	}
 
-		result := n.pubsubTopic.Publish(ctx, msg)
+	return nil
+}
 
-		// Esperar a que se complete la publicación
-		_, err = result.Get(ctx)
-		if err != nil {
-			return errors.NewAppError("NotificationService",
-				fmt.Errorf("error publishing message to Pub/Sub: %w", err))
+func (n *notificationServiceImpl) HandleNotification(ctx context.Context) {
+	log.Printf("[NotificationService] Starting %s notification consumer...\n", n.enviromentConfig.NotificationQueue)
+
+	err := n.notificationQueue.Consume(ctx, n.handleNotification)
+	if err != nil {
+		log.Printf("%v\n", err)
+	}
+}
+
+func (n *notificationServiceImpl) handleNotification(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
+	// Messages published before the IDs were introduced don't carry one
+	id := request.EnsureID()
+	log.Printf("[NotificationService] Message received: ID=%s, Type=%s, ChatID=%s, Run=%s, Location=%d\n",
+		id,
+		request.Type.String(),
+		request.ChatID,
+		request.Run,
+		request.Location,
+	)
+
+	// The queue may redeliver a message whose webhook call succeeded but was
+	// not acknowledged in time
+	delivered, err := n.deliveryRepository.IsDelivered(ctx, id, time.Now().Add(-n.enviromentConfig.DeliveryDedupeRetention))
+	if err != nil {
+		return err
+	}
+	if delivered {
+		log.Printf("[NotificationService] Notification %s already delivered, skipping\n", id)
+		return nil
+	}
+
+	preferences, err := n.getChatPreferences(ctx, request.ChatID)
+	if err != nil {
+		return err
+	}
+
+	if status := n.suppressedStatus(preferences, request, time.Now()); status != nil {
+		log.Printf("[NotificationService] Notification %s for %s not delivered: %s\n", request.Type.String(), request.ChatID, *status)
+		if logErr := n.notificationLogRepository.SetStatus(ctx, request, *status); logErr != nil {
+			log.Printf("%v\n", logErr)
 		}
+		return nil
+	}
+
+	attempts, err := n.retryPolicy.do(ctx, func() *errors.AppError {
+		return n.notifyTemplate(ctx, request)
+	})
+	if err != nil && isCircuitOpen(err) {
+		// The gateway is down, the queue redelivers it after its retry delay
+		return err
+	}
+	if err != nil {
+		lastError := err.Error()
+		log.Printf("[NotificationService] Notification %s dead-lettered after %d attempts: %s\n", id, attempts, lastError)
+
+		deadLetter := &model.DeadLetter{
+			Request:    request,
+			Attempts:   attempts,
+			LastError:  lastError,
+			StatusCode: statusCode(err),
+		}
+		if deadLetterErr := n.deadLetterRepository.Create(ctx, deadLetter); deadLetterErr != nil {
+			// Not acknowledged, the queue redelivers it instead
+			return deadLetterErr
+		}
+
+		if logErr := n.notificationLogRepository.UpdateStatus(ctx, request, model.NotificationStatusFailed, attempts, &lastError); logErr != nil {
+			log.Printf("%v\n", logErr)
+		}
+		return nil
+	}
+
+	// Failing to record the delivery must not trigger a redelivery
+	if markErr := n.deliveryRepository.MarkDelivered(ctx, id, time.Now()); markErr != nil {
+		log.Printf("%v\n", markErr)
+	}
+
+	if logErr := n.notificationLogRepository.UpdateStatus(ctx, request, model.NotificationStatusDelivered, attempts, nil); logErr != nil {
+		log.Printf("%v\n", logErr)
 	}
 
 	return nil
 }
 
-func (n *notificationServiceImpl) HandleNotification() {
-	ctx := context.Background()
+func (n *notificationServiceImpl) GetDeadLetters(ctx context.Context, includeReplayed bool, limit int) ([]*model.DeadLetter, *errors.AppError) {
+	return n.deadLetterRepository.GetAll(ctx, includeReplayed, limit)
+}
+
+// ReplayDeadLetter publishes a dead-lettered notification again, it goes
+// through the whole retry policy and is dead-lettered anew if it fails. The
+// dead letter is claimed before publishing, so concurrent replays publish it
+// once.
+func (n *notificationServiceImpl) ReplayDeadLetter(ctx context.Context, id int64) *errors.AppError {
+	deadLetter, err := n.deadLetterRepository.GetById(ctx, id)
+	if err != nil {
+		return err
+	}
 
-	log.Println("[NotificationService] Starting Pub/Sub notification consumer...")
+	if deadLetter == nil {
+		return errors.NewAppErrorWithType("NotificationService", errors.TypeNotFound,
+			fmt.Errorf("dead letter %d not found", id))
+	}
 
-	err := n.pubsubSubscription.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
-		var notificationRequest model.NotificationRequest
-		if err := json.Unmarshal(msg.Data, &notificationRequest); err != nil {
-			log.Printf("Error deserializing message: %v\n", err)
-			msg.Nack() // Reject the message to retry
-			return
+	replayedAt := time.Now().UTC().Truncate(time.Second)
+	claimed, err := n.deadLetterRepository.MarkReplayed(ctx, id, replayedAt)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return errors.NewAppErrorWithType("NotificationService", errors.TypeValidation,
+			fmt.Errorf("dead letter %d was already replayed", id))
+	}
+
+	if err := n.notificationQueue.Publish(ctx, deadLetter.Request); err != nil {
+		// Released so the replay can be tried again
+		if unmarkErr := n.deadLetterRepository.UnmarkReplayed(ctx, id, replayedAt); unmarkErr != nil {
+			log.Printf("%v\n", unmarkErr)
 		}
+		return err
+	}
+
+	if logErr := n.notificationLogRepository.SetStatus(ctx, deadLetter.Request, model.NotificationStatusQueued); logErr != nil {
+		log.Printf("%v\n", logErr)
+	}
+
+	replayed := *deadLetter
+	replayed.ReplayedAt = &replayedAt
+	n.auditService.Record(ctx, model.AuditActionDeadLetterReplay, deadLetter.Request.ChatID, deadLetter.Request.Run, deadLetter, &replayed)
+
+	return nil
+}
+
+// PurgeDeliveries forgets the delivered IDs older than the retention window.
+func (n *notificationServiceImpl) PurgeDeliveries(ctx context.Context) *errors.AppError {
+	return n.deliveryRepository.DeleteBefore(ctx, time.Now().Add(-n.enviromentConfig.DeliveryDedupeRetention))
+}
+
+// suppressedStatus returns the status to record when the chat preferences
+// prevent the notification from being delivered at now, or nil otherwise.
+func (n *notificationServiceImpl) suppressedStatus(
+	preferences *model.ChatPreferences,
+	request *model.NotificationRequest,
+	now time.Time,
+) *model.NotificationStatus {
+	status := model.NotificationStatusSuppressed
+	if !preferences.Allows(request.Type) {
+		return &status
+	}
+
+	if !preferences.InQuietHours(now, n.chatLocation(preferences)) {
+		return nil
+	}
+
+	if preferences.QuietMode == model.QuietModeBatch {
+		status = model.NotificationStatusBatched
+	}
+	return &status
+}
+
+// SendQuietHoursSummaries sends a single message per chat with the
+// notifications held during quiet hours, once those hours are over.
+func (n *notificationServiceImpl) SendQuietHoursSummaries(ctx context.Context) *errors.AppError {
+	batched, err := n.notificationLogRepository.GetByStatus(ctx, model.NotificationStatusBatched)
+	if err != nil {
+		return err
+	}
 
-		log.Printf("[NotificationService] Message received: Type=%s, ChatID=%s, Run=%s, Location=%d\n",
-			notificationRequest.Type.String(),
-			notificationRequest.ChatID,
-			notificationRequest.Run,
-			notificationRequest.Location,
-		)
+	batchedByChat := make(map[string][]*model.NotificationLog)
+	for _, notificationLog := range batched {
+		batchedByChat[notificationLog.ChatID] = append(batchedByChat[notificationLog.ChatID], notificationLog)
+	}
 
-		err := n.notifyTemplate(&notificationRequest)
+	for chatId, logs := range batchedByChat {
+		preferences, err := n.getChatPreferences(ctx, chatId)
 		if err != nil {
+			return err
+		}
+
+		location := n.chatLocation(preferences)
+		if preferences.InQuietHours(time.Now(), location) {
+			continue
+		}
+
+		ids := make([]int64, 0, len(logs))
+		for _, notificationLog := range logs {
+			ids = append(ids, notificationLog.ID)
+		}
+
+		if err := n.SendMessage(ctx, chatId, n.quietHoursSummary(logs, location)); err != nil {
 			log.Printf("%v\n", err)
-			msg.Nack()
-			return
+			continue
 		}
 
-		// Message sended with success
-		msg.Ack()
-	})
+		if err := n.notificationLogRepository.UpdateStatusByIds(ctx, ids, model.NotificationStatusDelivered); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quietHoursSummary lists the held notifications, one line each with the
+// time in the chat location and the alias the chat gave the person.
+func (n *notificationServiceImpl) quietHoursSummary(logs []*model.NotificationLog, location *time.Location) string {
+	var message strings.Builder
+	message.WriteString("🌙 Resumen de notificaciones:\n")
+	for _, notificationLog := range logs {
+		action := "entró a"
+		switch notificationLog.Type {
+		case model.NotificationTypeExit:
+			action = "salió de"
+		case model.NotificationTypeLongStay:
+			action = "sigue en"
+		case model.NotificationTypeInactive:
+			action = "no vuelve desde su visita a"
+		}
+		name := notificationLog.FullName
+		if notificationLog.Alias != nil {
+			name = *notificationLog.Alias
+		}
+		message.WriteString(fmt.Sprintf("- %s %s %s %s\n",
+			notificationLog.Date.In(location).Format("15:04"),
+			name,
+			action,
+			n.locationService.Name(notificationLog.Location),
+		))
+	}
+	return message.String()
+}
 
+func (n *notificationServiceImpl) getChatPreferences(ctx context.Context, chatId string) (*model.ChatPreferences, *errors.AppError) {
+	preferences, err := n.chatPreferencesRepository.GetByChatId(ctx, chatId)
 	if err != nil {
-		log.Printf("Error in Pub/Sub subscription: %v\n", err)
+		return nil, err
+	}
+
+	if preferences == nil {
+		return model.DefaultChatPreferences(chatId), nil
+	}
+	return preferences, nil
+}
+
+// chatLocation returns the chat time zone, falling back to the configured Zone.
+func (n *notificationServiceImpl) chatLocation(preferences *model.ChatPreferences) *time.Location {
+	if preferences.TimeZone != nil {
+		if location, err := config.ParseZone(*preferences.TimeZone); err == nil {
+			return location
+		}
 	}
+	return n.enviromentConfig.Location()
 }
 
-func (n *notificationServiceImpl) notifyTemplate(request *model.NotificationRequest) *errors.AppError {
+func (n *notificationServiceImpl) GetNotificationHistory(ctx context.Context, filter *request.NotificationHistoryDTO) ([]*model.NotificationLog, *errors.AppError) {
+	logs, err := n.notificationLogRepository.GetByChatId(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, notificationLog := range logs {
+		notificationLog.LocationName = n.locationService.Name(notificationLog.Location)
+	}
+	return logs, nil
+}
+
+func (n *notificationServiceImpl) notifyTemplate(ctx context.Context, request *model.NotificationRequest) *errors.AppError {
 	fullName := request.FullName
 	if request.Alias != nil {
 		fullName = *request.Alias
@@ -129,40 +355,37 @@ func (n *notificationServiceImpl) notifyTemplate(request *model.NotificationRequ
 	body := map[string]string{
 		"chatId":   request.ChatID,
 		"fullName": fullName,
-		"location": request.LocationName(),
-	}
-
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return n.error(err)
+		"location": n.locationService.Name(request.Location),
 	}
 
 	finalPath := "notify-entry"
-	if request.Type == model.NotificationTypeExit {
+	switch request.Type {
+	case model.NotificationTypeExit:
 		finalPath = "notify-exit"
+	case model.NotificationTypeLongStay:
+		finalPath = "notify-long-stay"
+		body["hours"] = strconv.Itoa(request.Hours)
+	case model.NotificationTypeInactive:
+		finalPath = "notify-inactive"
+		body["days"] = strconv.Itoa(request.Days)
 	}
 
-	req, err := http.NewRequest("POST", n.enviromentConfig.NotificationBaseUrl+"webhook/whatsapp/"+finalPath, bytes.NewBuffer(jsonBody))
+	jsonBody, err := json.Marshal(body)
 	if err != nil {
 		return n.error(err)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.SetBasicAuth(n.enviromentConfig.NotificationUsername, n.enviromentConfig.NotificationPassword)
 
-	resp, err := n.whatsappClient.Do(req)
+	req, err := http.NewRequestWithContext(ctx, "POST", n.enviromentConfig.NotificationBaseUrl+"webhook/whatsapp/"+finalPath, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return n.error(err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return n.error(fmt.Errorf("error sending notification: %s", resp.Status))
-	}
+	// Lets the webhook drop a retry of a message it already sent
+	req.Header.Set("Idempotency-Key", request.EnsureID())
 
-	return nil
+	return n.postWebhook(req, "error sending notification")
 }
 
-func (n *notificationServiceImpl) SendMessage(chatID string, message string) *errors.AppError {
+func (n *notificationServiceImpl) SendMessage(ctx context.Context, chatID string, message string) *errors.AppError {
 	body := map[string]string{
 		"chatId":  chatID,
 		"message": message,
@@ -173,27 +396,36 @@ func (n *notificationServiceImpl) SendMessage(chatID string, message string) *er
 		return n.error(err)
 	}
 
-	req, err := http.NewRequest("POST", n.enviromentConfig.NotificationBaseUrl+"webhook/whatsapp", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", n.enviromentConfig.NotificationBaseUrl+"webhook/whatsapp", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return n.error(err)
 	}
+
+	return n.postWebhook(req, "error sending WhatsApp message")
+}
+
+// postWebhook sends the request to the WhatsApp gateway through its circuit
+// breaker, so calls fail fast while the gateway is down.
+func (n *notificationServiceImpl) postWebhook(req *http.Request, operation string) *errors.AppError {
 	req.Header.Set("Content-Type", "application/json")
 	req.SetBasicAuth(n.enviromentConfig.NotificationUsername, n.enviromentConfig.NotificationPassword)
 
-	resp, err := n.whatsappClient.Do(req)
-	if err != nil {
-		return n.error(err)
-	}
-	defer resp.Body.Close()
+	return n.circuitBreaker.execute(func() *errors.AppError {
+		resp, err := n.whatsappClient.Do(req)
+		if err != nil {
+			return n.error(err)
+		}
+		defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return n.error(fmt.Errorf("error sending WhatsApp message: %s", resp.Status))
-	}
+		if resp.StatusCode != http.StatusOK {
+			return n.error(&httpStatusError{operation: operation, statusCode: resp.StatusCode, status: resp.Status})
+		}
 
-	return nil
+		return nil
+	})
 }
 
-func (n *notificationServiceImpl) SendTracks(chatId string, tracks []*model.Track) *errors.AppError {
+func (n *notificationServiceImpl) SendTracks(ctx context.Context, chatId string, tracks []*model.Track) *errors.AppError {
 	message := "No tienes seguimientos."
 	if len(tracks) > 0 {
 		var trackList string
@@ -207,7 +439,7 @@ func (n *notificationServiceImpl) SendTracks(chatId string, tracks []*model.Trac
 		message = fmt.Sprintf("📋 Listado:\n%s", trackList)
 	}
 
-	err := n.SendMessage(chatId, message)
+	err := n.SendMessage(ctx, chatId, message)
 	if err != nil {
 		return n.error(err)
 	}
@@ -216,16 +448,7 @@ func (n *notificationServiceImpl) SendTracks(chatId string, tracks []*model.Trac
 }
 
 func (n *notificationServiceImpl) Close() error {
-	// Detener el topic para que no acepte más publicaciones
-	n.pubsubTopic.Stop()
-
-	// Cerrar el cliente de Pub/Sub
-	err := n.pubsubClient.Close()
-	if err != nil {
-		return fmt.Errorf("error al cerrar cliente de Pub/Sub: %w", err)
-	}
-
-	return nil
+	return n.notificationQueue.Close()
 }
 
 func (n *notificationServiceImpl) error(err error) *errors.AppError {
@@ -0,0 +1,601 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"spl-notification/internal/config"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/queue"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockNotificationLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationLogRepository) Create(ctx context.Context, notification *model.NotificationRequest) *apperrors.AppError {
+	return nil
+}
+
+func (m *MockNotificationLogRepository) UpdateStatus(ctx context.Context, notification *model.NotificationRequest, status model.NotificationStatus, attempts int, lastError *string) *apperrors.AppError {
+	m.Called(status, attempts)
+	return nil
+}
+
+func (m *MockNotificationLogRepository) SetStatus(ctx context.Context, notification *model.NotificationRequest, status model.NotificationStatus) *apperrors.AppError {
+	m.Called(status)
+	return nil
+}
+
+func (m *MockNotificationLogRepository) UpdateStatusByIds(ctx context.Context, ids []int64, status model.NotificationStatus) *apperrors.AppError {
+	return nil
+}
+
+func (m *MockNotificationLogRepository) GetByChatId(ctx context.Context, filter *request.NotificationHistoryDTO) ([]*model.NotificationLog, *apperrors.AppError) {
+	args := m.Called(filter)
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.NotificationLog), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockNotificationLogRepository) GetByStatus(ctx context.Context, status model.NotificationStatus) ([]*model.NotificationLog, *apperrors.AppError) {
+	return nil, nil
+}
+
+type MockChatPreferencesRepository struct {
+	mock.Mock
+}
+
+func (m *MockChatPreferencesRepository) GetByChatId(ctx context.Context, chatId string) (*model.ChatPreferences, *apperrors.AppError) {
+	return nil, nil
+}
+
+func (m *MockChatPreferencesRepository) Upsert(ctx context.Context, preferences *model.ChatPreferences) *apperrors.AppError {
+	return nil
+}
+
+// MockNotificationDeliveryRepository keeps the delivered IDs in memory.
+type MockNotificationDeliveryRepository struct {
+	delivered map[string]time.Time
+}
+
+func (m *MockNotificationDeliveryRepository) IsDelivered(ctx context.Context, id string, since time.Time) (bool, *apperrors.AppError) {
+	deliveredAt, ok := m.delivered[id]
+	return ok && !deliveredAt.Before(since), nil
+}
+
+func (m *MockNotificationDeliveryRepository) MarkDelivered(ctx context.Context, id string, deliveredAt time.Time) *apperrors.AppError {
+	m.delivered[id] = deliveredAt
+	return nil
+}
+
+func (m *MockNotificationDeliveryRepository) DeleteBefore(ctx context.Context, before time.Time) *apperrors.AppError {
+	for id, deliveredAt := range m.delivered {
+		if deliveredAt.Before(before) {
+			delete(m.delivered, id)
+		}
+	}
+	return nil
+}
+
+// MockDeadLetterRepository keeps the dead letters in memory.
+type MockDeadLetterRepository struct {
+	deadLetters []*model.DeadLetter
+}
+
+func (m *MockDeadLetterRepository) Create(ctx context.Context, deadLetter *model.DeadLetter) *apperrors.AppError {
+	deadLetter.ID = int64(len(m.deadLetters) + 1)
+	m.deadLetters = append(m.deadLetters, deadLetter)
+	return nil
+}
+
+func (m *MockDeadLetterRepository) GetAll(ctx context.Context, includeReplayed bool, limit int) ([]*model.DeadLetter, *apperrors.AppError) {
+	return m.deadLetters, nil
+}
+
+func (m *MockDeadLetterRepository) GetById(ctx context.Context, id int64) (*model.DeadLetter, *apperrors.AppError) {
+	for _, deadLetter := range m.deadLetters {
+		if deadLetter.ID == id {
+			return deadLetter, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockDeadLetterRepository) MarkReplayed(ctx context.Context, id int64, replayedAt time.Time) (bool, *apperrors.AppError) {
+	if m.deadLetters[id-1].ReplayedAt != nil {
+		return false, nil
+	}
+	m.deadLetters[id-1].ReplayedAt = &replayedAt
+	return true, nil
+}
+
+func (m *MockDeadLetterRepository) UnmarkReplayed(ctx context.Context, id int64, replayedAt time.Time) *apperrors.AppError {
+	m.deadLetters[id-1].ReplayedAt = nil
+	return nil
+}
+
+func TestHandleNotification_SkipsRedeliveredMessage(t *testing.T) {
+	var keys []string
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhook.Close()
+
+	logRepo := new(MockNotificationLogRepository)
+	logRepo.On("UpdateStatus", mock.Anything, mock.Anything).Return()
+
+	envConfig := &config.EnvironmentConfig{
+		NotificationBaseUrl:     webhook.URL + "/",
+		DeliveryDedupeRetention: time.Hour,
+	}
+	service := NewNotificationServiceImpl(
+		envConfig,
+		nil,
+		logRepo,
+		new(MockChatPreferencesRepository),
+		&MockNotificationDeliveryRepository{delivered: make(map[string]time.Time)},
+		new(MockDeadLetterRepository),
+		NewCircuitBreakers(envConfig),
+		&MockAuditService{},
+		newMockLocationService(),
+	).(*notificationServiceImpl)
+
+	date := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	message := func() *model.NotificationRequest {
+		return &model.NotificationRequest{
+			Type:     model.NotificationTypeEntry,
+			Date:     date,
+			ChatID:   "chat123",
+			Run:      "12345678-9",
+			FullName: "John Doe",
+			Location: 102,
+		}
+	}
+
+	// The same event received twice is only sent to the webhook once
+	assert.Nil(t, service.handleNotification(context.Background(), message()))
+	assert.Nil(t, service.handleNotification(context.Background(), message()))
+
+	assert.Equal(t, []string{message().EnsureID()}, keys)
+	logRepo.AssertNumberOfCalls(t, "UpdateStatus", 1)
+
+	// Another event of the same person is a different notification
+	next := message()
+	next.Date = date.Add(time.Hour)
+	assert.Nil(t, service.handleNotification(context.Background(), next))
+	assert.Len(t, keys, 2)
+	assert.NotEqual(t, keys[0], keys[1])
+}
+
+func TestNotifyTemplate_SendsAlertDuration(t *testing.T) {
+	bodies := make(map[string]map[string]string)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies[r.URL.Path] = body
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhook.Close()
+
+	envConfig := &config.EnvironmentConfig{NotificationBaseUrl: webhook.URL + "/"}
+	service := NewNotificationServiceImpl(
+		envConfig,
+		nil,
+		new(MockNotificationLogRepository),
+		new(MockChatPreferencesRepository),
+		&MockNotificationDeliveryRepository{delivered: make(map[string]time.Time)},
+		new(MockDeadLetterRepository),
+		NewCircuitBreakers(envConfig),
+		&MockAuditService{},
+		newMockLocationService(),
+	).(*notificationServiceImpl)
+
+	longStay := &model.NotificationRequest{
+		Type:     model.NotificationTypeLongStay,
+		Date:     time.Now().Add(-5*time.Hour - time.Minute),
+		ChatID:   "chat123",
+		FullName: "John Doe",
+		Location: 102,
+		Hours:    4,
+	}
+	inactive := &model.NotificationRequest{
+		Type:     model.NotificationTypeInactive,
+		Date:     time.Now().Add(-3*24*time.Hour - time.Hour),
+		ChatID:   "chat123",
+		FullName: "John Doe",
+		Days:     2,
+	}
+	assert.Nil(t, service.notifyTemplate(context.Background(), longStay))
+	assert.Nil(t, service.notifyTemplate(context.Background(), inactive))
+
+	// The counts taken when the alert fired are sent as is, whenever the
+	// message is delivered or replayed
+	assert.Equal(t, "4", bodies["/webhook/whatsapp/notify-long-stay"]["hours"])
+	assert.Equal(t, "John Doe", bodies["/webhook/whatsapp/notify-long-stay"]["fullName"])
+	assert.Equal(t, "2", bodies["/webhook/whatsapp/notify-inactive"]["days"])
+}
+
+func TestHandleNotification_RetriesAndDeadLetters(t *testing.T) {
+	// Status codes answered by the webhook, in order
+	var responses []int
+	hits := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(responses[hits])
+		hits++
+	}))
+	defer webhook.Close()
+
+	logRepo := new(MockNotificationLogRepository)
+	logRepo.On("UpdateStatus", mock.Anything, mock.Anything).Return()
+	logRepo.On("SetStatus", mock.Anything).Return()
+	deadLetterRepo := new(MockDeadLetterRepository)
+	notificationQueue := queue.NewMemoryQueueImpl()
+
+	envConfig := &config.EnvironmentConfig{
+		NotificationBaseUrl:     webhook.URL + "/",
+		DeliveryDedupeRetention: time.Hour,
+		DeliveryMaxAttempts:     3,
+		DeliveryRetryBaseDelay:  time.Millisecond,
+		DeliveryRetryMaxDelay:   2 * time.Millisecond,
+	}
+	service := NewNotificationServiceImpl(
+		envConfig,
+		notificationQueue,
+		logRepo,
+		new(MockChatPreferencesRepository),
+		&MockNotificationDeliveryRepository{delivered: make(map[string]time.Time)},
+		deadLetterRepo,
+		NewCircuitBreakers(envConfig),
+		&MockAuditService{},
+		newMockLocationService(),
+	).(*notificationServiceImpl)
+
+	date := time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC)
+	message := func(minutes int) *model.NotificationRequest {
+		return &model.NotificationRequest{
+			Type:     model.NotificationTypeEntry,
+			Date:     date.Add(time.Duration(minutes) * time.Minute),
+			ChatID:   "chat123",
+			Run:      "12345678-9",
+			FullName: "John Doe",
+			Location: 102,
+		}
+	}
+
+	// Server errors are retried until the webhook accepts the message
+	responses, hits = []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, 0
+	assert.Nil(t, service.handleNotification(context.Background(), message(0)))
+	assert.Equal(t, 3, hits)
+	assert.Empty(t, deadLetterRepo.deadLetters)
+
+	// A rejected chat is dead-lettered on the first attempt and acknowledged
+	responses, hits = []int{http.StatusNotFound}, 0
+	assert.Nil(t, service.handleNotification(context.Background(), message(1)))
+	assert.Equal(t, 1, hits)
+	assert.Len(t, deadLetterRepo.deadLetters, 1)
+	assert.Equal(t, 1, deadLetterRepo.deadLetters[0].Attempts)
+	assert.Equal(t, http.StatusNotFound, *deadLetterRepo.deadLetters[0].StatusCode)
+
+	// Retryable errors are dead-lettered once the attempts are exhausted
+	responses, hits = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusBadGateway}, 0
+	assert.Nil(t, service.handleNotification(context.Background(), message(2)))
+	assert.Equal(t, 3, hits)
+	assert.Len(t, deadLetterRepo.deadLetters, 2)
+	assert.Equal(t, 3, deadLetterRepo.deadLetters[1].Attempts)
+
+	// A replay publishes the notification again, only once
+	assert.Nil(t, service.ReplayDeadLetter(context.Background(), 2))
+	assert.NotNil(t, deadLetterRepo.deadLetters[1].ReplayedAt)
+	err := service.ReplayDeadLetter(context.Background(), 2)
+	assert.True(t, err.HasType(apperrors.TypeValidation))
+	err = service.ReplayDeadLetter(context.Background(), 5)
+	assert.True(t, err.HasType(apperrors.TypeNotFound))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var replayed *model.NotificationRequest
+	notificationQueue.Consume(ctx, func(ctx context.Context, request *model.NotificationRequest) *apperrors.AppError {
+		replayed = request
+		cancel()
+		return nil
+	})
+	assert.Equal(t, message(2).EnsureID(), replayed.ID)
+
+	// The history counts every webhook call
+	logRepo.AssertCalled(t, "UpdateStatus", model.NotificationStatusDelivered, 3)
+	logRepo.AssertCalled(t, "UpdateStatus", model.NotificationStatusFailed, 1)
+	logRepo.AssertCalled(t, "UpdateStatus", model.NotificationStatusFailed, 3)
+}
+
+// failingQueue rejects every published message.
+type failingQueue struct {
+	queue.NotificationQueue
+}
+
+func (q failingQueue) Publish(ctx context.Context, request *model.NotificationRequest) *apperrors.AppError {
+	return apperrors.NewAppError("TestQueue", errors.New("queue unavailable"))
+}
+
+func TestReplayDeadLetter_ReleasesClaimWhenPublishFails(t *testing.T) {
+	deadLetterRepo := &MockDeadLetterRepository{}
+	require.Nil(t, deadLetterRepo.Create(context.Background(), &model.DeadLetter{
+		Request: &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat123", Run: "12345678-9", Date: time.Now()},
+	}))
+	auditService := &MockAuditService{}
+
+	envConfig := &config.EnvironmentConfig{}
+	service := NewNotificationServiceImpl(
+		envConfig,
+		failingQueue{},
+		new(MockNotificationLogRepository),
+		new(MockChatPreferencesRepository),
+		&MockNotificationDeliveryRepository{delivered: make(map[string]time.Time)},
+		deadLetterRepo,
+		NewCircuitBreakers(envConfig),
+		auditService,
+		newMockLocationService(),
+	)
+
+	assert.NotNil(t, service.ReplayDeadLetter(context.Background(), 1))
+
+	// The dead letter can be replayed again
+	assert.Nil(t, deadLetterRepo.deadLetters[0].ReplayedAt)
+	assert.Empty(t, auditService.actions)
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 10, baseDelay: time.Second, maxDelay: 5 * time.Second}
+
+	assert.Equal(t, time.Second, policy.delay(1))
+	assert.Equal(t, 2*time.Second, policy.delay(2))
+	assert.Equal(t, 4*time.Second, policy.delay(3))
+	assert.Equal(t, 5*time.Second, policy.delay(4))
+	assert.Equal(t, 5*time.Second, policy.delay(9))
+}
+
+func TestCircuitBreaker_OpensAndProbes(t *testing.T) {
+	breaker := newCircuitBreaker("notification", 2, 20*time.Millisecond)
+	failure := func() *apperrors.AppError {
+		return apperrors.NewAppError("Test", &httpStatusError{statusCode: http.StatusBadGateway, status: "502 Bad Gateway"})
+	}
+	rejected := func() *apperrors.AppError {
+		return apperrors.NewAppError("Test", &httpStatusError{statusCode: http.StatusNotFound, status: "404 Not Found"})
+	}
+	calls := 0
+	success := func() *apperrors.AppError {
+		calls++
+		return nil
+	}
+
+	// A rejection means the dependency is up and resets the failures
+	breaker.execute(failure)
+	breaker.execute(rejected)
+	assert.Equal(t, 0, breaker.stats().ConsecutiveFailures)
+
+	// Consecutive failures open it, calls fail fast without being made
+	breaker.execute(failure)
+	breaker.execute(failure)
+	assert.Equal(t, model.CircuitStateOpen, breaker.stats().State)
+	err := breaker.execute(success)
+	assert.True(t, isCircuitOpen(err))
+	assert.Equal(t, 0, calls)
+
+	// A failed probe opens it again
+	time.Sleep(25 * time.Millisecond)
+	breaker.execute(failure)
+	assert.Equal(t, model.CircuitStateOpen, breaker.stats().State)
+
+	// A successful probe closes it
+	time.Sleep(25 * time.Millisecond)
+	assert.Nil(t, breaker.execute(success))
+	assert.Equal(t, model.CircuitStateClosed, breaker.stats().State)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCircuitBreaker_IgnoresCanceledCalls(t *testing.T) {
+	breaker := newCircuitBreaker("notification", 1, 20*time.Millisecond)
+	canceled := func() *apperrors.AppError {
+		return apperrors.NewAppError("Test", &url.Error{Op: "Post", URL: "http://gateway", Err: context.Canceled})
+	}
+
+	// Calls abandoned on shutdown don't trip the breaker
+	breaker.execute(canceled)
+	breaker.execute(canceled)
+	assert.Equal(t, model.CircuitStateClosed, breaker.stats().State)
+	assert.Equal(t, 0, breaker.stats().ConsecutiveFailures)
+
+	// A canceled probe leaves it open for the next call to probe again
+	breaker.execute(func() *apperrors.AppError {
+		return apperrors.NewAppError("Test", &httpStatusError{statusCode: http.StatusBadGateway, status: "502 Bad Gateway"})
+	})
+	time.Sleep(25 * time.Millisecond)
+	breaker.execute(canceled)
+	assert.Equal(t, model.CircuitStateOpen, breaker.stats().State)
+	assert.Nil(t, breaker.execute(func() *apperrors.AppError { return nil }))
+	assert.Equal(t, model.CircuitStateClosed, breaker.stats().State)
+}
+
+func TestHandleNotification_OpenCircuitLeavesMessageQueued(t *testing.T) {
+	hits := 0
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer webhook.Close()
+
+	deadLetterRepo := new(MockDeadLetterRepository)
+	envConfig := &config.EnvironmentConfig{
+		NotificationBaseUrl:            webhook.URL + "/",
+		DeliveryDedupeRetention:        time.Hour,
+		DeliveryMaxAttempts:            5,
+		DeliveryRetryBaseDelay:         time.Millisecond,
+		DeliveryRetryMaxDelay:          time.Millisecond,
+		CircuitBreakerFailureThreshold: 2,
+		CircuitBreakerOpenTimeout:      time.Minute,
+	}
+	service := NewNotificationServiceImpl(
+		envConfig,
+		nil,
+		new(MockNotificationLogRepository),
+		new(MockChatPreferencesRepository),
+		&MockNotificationDeliveryRepository{delivered: make(map[string]time.Time)},
+		deadLetterRepo,
+		NewCircuitBreakers(envConfig),
+		&MockAuditService{},
+		newMockLocationService(),
+	).(*notificationServiceImpl)
+
+	request := &model.NotificationRequest{
+		Type:   model.NotificationTypeEntry,
+		Date:   time.Date(2025, 10, 5, 8, 0, 0, 0, time.UTC),
+		ChatID: "chat123",
+		Run:    "12345678-9",
+	}
+
+	// The retries stop once the breaker opens and the message is left to the
+	// queue instead of being dead-lettered
+	err := service.handleNotification(context.Background(), request)
+	assert.True(t, isCircuitOpen(err))
+	assert.Equal(t, 2, hits)
+	assert.Empty(t, deadLetterRepo.deadLetters)
+
+	// Redeliveries fail fast without calling the gateway
+	err = service.handleNotification(context.Background(), request)
+	assert.True(t, isCircuitOpen(err))
+	assert.Equal(t, 2, hits)
+}
+
+func newPreferencesTestService(locationService LocationService) *notificationServiceImpl {
+	envConfig := &config.EnvironmentConfig{Zone: "UTC"}
+	return NewNotificationServiceImpl(
+		envConfig,
+		nil,
+		new(MockNotificationLogRepository),
+		new(MockChatPreferencesRepository),
+		&MockNotificationDeliveryRepository{delivered: make(map[string]time.Time)},
+		new(MockDeadLetterRepository),
+		NewCircuitBreakers(envConfig),
+		&MockAuditService{},
+		locationService,
+	).(*notificationServiceImpl)
+}
+
+func TestSuppressedStatus(t *testing.T) {
+	start, end := "22:00", "07:00"
+	timeZone := "America/Santiago"
+	preferences := func(notifyMode string, quietMode string, zone *string) *model.ChatPreferences {
+		return &model.ChatPreferences{
+			ChatID:          "chat1",
+			NotifyMode:      notifyMode,
+			QuietMode:       quietMode,
+			QuietHoursStart: &start,
+			QuietHoursEnd:   &end,
+			TimeZone:        zone,
+		}
+	}
+	night := time.Date(2025, 10, 5, 23, 30, 0, 0, time.UTC)
+	day := time.Date(2025, 10, 5, 12, 0, 0, 0, time.UTC)
+	suppressed := model.NotificationStatusSuppressed
+	batched := model.NotificationStatusBatched
+
+	tests := []struct {
+		name             string
+		preferences      *model.ChatPreferences
+		notificationType model.NotificationType
+		now              time.Time
+		expected         *model.NotificationStatus
+	}{
+		{"delivered outside quiet hours", preferences(model.NotifyModeBoth, model.QuietModeDrop, nil), model.NotificationTypeEntry, day, nil},
+		{"dropped in quiet hours", preferences(model.NotifyModeBoth, model.QuietModeDrop, nil), model.NotificationTypeEntry, night, &suppressed},
+		{"batched in quiet hours", preferences(model.NotifyModeBoth, model.QuietModeBatch, nil), model.NotificationTypeExit, night, &batched},
+		{"exit filtered by entry mode", preferences(model.NotifyModeEntry, model.QuietModeBatch, nil), model.NotificationTypeExit, day, &suppressed},
+		{"filtered before batching", preferences(model.NotifyModeEntry, model.QuietModeBatch, nil), model.NotificationTypeExit, night, &suppressed},
+		{"entry filtered by exit mode", preferences(model.NotifyModeExit, model.QuietModeDrop, nil), model.NotificationTypeEntry, day, &suppressed},
+		{"alerts pass the exit mode", preferences(model.NotifyModeExit, model.QuietModeDrop, nil), model.NotificationTypeLongStay, day, nil},
+		// 23:30 UTC is 20:30 in Santiago
+		{"quiet hours in the chat time zone", preferences(model.NotifyModeBoth, model.QuietModeDrop, &timeZone), model.NotificationTypeEntry, night, nil},
+	}
+
+	service := newPreferencesTestService(newMockLocationService())
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := service.suppressedStatus(test.preferences, &model.NotificationRequest{Type: test.notificationType}, test.now)
+			assert.Equal(t, test.expected, status)
+		})
+	}
+}
+
+func TestQuietHoursSummary(t *testing.T) {
+	locationService := new(MockLocationService)
+	locationService.On("Name", int8(102)).Return("Espacio Urbano")
+	locationService.On("Name", int8(110)).Return(model.UnknownLocationName)
+
+	logAt := func(notificationType model.NotificationType, hour int, location int8) *model.NotificationLog {
+		return &model.NotificationLog{
+			Type:     notificationType,
+			Date:     time.Date(2025, 10, 6, hour, 5, 0, 0, time.UTC),
+			FullName: "John Doe",
+			Location: location,
+		}
+	}
+
+	// The alias the chat gave the person replaces the name
+	alias := "Johnny"
+	aliased := logAt(model.NotificationTypeExit, 2, 102)
+	aliased.Alias = &alias
+
+	summary := newPreferencesTestService(locationService).quietHoursSummary([]*model.NotificationLog{
+		logAt(model.NotificationTypeEntry, 1, 102),
+		aliased,
+		logAt(model.NotificationTypeLongStay, 4, 102),
+		logAt(model.NotificationTypeInactive, 5, 110),
+	}, time.FixedZone("GMT-3", -3*60*60))
+
+	assert.Equal(t, "🌙 Resumen de notificaciones:\n"+
+		"- 22:05 John Doe entró a Espacio Urbano\n"+
+		"- 23:05 Johnny salió de Espacio Urbano\n"+
+		"- 01:05 John Doe sigue en Espacio Urbano\n"+
+		"- 02:05 John Doe no vuelve desde su visita a Unknown Location\n", summary)
+}
+
+func TestGetNotificationHistory_NamesLocations(t *testing.T) {
+	logRepo := new(MockNotificationLogRepository)
+	locationService := new(MockLocationService)
+	locationService.On("Name", int8(102)).Return("Espacio Urbano")
+	locationService.On("Name", int8(110)).Return(model.UnknownLocationName)
+
+	entry := model.NotificationType(model.NotificationTypeEntry)
+	filter := &request.NotificationHistoryDTO{ChatID: "chat1", Type: &entry, Limit: 20}
+	logRepo.On("GetByChatId", filter).Return([]*model.NotificationLog{
+		{ID: 2, Type: model.NotificationTypeEntry, Location: 110},
+		{ID: 1, Type: model.NotificationTypeEntry, Location: 102},
+	}, nil)
+
+	service := newPreferencesTestService(locationService)
+	service.notificationLogRepository = logRepo
+
+	logs, err := service.GetNotificationHistory(context.Background(), filter)
+
+	assert.Nil(t, err)
+	assert.Len(t, logs, 2)
+	assert.Equal(t, model.UnknownLocationName, logs[0].LocationName)
+	assert.Equal(t, "Espacio Urbano", logs[1].LocationName)
+
+	logRepo.On("GetByChatId", mock.Anything).Return(nil, apperrors.NewAppError("TestError", errors.New("database unavailable")))
+	_, err = service.GetNotificationHistory(context.Background(), &request.NotificationHistoryDTO{ChatID: "chat2"})
+	assert.NotNil(t, err)
+}
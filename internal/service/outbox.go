@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"log"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
+	"time"
+)
+
+const outboxBatchSize = 50
+
+// outboxRetryPolicy spaces out the relay attempts of a failed row, only its
+// delays are used since the relay itself runs on a schedule.
+var outboxRetryPolicy = retryPolicy{
+	baseDelay: 5 * time.Second,
+	maxDelay:  10 * time.Minute,
+}
+
+type outboxServiceImpl struct {
+	outboxRepository    repository.OutboxRepository
+	notificationService NotificationService
+}
+
+func NewOutboxServiceImpl(
+	outboxRepository repository.OutboxRepository,
+	notificationService NotificationService,
+) OutboxService {
+	return &outboxServiceImpl{
+		outboxRepository:    outboxRepository,
+		notificationService: notificationService,
+	}
+}
+
+// RelayNotifications publishes pending outbox rows to the notification queue
+// and marks them as sent. Rows that fail stay pending and are retried with
+// an exponential backoff, rows that can't be decoded are marked as failed.
+func (o *outboxServiceImpl) RelayNotifications(ctx context.Context) *errors.AppError {
+	messages, err := o.outboxRepository.GetPending(ctx, outboxBatchSize)
+	if err != nil {
+		return err
+	}
+
+	sentIds := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		if message.PayloadError != nil {
+			log.Printf("[OutboxService] Dropping message %d with an invalid payload: %v\n", message.ID, message.PayloadError)
+			if markErr := o.outboxRepository.MarkUndeliverable(ctx, message.ID, message.PayloadError.Error()); markErr != nil {
+				log.Printf("%v\n", markErr)
+			}
+			continue
+		}
+
+		err := o.notificationService.SendNotification(ctx, []*model.NotificationRequest{message.Request})
+		if err != nil {
+			log.Printf("[OutboxService] Error relaying message %d: %v\n", message.ID, err)
+			nextAttemptAt := time.Now().Add(outboxRetryPolicy.delay(message.Attempts + 1))
+			if markErr := o.outboxRepository.MarkFailed(ctx, message.ID, err.Error(), nextAttemptAt); markErr != nil {
+				log.Printf("%v\n", markErr)
+			}
+			continue
+		}
+
+		sentIds = append(sentIds, message.ID)
+	}
+
+	return o.outboxRepository.MarkSent(ctx, sentIds)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) GetPending(ctx context.Context, limit int) ([]*model.OutboxMessage, *apperrors.AppError) {
+	args := m.Called(limit)
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.OutboxMessage), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockOutboxRepository) MarkSent(ctx context.Context, ids []int64) *apperrors.AppError {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) *apperrors.AppError {
+	args := m.Called(id, lastError, nextAttemptAt)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockOutboxRepository) MarkUndeliverable(ctx context.Context, id int64, lastError string) *apperrors.AppError {
+	args := m.Called(id, lastError)
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func TestRelayNotifications_MarksPublishedAsSent(t *testing.T) {
+	mockOutbox := new(MockOutboxRepository)
+	mockNotifyService := new(MockNotificationService)
+
+	first := &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat1"}
+	second := &model.NotificationRequest{Type: model.NotificationTypeExit, ChatID: "chat2"}
+
+	mockOutbox.On("GetPending", outboxBatchSize).Return([]*model.OutboxMessage{
+		{ID: 1, Request: first},
+		{ID: 2, Request: second},
+	}, nil)
+	mockOutbox.On("MarkSent", mock.Anything).Return(nil)
+	mockNotifyService.On("SendNotification", mock.Anything).Return(nil)
+
+	service := NewOutboxServiceImpl(mockOutbox, mockNotifyService)
+
+	err := service.RelayNotifications(context.Background())
+
+	assert.Nil(t, err)
+	mockNotifyService.AssertCalled(t, "SendNotification", []*model.NotificationRequest{first})
+	mockNotifyService.AssertCalled(t, "SendNotification", []*model.NotificationRequest{second})
+	mockOutbox.AssertCalled(t, "MarkSent", []int64{1, 2})
+	mockOutbox.AssertNotCalled(t, "MarkFailed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRelayNotifications_PublishErrorKeepsMessagePending(t *testing.T) {
+	mockOutbox := new(MockOutboxRepository)
+	mockNotifyService := new(MockNotificationService)
+	publishError := apperrors.NewAppError("TestError", errors.New("pubsub unavailable"))
+
+	failing := &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat1"}
+	succeeding := &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat2"}
+
+	mockOutbox.On("GetPending", outboxBatchSize).Return([]*model.OutboxMessage{
+		{ID: 1, Request: failing, Attempts: 2},
+		{ID: 2, Request: succeeding},
+	}, nil)
+	mockOutbox.On("MarkSent", mock.Anything).Return(nil)
+	mockOutbox.On("MarkFailed", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockNotifyService.On("SendNotification", []*model.NotificationRequest{failing}).Return(publishError)
+	mockNotifyService.On("SendNotification", []*model.NotificationRequest{succeeding}).Return(nil)
+
+	service := NewOutboxServiceImpl(mockOutbox, mockNotifyService)
+
+	err := service.RelayNotifications(context.Background())
+
+	assert.Nil(t, err)
+	// The third failure waits four times the base delay
+	mockOutbox.AssertCalled(t, "MarkFailed", int64(1), publishError.Error(), mock.MatchedBy(func(nextAttemptAt time.Time) bool {
+		return nextAttemptAt.After(time.Now().Add(4*outboxRetryPolicy.baseDelay - time.Second))
+	}))
+	mockOutbox.AssertCalled(t, "MarkSent", []int64{2})
+}
+
+func TestRelayNotifications_InvalidPayloadDoesNotBlockBatch(t *testing.T) {
+	mockOutbox := new(MockOutboxRepository)
+	mockNotifyService := new(MockNotificationService)
+	payloadError := errors.New("unexpected end of JSON input")
+
+	valid := &model.NotificationRequest{Type: model.NotificationTypeEntry, ChatID: "chat1"}
+
+	mockOutbox.On("GetPending", outboxBatchSize).Return([]*model.OutboxMessage{
+		{ID: 1, PayloadError: payloadError},
+		{ID: 2, Request: valid},
+	}, nil)
+	mockOutbox.On("MarkUndeliverable", mock.Anything, mock.Anything).Return(nil)
+	mockOutbox.On("MarkSent", mock.Anything).Return(nil)
+	mockNotifyService.On("SendNotification", mock.Anything).Return(nil)
+
+	service := NewOutboxServiceImpl(mockOutbox, mockNotifyService)
+
+	err := service.RelayNotifications(context.Background())
+
+	assert.Nil(t, err)
+	mockOutbox.AssertCalled(t, "MarkUndeliverable", int64(1), payloadError.Error())
+	mockNotifyService.AssertNumberOfCalls(t, "SendNotification", 1)
+	mockOutbox.AssertCalled(t, "MarkSent", []int64{2})
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// accessPollState decides when the next access poll is due. The interval is
+// doubled after an error or after every idleCycles polls in a row without
+// changes, up to maxInterval, and goes back to the base interval as soon as a change
+// is detected. While closed the interval is at least closedInterval.
+type accessPollState struct {
+	baseInterval   time.Duration
+	maxInterval    time.Duration
+	closedInterval time.Duration
+	idleCycles     int
+
+	mu          sync.Mutex
+	interval    time.Duration
+	emptyCycles int
+	nextPoll    time.Time
+}
+
+func newAccessPollState(baseInterval, maxInterval, closedInterval time.Duration, idleCycles int) *accessPollState {
+	return &accessPollState{
+		baseInterval:   baseInterval,
+		maxInterval:    maxInterval,
+		closedInterval: closedInterval,
+		idleCycles:     idleCycles,
+		interval:       baseInterval,
+	}
+}
+
+func (p *accessPollState) due(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !now.Before(p.nextPoll)
+}
+
+// record updates the interval with the result of a poll and schedules the
+// next one.
+func (p *accessPollState) record(now time.Time, changes int, failed bool, open bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case failed:
+		p.slowDown()
+	case changes > 0:
+		p.interval = p.baseInterval
+		p.emptyCycles = 0
+	default:
+		p.emptyCycles++
+		if p.emptyCycles >= p.idleCycles {
+			// Counted again so the interval grows gradually
+			p.emptyCycles = 0
+			p.slowDown()
+		}
+	}
+
+	interval := p.interval
+	if !open && interval < p.closedInterval {
+		interval = p.closedInterval
+	}
+	p.nextPoll = now.Add(interval)
+}
+
+func (p *accessPollState) slowDown() {
+	p.interval *= 2
+	if p.interval > p.maxInterval {
+		p.interval = p.maxInterval
+	}
+}
+
+// currentInterval returns the interval applied while open.
+func (p *accessPollState) currentInterval() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.interval
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"spl-notification/internal/config"
+	"spl-notification/internal/dto/request"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
+)
+
+type preferencesServiceImpl struct {
+	chatPreferencesRepository repository.ChatPreferencesRepository
+}
+
+func NewPreferencesServiceImpl(
+	chatPreferencesRepository repository.ChatPreferencesRepository,
+) PreferencesService {
+	return &preferencesServiceImpl{
+		chatPreferencesRepository: chatPreferencesRepository,
+	}
+}
+
+func (p *preferencesServiceImpl) GetPreferences(ctx context.Context, chatId string) (*model.ChatPreferences, *errors.AppError) {
+	preferences, err := p.chatPreferencesRepository.GetByChatId(ctx, chatId)
+	if err != nil {
+		return nil, err
+	}
+
+	if preferences == nil {
+		return model.DefaultChatPreferences(chatId), nil
+	}
+	return preferences, nil
+}
+
+func (p *preferencesServiceImpl) UpdatePreferences(ctx context.Context, preferencesDTO *request.UpdatePreferencesDTO) (*model.ChatPreferences, *errors.AppError) {
+	if preferencesDTO.TimeZone != nil {
+		if _, err := config.ParseZone(*preferencesDTO.TimeZone); err != nil {
+			return nil, errors.NewAppErrorWithType("PreferencesService", errors.TypeValidation, err)
+		}
+	}
+
+	preferences := model.DefaultChatPreferences(preferencesDTO.ChatID)
+	if preferencesDTO.NotifyMode != "" {
+		preferences.NotifyMode = preferencesDTO.NotifyMode
+	}
+	if preferencesDTO.QuietMode != "" {
+		preferences.QuietMode = preferencesDTO.QuietMode
+	}
+	preferences.QuietHoursStart = preferencesDTO.QuietHoursStart
+	preferences.QuietHoursEnd = preferencesDTO.QuietHoursEnd
+	preferences.TimeZone = preferencesDTO.TimeZone
+
+	if err := p.chatPreferencesRepository.Upsert(ctx, preferences); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
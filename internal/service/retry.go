@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"spl-notification/internal/errors"
+	"time"
+)
+
+// retryPolicy bounds the webhook attempts of a notification, waiting
+// baseDelay after the first failure and doubling up to maxDelay.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// delay returns the wait after the given failed attempt (1 based).
+func (p retryPolicy) delay(attempt int) time.Duration {
+	delay := p.baseDelay
+	for i := 1; i < attempt && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.maxDelay)
+}
+
+// do runs the call until it succeeds, fails with a permanent error or the
+// attempts are exhausted, and returns the attempts made along with the last
+// error.
+func (p retryPolicy) do(ctx context.Context, call func() *errors.AppError) (int, *errors.AppError) {
+	for attempt := 1; ; attempt++ {
+		err := call()
+		// Rejections and an open circuit breaker are not retried here
+		if err == nil || !isDependencyFailure(err) || attempt >= p.maxAttempts {
+			return attempt, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempt, err
+		case <-time.After(p.delay(attempt)):
+		}
+	}
+}
+
+// statusCode returns the webhook response status of a delivery error, if any.
+func statusCode(err *errors.AppError) *int {
+	if statusErr, ok := err.Err.(*httpStatusError); ok {
+		return &statusErr.statusCode
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"spl-notification/internal/config"
+	"spl-notification/internal/errors"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	outboxRelayInterval   = 2 * time.Second
+	longStayInterval      = 5 * time.Minute
+	quietSummaryInterval  = 1 * time.Minute
+	deliveryPurgeInterval = 1 * time.Hour
+)
+
+type scheduledJob struct {
+	name       string
+	definition gocron.JobDefinition
+	task       func(ctx context.Context) *errors.AppError
+	limitMode  gocron.LimitMode
+	options    []gocron.JobOption
+}
+
+// schedulerServiceImpl runs the periodic jobs: the access poller, the outbox
+// relay and the alerts and summaries. Jobs share a context that is cancelled
+// once Stop has drained the running ones.
+type schedulerServiceImpl struct {
+	accessService       AccessService
+	outboxService       OutboxService
+	statsService        StatsService
+	notificationService NotificationService
+	locationService     LocationService
+	enviromentConfig    *config.EnvironmentConfig
+
+	accessPoll *accessPollState
+
+	mu        sync.Mutex
+	scheduler gocron.Scheduler
+	cancel    context.CancelFunc
+}
+
+func NewSchedulerServiceImpl(
+	accessService AccessService,
+	outboxService OutboxService,
+	statsService StatsService,
+	notificationService NotificationService,
+	locationService LocationService,
+	enviromentConfig *config.EnvironmentConfig,
+) SchedulerService {
+	return &schedulerServiceImpl{
+		accessService:       accessService,
+		outboxService:       outboxService,
+		statsService:        statsService,
+		notificationService: notificationService,
+		locationService:     locationService,
+		enviromentConfig:    enviromentConfig,
+		accessPoll: newAccessPollState(
+			enviromentConfig.AccessPollInterval,
+			enviromentConfig.AccessPollMaxInterval,
+			enviromentConfig.AccessPollClosedInterval,
+			enviromentConfig.AccessPollIdleCycles,
+		),
+	}
+}
+
+func (s *schedulerServiceImpl) Start() *errors.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scheduler != nil {
+		return s.error(fmt.Errorf("scheduler already started"))
+	}
+
+	scheduler, err := gocron.NewScheduler(
+		gocron.WithLocation(s.enviromentConfig.Location()),
+		gocron.WithStopTimeout(s.enviromentConfig.ShutdownTimeout),
+	)
+	if err != nil {
+		return s.error(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	jobs := []scheduledJob{
+		{"checking long stays", gocron.DurationJob(longStayInterval), s.accessService.CheckLongStays, gocron.LimitModeReschedule, nil},
+		{"relaying notifications", gocron.DurationJob(outboxRelayInterval), s.outboxService.RelayNotifications, gocron.LimitModeReschedule, nil},
+		{"sending quiet hours summaries", gocron.DurationJob(quietSummaryInterval), s.notificationService.SendQuietHoursSummaries, gocron.LimitModeReschedule, nil},
+		{"purging delivered notifications", gocron.DurationJob(deliveryPurgeInterval), s.notificationService.PurgeDeliveries, gocron.LimitModeReschedule, nil},
+		{"checking inactivity", gocron.CronJob(s.enviromentConfig.InactivitySchedule, false), s.accessService.CheckInactivity, gocron.LimitModeReschedule, zonedCronOptions()},
+		{"sending weekly digest", gocron.CronJob(s.enviromentConfig.DigestSchedule, false), s.statsService.SendWeeklyDigest, gocron.LimitModeReschedule, zonedCronOptions()},
+	}
+
+	switch s.enviromentConfig.AccessIngestMode {
+	case AccessIngestModePush:
+		// Accesses are only received on POST /access/events
+	case AccessIngestModeHybrid:
+		jobs = append(jobs, scheduledJob{"reconciling accesses", gocron.DurationJob(s.enviromentConfig.AccessReconcileInterval), s.reconcileAccesses, gocron.LimitModeWait, nil})
+	default:
+		jobs = append(jobs, scheduledJob{"checking accesses", gocron.DurationJob(s.enviromentConfig.AccessPollInterval), s.pollAccesses, gocron.LimitModeWait, nil})
+	}
+
+	for _, job := range jobs {
+		options := append([]gocron.JobOption{gocron.WithSingletonMode(job.limitMode)}, job.options...)
+		_, err := scheduler.NewJob(
+			job.definition,
+			gocron.NewTask(func() {
+				if err := job.task(ctx); err != nil {
+					log.Printf("[CRON] Error %s: %v\n", job.name, err)
+				}
+			}),
+			options...,
+		)
+		if err != nil {
+			// A job that can't be scheduled would never run, startup is aborted
+			cancel()
+			if shutdownErr := scheduler.Shutdown(); shutdownErr != nil {
+				log.Printf("%v\n", shutdownErr)
+			}
+			return s.error(fmt.Errorf("scheduling %s: %w", job.name, err))
+		}
+	}
+
+	scheduler.Start()
+	s.scheduler = scheduler
+	s.cancel = cancel
+	return nil
+}
+
+// Stop prevents new runs and waits for the running jobs, such as an access
+// cycle, to finish before cancelling their context.
+func (s *schedulerServiceImpl) Stop(ctx context.Context) *errors.AppError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.scheduler == nil {
+		return nil
+	}
+	defer func() {
+		s.cancel()
+		s.scheduler = nil
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.scheduler.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return s.error(err)
+		}
+	case <-ctx.Done():
+		return s.error(ctx.Err())
+	}
+
+	return nil
+}
+
+// pollAccesses runs on every base interval tick and skips the ticks before
+// the next poll is due, so the effective interval adapts to the results.
+func (s *schedulerServiceImpl) pollAccesses(ctx context.Context) *errors.AppError {
+	now := time.Now()
+	if !s.accessPoll.due(now) {
+		return nil
+	}
+
+	changes, err := s.accessService.PollAccesses(ctx)
+	s.accessPoll.record(now, changes, err != nil, s.isOpen(now))
+	return err
+}
+
+// reconcileAccesses polls on a fixed interval in hybrid mode, catching the
+// events the push missed. Events already pushed produce no changes.
+func (s *schedulerServiceImpl) reconcileAccesses(ctx context.Context) *errors.AppError {
+	_, err := s.accessService.PollAccesses(ctx)
+	return err
+}
+
+// isOpen reports whether any location is open at t, in its own time zone.
+// Active locations missing from OpeningHours are always open.
+func (s *schedulerServiceImpl) isOpen(t time.Time) bool {
+	if len(s.enviromentConfig.OpeningHours) == 0 {
+		return true
+	}
+
+	for _, code := range s.locationService.ActiveCodes() {
+		if _, ok := s.enviromentConfig.OpeningHours[code]; !ok {
+			return true
+		}
+	}
+
+	for code, openingHours := range s.enviromentConfig.OpeningHours {
+		timeZone := s.locationService.TimeZone(code)
+		if timeZone == nil {
+			timeZone = s.enviromentConfig.Location()
+		}
+		if openingHours.IsOpen(t.In(timeZone)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schedulerServiceImpl) error(err error) *errors.AppError {
+	return errors.NewAppError("SchedulerService", err)
+}
+
+// zonedCron runs a five field cron expression in the scheduler location. The
+// default implementation prefixes the expression with CRON_TZ and the name of
+// the location, which can't be loaded back for fixed offsets such as GMT-3.
+type zonedCron struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// zonedCronOptions returns the options of a cron job, each job needs its own
+// zonedCron since it keeps the parsed expression.
+func zonedCronOptions() []gocron.JobOption {
+	return []gocron.JobOption{gocron.WithCronImplementation(&zonedCron{})}
+}
+
+func (c *zonedCron) IsValid(crontab string, location *time.Location, now time.Time) error {
+	schedule, err := cron.ParseStandard(crontab)
+	if err != nil {
+		return err
+	}
+	if schedule.Next(now.In(location)).IsZero() {
+		return fmt.Errorf("cron expression %q never runs", crontab)
+	}
+
+	c.schedule = schedule
+	c.location = location
+	return nil
+}
+
+func (c *zonedCron) Next(lastRun time.Time) time.Time {
+	return c.schedule.Next(lastRun.In(c.location))
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"spl-notification/internal/config"
+	"spl-notification/internal/dto/response"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccessService struct {
+	mock.Mock
+}
+
+func (m *MockAccessService) CheckAccess(ctx context.Context, access []*model.Access) (int, *apperrors.AppError) {
+	args := m.Called(access)
+	if args.Get(1) == nil {
+		return args.Int(0), nil
+	}
+	return args.Int(0), args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockAccessService) PollAccesses(ctx context.Context) (int, *apperrors.AppError) {
+	args := m.Called()
+	if args.Get(1) == nil {
+		return args.Int(0), nil
+	}
+	return args.Int(0), args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockAccessService) IngestAccesses(ctx context.Context, events []*response.AccessDTO) (int, int, *apperrors.AppError) {
+	args := m.Called(events)
+	if args.Get(2) == nil {
+		return args.Int(0), args.Int(1), nil
+	}
+	return args.Int(0), args.Int(1), args.Get(2).(*apperrors.AppError)
+}
+
+func (m *MockAccessService) GetCompleteAccess(ctx context.Context) ([]*model.Access, *apperrors.AppError) {
+	args := m.Called()
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.Access), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockAccessService) CheckLongStays(ctx context.Context) *apperrors.AppError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+func (m *MockAccessService) CheckInactivity(ctx context.Context) *apperrors.AppError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+type MockOutboxService struct {
+	mock.Mock
+}
+
+func (m *MockOutboxService) RelayNotifications(ctx context.Context) *apperrors.AppError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+type MockStatsService struct {
+	mock.Mock
+}
+
+func (m *MockStatsService) GetStatsByChatId(ctx context.Context, chatId string, days int) ([]*model.VisitStats, *apperrors.AppError) {
+	args := m.Called(chatId, days)
+	if args.Get(1) == nil {
+		return args.Get(0).([]*model.VisitStats), nil
+	}
+	return nil, args.Get(1).(*apperrors.AppError)
+}
+
+func (m *MockStatsService) SendWeeklyDigest(ctx context.Context) *apperrors.AppError {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil
+	}
+	return args.Get(0).(*apperrors.AppError)
+}
+
+// newTestScheduler returns a scheduler polling accesses every 10ms, the
+// remaining jobs don't fire during the tests.
+func newTestScheduler(accessService AccessService) *schedulerServiceImpl {
+	envConfig := &config.EnvironmentConfig{
+		Zone:                     "GMT-3",
+		ShutdownTimeout:          time.Second,
+		InactivitySchedule:       "0 10 * * *",
+		DigestSchedule:           "0 20 * * 0",
+		AccessPollInterval:       10 * time.Millisecond,
+		AccessPollMaxInterval:    10 * time.Millisecond,
+		AccessPollClosedInterval: 10 * time.Millisecond,
+		AccessPollIdleCycles:     1,
+	}
+
+	return NewSchedulerServiceImpl(
+		accessService,
+		new(MockOutboxService),
+		new(MockStatsService),
+		new(MockNotificationService),
+		newMockLocationService(),
+		envConfig,
+	).(*schedulerServiceImpl)
+}
+
+func TestScheduler_NoAccessCycleAfterStop(t *testing.T) {
+	var cycles atomic.Int32
+	mockAccessService := new(MockAccessService)
+	mockAccessService.On("PollAccesses").
+		Run(func(args mock.Arguments) { cycles.Add(1) }).
+		Return(0, nil)
+
+	scheduler := newTestScheduler(mockAccessService)
+
+	assert.Nil(t, scheduler.Start())
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, scheduler.Stop(context.Background()))
+
+	stoppedAt := cycles.Load()
+	assert.Greater(t, stoppedAt, int32(0))
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, stoppedAt, cycles.Load())
+}
+
+func TestScheduler_StopDrainsRunningCycle(t *testing.T) {
+	var started, finished atomic.Bool
+	mockAccessService := new(MockAccessService)
+	mockAccessService.On("PollAccesses").
+		Run(func(args mock.Arguments) {
+			started.Store(true)
+			time.Sleep(100 * time.Millisecond)
+			finished.Store(true)
+		}).
+		Return(0, nil)
+
+	scheduler := newTestScheduler(mockAccessService)
+
+	assert.Nil(t, scheduler.Start())
+	assert.Eventually(t, started.Load, time.Second, 5*time.Millisecond)
+	assert.Nil(t, scheduler.Stop(context.Background()))
+
+	// Stop returns only once the running cycle is over
+	assert.True(t, finished.Load())
+}
+
+func TestScheduler_StartFailsOnInvalidSchedule(t *testing.T) {
+	scheduler := newTestScheduler(new(MockAccessService))
+	scheduler.enviromentConfig.DigestSchedule = "every sunday"
+
+	err := scheduler.Start()
+
+	// Nothing is left running and startup is aborted
+	assert.NotNil(t, err)
+	assert.Nil(t, scheduler.scheduler)
+	assert.Nil(t, scheduler.Stop(context.Background()))
+}
+
+func TestScheduler_CronJobsRunInZone(t *testing.T) {
+	scheduler := newTestScheduler(new(MockAccessService))
+
+	assert.Nil(t, scheduler.Start())
+	defer scheduler.Stop(context.Background())
+
+	// Each cron job keeps its own expression, evaluated in GMT-3: inactivity
+	// at 10:00 (13:00 UTC) and the digest on Sundays at 20:00 (23:00 UTC)
+	var inactivity, digest int
+	for _, job := range scheduler.scheduler.Jobs() {
+		nextRun, err := job.NextRun()
+		assert.NoError(t, err)
+
+		nextRun = nextRun.UTC()
+		if nextRun.Minute() != 0 || nextRun.Second() != 0 || nextRun.Nanosecond() != 0 {
+			continue
+		}
+		switch {
+		case nextRun.Hour() == 13:
+			inactivity++
+		case nextRun.Hour() == 23 && nextRun.Weekday() == time.Sunday:
+			digest++
+		}
+	}
+	assert.Equal(t, 1, inactivity)
+	assert.Equal(t, 1, digest)
+}
+
+func TestAccessPollState_AdaptsInterval(t *testing.T) {
+	now := time.Now()
+	poll := newAccessPollState(5*time.Second, 40*time.Second, 5*time.Minute, 2)
+
+	// Errors double the interval up to the maximum
+	poll.record(now, 0, true, true)
+	assert.Equal(t, 10*time.Second, poll.currentInterval())
+	poll.record(now, 0, true, true)
+	poll.record(now, 0, true, true)
+	poll.record(now, 0, true, true)
+	assert.Equal(t, 40*time.Second, poll.currentInterval())
+
+	// A detected change goes back to the base interval
+	poll.record(now, 3, false, true)
+	assert.Equal(t, 5*time.Second, poll.currentInterval())
+	assert.False(t, poll.due(now.Add(4*time.Second)))
+	assert.True(t, poll.due(now.Add(5*time.Second)))
+
+	// Empty polls slow down once per idle cycles, up to the maximum
+	expected := []time.Duration{
+		5 * time.Second, 10 * time.Second,
+		10 * time.Second, 20 * time.Second,
+		20 * time.Second, 40 * time.Second,
+		40 * time.Second, 40 * time.Second,
+	}
+	for n, interval := range expected {
+		poll.record(now, 0, false, true)
+		assert.Equal(t, interval, poll.currentInterval(), "empty poll %d", n+1)
+	}
+
+	// While closed the next poll waits at least the closed interval
+	poll.record(now, 1, false, false)
+	assert.False(t, poll.due(now.Add(time.Minute)))
+	assert.True(t, poll.due(now.Add(5*time.Minute)))
+}
+
+func TestScheduler_IsOpen(t *testing.T) {
+	// 03:00 UTC is midnight in GMT-3, 12:00 UTC is 09:00
+	night := time.Date(2025, 10, 5, 3, 0, 0, 0, time.UTC)
+	day := time.Date(2025, 10, 5, 12, 0, 0, 0, time.UTC)
+	openingHours := map[int8]model.OpeningHours{102: {Start: "06:00", End: "23:00"}}
+
+	tests := []struct {
+		name         string
+		openingHours map[int8]model.OpeningHours
+		activeCodes  []int8
+		at           time.Time
+		expected     bool
+	}{
+		{"without opening hours", nil, []int8{102}, night, true},
+		{"listed location open", openingHours, []int8{102}, day, true},
+		{"listed location closed", openingHours, []int8{102}, night, false},
+		{"unlisted location always open", openingHours, []int8{102, 104}, night, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			locationService := new(MockLocationService)
+			locationService.On("TimeZone", mock.Anything).Return(nil).Maybe()
+			locationService.On("ActiveCodes").Return(test.activeCodes).Maybe()
+
+			scheduler := &schedulerServiceImpl{
+				locationService:  locationService,
+				enviromentConfig: &config.EnvironmentConfig{Zone: "GMT-3", OpeningHours: test.openingHours},
+			}
+
+			assert.Equal(t, test.expected, scheduler.isOpen(test.at))
+		})
+	}
+}
+
+func TestZonedCron_RunsInFixedOffset(t *testing.T) {
+	location, err := config.ParseZone("GMT-3")
+	assert.NoError(t, err)
+
+	zoned := &zonedCron{}
+	now := time.Date(2025, 10, 5, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, zoned.IsValid("0 10 * * *", location, now))
+
+	// 10:00 in GMT-3 is 13:00 UTC
+	assert.True(t, zoned.Next(now).Equal(time.Date(2025, 10, 5, 13, 0, 0, 0, time.UTC)))
+	assert.True(t, zoned.Next(now.Add(2*time.Hour)).Equal(time.Date(2025, 10, 6, 13, 0, 0, 0, time.UTC)))
+
+	// Sundays at 20:00 in GMT-3, 2025-10-05 is a Sunday
+	assert.NoError(t, zoned.IsValid("0 20 * * 0", location, now))
+	assert.True(t, zoned.Next(time.Date(2025, 10, 5, 22, 0, 0, 0, time.UTC)).Equal(time.Date(2025, 10, 5, 23, 0, 0, 0, time.UTC)))
+
+	assert.Error(t, zoned.IsValid("every day", location, now))
+	assert.Error(t, zoned.IsValid("0 10 30 2 *", location, now))
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"spl-notification/internal/config"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of a signed request. The signature is the hex HMAC-SHA256 of
+// "METHOD\nREQUEST_URI\nTIMESTAMP\nhex(SHA256(body))" with the key named by
+// SignatureKeyIDHeader, the timestamp being in Unix seconds.
+const (
+	SignatureKeyIDHeader     = "X-Key-Id"
+	SignatureTimestampHeader = "X-Timestamp"
+	SignatureHeader          = "X-Signature"
+)
+
+// requestSigner signs the requests sent to the services that verify them.
+type requestSigner struct {
+	key model.SigningKey
+	now func() time.Time
+}
+
+// newRequestSigner returns nil without a key, the caller then falls back to
+// its static token.
+func newRequestSigner(key *model.SigningKey) *requestSigner {
+	if key == nil {
+		return nil
+	}
+	return &requestSigner{key: *key, now: time.Now}
+}
+
+// sign sets the signature headers, body must be the content sent with req.
+func (s *requestSigner) sign(req *http.Request, body []byte) {
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set(SignatureKeyIDHeader, s.key.ID)
+	req.Header.Set(SignatureTimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, requestSignature([]byte(s.key.Secret), req.Method, req.URL.RequestURI(), timestamp, body))
+}
+
+func requestSignature(secret []byte, method string, uri string, timestamp string, body []byte) string {
+	digest := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.ToUpper(method) + "\n" + uri + "\n" + timestamp + "\n" + hex.EncodeToString(digest[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RequestVerifier checks the signature of the inbound requests. A signature
+// is accepted once, and only while its timestamp is inside the window.
+type RequestVerifier struct {
+	keys   map[string][]byte
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPurge time.Time
+}
+
+func NewRequestVerifier(enviromentConfig *config.EnvironmentConfig) *RequestVerifier {
+	keys := make(map[string][]byte, len(enviromentConfig.ApiSigningKeys))
+	for _, key := range enviromentConfig.ApiSigningKeys {
+		keys[key.ID] = []byte(key.Secret)
+	}
+
+	return &RequestVerifier{
+		keys:   keys,
+		window: enviromentConfig.ApiSignatureWindow,
+		now:    time.Now,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// Enabled reports whether any signing key is configured.
+func (v *RequestVerifier) Enabled() bool {
+	return len(v.keys) > 0
+}
+
+// Verify checks the signature headers read with header against the request.
+func (v *RequestVerifier) Verify(method string, uri string, header func(string) string, body []byte) *errors.AppError {
+	keyID := header(SignatureKeyIDHeader)
+	timestamp := header(SignatureTimestampHeader)
+	signature := header(SignatureHeader)
+	if keyID == "" || timestamp == "" || signature == "" {
+		return v.error(fmt.Errorf("missing signature headers"))
+	}
+
+	secret, ok := v.keys[keyID]
+	if !ok {
+		return v.error(fmt.Errorf("unknown signing key %q", keyID))
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return v.error(fmt.Errorf("invalid signature timestamp %q", timestamp))
+	}
+	now := v.now()
+	signedAt := time.Unix(seconds, 0)
+	if signedAt.Before(now.Add(-v.window)) || signedAt.After(now.Add(v.window)) {
+		return v.error(fmt.Errorf("signature timestamp outside of the %s window", v.window))
+	}
+
+	expected := requestSignature(secret, method, uri, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return v.error(fmt.Errorf("invalid signature for key %q", keyID))
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.purge(now)
+	if _, replayed := v.seen[expected]; replayed {
+		return v.error(fmt.Errorf("signature already used"))
+	}
+	// Kept until the timestamp leaves the window, after which it is rejected
+	// anyway
+	v.seen[expected] = signedAt.Add(v.window)
+
+	return nil
+}
+
+// purge forgets the signatures that expired, at most once per window.
+func (v *RequestVerifier) purge(now time.Time) {
+	if now.Sub(v.lastPurge) < v.window {
+		return
+	}
+	for signature, expiresAt := range v.seen {
+		if now.After(expiresAt) {
+			delete(v.seen, signature)
+		}
+	}
+	v.lastPurge = now
+}
+
+func (v *RequestVerifier) error(err error) *errors.AppError {
+	return errors.NewAppError("RequestVerifier", err)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"net/http"
+	"spl-notification/internal/config"
+	"spl-notification/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestVerifier_VerifiesSignedRequests(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	oldKey := model.SigningKey{ID: "2026-01", Secret: "old-secret"}
+	newKey := model.SigningKey{ID: "2026-10", Secret: "new-secret"}
+
+	verifier := NewRequestVerifier(&config.EnvironmentConfig{
+		ApiSigningKeys:     []model.SigningKey{oldKey, newKey},
+		ApiSignatureWindow: 5 * time.Minute,
+	})
+	verifier.now = func() time.Time { return now }
+
+	signed := func(key model.SigningKey, signedAt time.Time, body string) *http.Request {
+		req, _ := http.NewRequest("POST", "http://localhost/track?x=1", strings.NewReader(body))
+		signer := newRequestSigner(&key)
+		signer.now = func() time.Time { return signedAt }
+		signer.sign(req, []byte(body))
+		return req
+	}
+	verify := func(req *http.Request, body string) bool {
+		return verifier.Verify(req.Method, req.URL.RequestURI(), req.Header.Get, []byte(body)) == nil
+	}
+
+	// Both keys are accepted while the old one is rotated out
+	assert.True(t, verify(signed(oldKey, now, `{"a":1}`), `{"a":1}`))
+	assert.True(t, verify(signed(newKey, now.Add(-time.Minute), `{"a":1}`), `{"a":1}`))
+
+	// A signature is accepted once
+	req := signed(newKey, now.Add(-2*time.Minute), `{"a":2}`)
+	assert.True(t, verify(req, `{"a":2}`))
+	assert.False(t, verify(req, `{"a":2}`))
+
+	// Tampered body, stale timestamp and unknown key
+	assert.False(t, verify(signed(newKey, now, `{"a":3}`), `{"a":4}`))
+	assert.False(t, verify(signed(newKey, now.Add(-10*time.Minute), `{"a":5}`), `{"a":5}`))
+	assert.False(t, verify(signed(model.SigningKey{ID: "other", Secret: "new-secret"}, now, `{"a":6}`), `{"a":6}`))
+
+	// Unsigned request
+	unsigned, _ := http.NewRequest("POST", "http://localhost/track", nil)
+	assert.False(t, verify(unsigned, ""))
+}
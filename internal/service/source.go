@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -13,29 +14,33 @@ import (
 type sourceServiceImpl struct {
 	enviromentConfig *config.EnvironmentConfig
 	httpClient       *http.Client
+	circuitBreaker   *circuitBreaker
+	signer           *requestSigner
 }
 
 func NewSourceServiceImpl(
 	enviromentConfig *config.EnvironmentConfig,
+	circuitBreakers *CircuitBreakers,
 ) SourceService {
 	return &sourceServiceImpl{
 		enviromentConfig: enviromentConfig,
 		httpClient: &http.Client{
 			Timeout: time.Second * 30,
 		},
+		circuitBreaker: circuitBreakers.Source,
+		signer:         newRequestSigner(enviromentConfig.SourceSigningKey),
 	}
 }
 
-func (s *sourceServiceImpl) GetABMUserByRun(run string) (*model.ABMUser, *errors.AppError) {
-	req, err := http.NewRequest("GET", s.enviromentConfig.SourceBaseUrl+"/user/abm/"+run, nil)
+func (s *sourceServiceImpl) GetABMUserByRun(ctx context.Context, run string) (*model.ABMUser, *errors.AppError) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.enviromentConfig.SourceBaseUrl+"/user/abm/"+run, nil)
 	if err != nil {
 		return nil, s.error(err)
 	}
-	req.Header.Set("X-Auth-String", s.enviromentConfig.AuthString)
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, s.error(err)
+	resp, appErr := s.do(req)
+	if appErr != nil {
+		return nil, appErr
 	}
 	defer resp.Body.Close()
 
@@ -54,16 +59,15 @@ func (s *sourceServiceImpl) GetABMUserByRun(run string) (*model.ABMUser, *errors
 	return &user, nil
 }
 
-func (s *sourceServiceImpl) GetUserByExternalId(externalId int32) (*model.User, *errors.AppError) {
-	req, err := http.NewRequest("GET", s.enviromentConfig.SourceBaseUrl+"/user/"+fmt.Sprintf("%d", externalId), nil)
+func (s *sourceServiceImpl) GetUserByExternalId(ctx context.Context, externalId int32) (*model.User, *errors.AppError) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.enviromentConfig.SourceBaseUrl+"/user/"+fmt.Sprintf("%d", externalId), nil)
 	if err != nil {
 		return nil, s.error(err)
 	}
-	req.Header.Set("X-Auth-String", s.enviromentConfig.AuthString)
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return nil, s.error(err)
+	resp, appErr := s.do(req)
+	if appErr != nil {
+		return nil, appErr
 	}
 	defer resp.Body.Close()
 
@@ -82,6 +86,37 @@ func (s *sourceServiceImpl) GetUserByExternalId(externalId int32) (*model.User,
 	return &user, nil
 }
 
+// do authenticates the request, signed when a signing key is configured,
+// and sends it through the circuit breaker of the source service.
+// Server errors count as failures and are returned as errors.
+func (s *sourceServiceImpl) do(req *http.Request) (*http.Response, *errors.AppError) {
+	if s.signer != nil {
+		s.signer.sign(req, nil)
+	} else {
+		req.Header.Set("X-Auth-String", s.enviromentConfig.AuthString)
+	}
+
+	var resp *http.Response
+	err := s.circuitBreaker.execute(func() *errors.AppError {
+		var err error
+		resp, err = s.httpClient.Do(req)
+		if err != nil {
+			return s.error(err)
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			resp.Body.Close()
+			return s.error(&httpStatusError{operation: "error fetching user", statusCode: resp.StatusCode, status: resp.Status})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
 func (s *sourceServiceImpl) error(err error) *errors.AppError {
 	return errors.NewAppError("SourceService", err)
 }
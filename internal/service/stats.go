@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"spl-notification/internal/config"
+	"spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"spl-notification/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	digestWindowDays = 7
+	statsVisitsPage  = 1000
+)
+
+type statsServiceImpl struct {
+	trackRepository       repository.TrackRepository
+	accessEventRepository repository.AccessEventRepository
+	notificationService   NotificationService
+	locationService       LocationService
+	enviromentConfig      *config.EnvironmentConfig
+}
+
+func NewStatsServiceImpl(
+	trackRepository repository.TrackRepository,
+	accessEventRepository repository.AccessEventRepository,
+	notificationService NotificationService,
+	locationService LocationService,
+	enviromentConfig *config.EnvironmentConfig,
+) StatsService {
+	return &statsServiceImpl{
+		trackRepository:       trackRepository,
+		accessEventRepository: accessEventRepository,
+		notificationService:   notificationService,
+		locationService:       locationService,
+		enviromentConfig:      enviromentConfig,
+	}
+}
+
+func (s *statsServiceImpl) GetStatsByChatId(ctx context.Context, chatId string, days int) ([]*model.VisitStats, *errors.AppError) {
+	tracks, err := s.trackRepository.GetTracksByChatId(ctx, chatId)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getStats(ctx, tracks, days)
+}
+
+func (s *statsServiceImpl) SendWeeklyDigest(ctx context.Context) *errors.AppError {
+	tracks, err := s.trackRepository.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	tracksByChat := make(map[string][]*model.Track)
+	for _, track := range tracks {
+		tracksByChat[track.ChatID] = append(tracksByChat[track.ChatID], track)
+	}
+
+	// A failing chat must not prevent the digest of the others
+	for chatId, chatTracks := range tracksByChat {
+		stats, err := s.getStats(ctx, chatTracks, digestWindowDays)
+		if err != nil {
+			log.Printf("[StatsService] Error computing digest for %s: %v\n", chatId, err)
+			continue
+		}
+
+		if !hasVisits(stats) {
+			continue
+		}
+
+		if err := s.notificationService.SendMessage(ctx, chatId, formatDigest(stats)); err != nil {
+			log.Printf("[StatsService] Error sending digest to %s: %v\n", chatId, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *statsServiceImpl) getStats(ctx context.Context, tracks []*model.Track, days int) ([]*model.VisitStats, *errors.AppError) {
+	if days <= 0 {
+		days = s.enviromentConfig.StatsWindowDays
+	}
+
+	location := s.enviromentConfig.Location()
+	now := time.Now().In(location)
+	from := startOfDay(now).AddDate(0, 0, -(days - 1))
+
+	stats := make([]*model.VisitStats, 0, len(tracks))
+	for _, track := range tracks {
+		visits, err := s.getAllVisits(ctx, track.ID, from)
+		if err != nil {
+			return nil, err
+		}
+
+		trackStats := computeVisitStats(visits, location, now)
+		if trackStats.FavouriteLocation != nil {
+			favouriteName := s.locationService.Name(*trackStats.FavouriteLocation)
+			trackStats.FavouriteLocationName = &favouriteName
+		}
+		trackStats.Run = track.Run
+		trackStats.FullName = track.FullName
+		trackStats.Alias = track.Alias
+		stats = append(stats, trackStats)
+	}
+
+	return stats, nil
+}
+
+// getAllVisits reads every visit of the track since from, one page at a time
+// until the total is reached.
+func (s *statsServiceImpl) getAllVisits(ctx context.Context, trackId int, from time.Time) ([]*model.Visit, *errors.AppError) {
+	var visits []*model.Visit
+	for {
+		page, total, err := s.accessEventRepository.GetVisits(ctx, trackId, &from, nil, statsVisitsPage, len(visits))
+		if err != nil {
+			return nil, err
+		}
+
+		visits = append(visits, page...)
+		if len(page) == 0 || len(visits) >= total {
+			return visits, nil
+		}
+	}
+}
+
+// computeVisitStats aggregates the visits of a single person. Streaks count
+// consecutive calendar days (in location) with at least one visit; the current
+// streak is still alive if the last visit was today or yesterday.
+func computeVisitStats(visits []*model.Visit, location *time.Location, now time.Time) *model.VisitStats {
+	stats := &model.VisitStats{Visits: len(visits)}
+
+	var finishedVisits int64
+	locationCount := make(map[int8]int)
+	days := make(map[time.Time]bool)
+	for _, visit := range visits {
+		if visit.DurationMinutes != nil {
+			stats.TotalMinutes += *visit.DurationMinutes
+			finishedVisits++
+		}
+		locationCount[visit.Location]++
+		days[startOfDay(visit.EntryAt.In(location))] = true
+	}
+
+	if finishedVisits > 0 {
+		stats.AverageMinutes = stats.TotalMinutes / finishedVisits
+	}
+
+	var favourite int8
+	favouriteCount := 0
+	for code, count := range locationCount {
+		if count > favouriteCount || (count == favouriteCount && code < favourite) {
+			favourite, favouriteCount = code, count
+		}
+	}
+	if favouriteCount > 0 {
+		stats.FavouriteLocation = &favourite
+	}
+
+	sortedDays := make([]time.Time, 0, len(days))
+	for day := range days {
+		sortedDays = append(sortedDays, day)
+	}
+	sort.Slice(sortedDays, func(i, j int) bool { return sortedDays[i].Before(sortedDays[j]) })
+
+	streak := 0
+	for i, day := range sortedDays {
+		if i > 0 && sortedDays[i-1].AddDate(0, 0, 1).Equal(day) {
+			streak++
+		} else {
+			streak = 1
+		}
+		if streak > stats.LongestStreak {
+			stats.LongestStreak = streak
+		}
+	}
+
+	if len(sortedDays) > 0 {
+		today := startOfDay(now)
+		last := sortedDays[len(sortedDays)-1]
+		if last.Equal(today) || last.Equal(today.AddDate(0, 0, -1)) {
+			stats.CurrentStreak = streak
+		}
+	}
+
+	return stats
+}
+
+func hasVisits(stats []*model.VisitStats) bool {
+	for _, stat := range stats {
+		if stat.Visits > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDigest(stats []*model.VisitStats) string {
+	var builder strings.Builder
+	builder.WriteString("📊 Resumen semanal:\n")
+
+	for _, stat := range stats {
+		name := stat.FullName
+		if stat.Alias != nil {
+			name = *stat.Alias
+		}
+
+		if stat.Visits == 0 {
+			builder.WriteString(fmt.Sprintf("\n- %s: sin visitas\n", name))
+			continue
+		}
+
+		builder.WriteString(fmt.Sprintf("\n- %s: %d visitas, %s en total (promedio %s)\n",
+			name, stat.Visits, formatMinutes(stat.TotalMinutes), formatMinutes(stat.AverageMinutes)))
+		if stat.FavouriteLocationName != nil {
+			builder.WriteString(fmt.Sprintf("  Sede favorita: %s\n", *stat.FavouriteLocationName))
+		}
+		if stat.CurrentStreak > 1 {
+			builder.WriteString(fmt.Sprintf("  Racha: %d días\n", stat.CurrentStreak))
+		}
+	}
+
+	return builder.String()
+}
+
+func formatMinutes(minutes int64) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
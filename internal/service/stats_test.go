@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"spl-notification/internal/config"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAccessEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccessEventRepository) GetVisits(ctx context.Context, trackId int, from *time.Time, to *time.Time, limit int, offset int) ([]*model.Visit, int, *apperrors.AppError) {
+	args := m.Called(trackId, from, to, limit, offset)
+	if args.Get(2) == nil {
+		return args.Get(0).([]*model.Visit), args.Int(1), nil
+	}
+	return nil, 0, args.Get(2).(*apperrors.AppError)
+}
+
+func TestComputeVisitStats(t *testing.T) {
+	location := time.FixedZone("GMT-3", -3*3600)
+	now := time.Date(2025, 10, 12, 12, 0, 0, 0, location)
+
+	visit := func(day int, hour int, minutes int64, place int8) *model.Visit {
+		entryAt := time.Date(2025, 10, day, hour, 0, 0, 0, location)
+		exitAt := entryAt.Add(time.Duration(minutes) * time.Minute)
+		return &model.Visit{EntryAt: entryAt, ExitAt: &exitAt, DurationMinutes: &minutes, Location: place}
+	}
+
+	visits := []*model.Visit{
+		visit(12, 8, 60, 104),
+		visit(11, 8, 90, 104),
+		visit(10, 20, 30, 106),
+		visit(7, 8, 60, 104),
+		visit(6, 8, 60, 106),
+		{EntryAt: time.Date(2025, 10, 12, 10, 0, 0, 0, location), Location: 104},
+	}
+
+	stats := computeVisitStats(visits, location, now)
+
+	assert.Equal(t, 6, stats.Visits)
+	assert.Equal(t, int64(300), stats.TotalMinutes)
+	assert.Equal(t, int64(60), stats.AverageMinutes)
+	assert.Equal(t, int8(104), *stats.FavouriteLocation)
+	assert.Equal(t, 3, stats.CurrentStreak)
+	assert.Equal(t, 3, stats.LongestStreak)
+}
+
+func TestComputeVisitStats_NoVisits(t *testing.T) {
+	stats := computeVisitStats(nil, time.UTC, time.Now())
+
+	assert.Equal(t, 0, stats.Visits)
+	assert.Nil(t, stats.FavouriteLocation)
+	assert.Equal(t, 0, stats.CurrentStreak)
+	assert.Equal(t, 0, stats.LongestStreak)
+}
+
+func TestSendWeeklyDigest(t *testing.T) {
+	mockTrackRepo := new(MockTrackRepository)
+	mockAccessEventRepo := new(MockAccessEventRepository)
+	mockNotificationService := new(MockNotificationService)
+	mockLocationService := new(MockLocationService)
+
+	mockTrackRepo.On("GetAll").Return([]*model.Track{
+		{ID: 1, ChatID: "active", ExternalID: 1, Run: "11111111-1", FullName: "John Doe"},
+		{ID: 2, ChatID: "active", ExternalID: 2, Run: "22222222-2", FullName: "Jane Doe"},
+		{ID: 3, ChatID: "idle", ExternalID: 2, Run: "22222222-2", FullName: "Jane Doe"},
+		{ID: 4, ChatID: "failing", ExternalID: 3, Run: "33333333-3", FullName: "Jim Doe"},
+	}, nil)
+
+	entryAt := time.Now().Add(-2 * time.Hour)
+	exitAt := entryAt.Add(time.Hour)
+	minutes := int64(60)
+	mockAccessEventRepo.On("GetVisits", 1, mock.Anything, mock.Anything, statsVisitsPage, 0).Return([]*model.Visit{
+		{EntryAt: entryAt, ExitAt: &exitAt, DurationMinutes: &minutes, Location: 104},
+	}, 1, nil)
+	mockAccessEventRepo.On("GetVisits", 2, mock.Anything, mock.Anything, statsVisitsPage, 0).Return([]*model.Visit{}, 0, nil)
+	mockAccessEventRepo.On("GetVisits", 3, mock.Anything, mock.Anything, statsVisitsPage, 0).Return([]*model.Visit{}, 0, nil)
+	mockAccessEventRepo.On("GetVisits", 4, mock.Anything, mock.Anything, statsVisitsPage, 0).
+		Return(nil, 0, apperrors.NewAppError("TestError", errors.New("database unavailable")))
+	mockLocationService.On("Name", int8(104)).Return("Calama")
+	mockNotificationService.On("SendMessage", "active", mock.Anything).Return(nil)
+
+	service := NewStatsServiceImpl(mockTrackRepo, mockAccessEventRepo, mockNotificationService, mockLocationService, &config.EnvironmentConfig{Zone: "UTC"})
+
+	err := service.SendWeeklyDigest(context.Background())
+
+	assert.Nil(t, err)
+	// The chat without visits gets no digest and the failing one doesn't stop the others
+	mockNotificationService.AssertNumberOfCalls(t, "SendMessage", 1)
+	message := mockNotificationService.Calls[0].Arguments.String(1)
+	assert.True(t, strings.Contains(message, "John Doe: 1 visitas"), message)
+	assert.True(t, strings.Contains(message, "Sede favorita: Calama"), message)
+	assert.True(t, strings.Contains(message, "Jane Doe: sin visitas"), message)
+}
+
+func TestGetStatsByChatId_ReadsEveryPage(t *testing.T) {
+	mockTrackRepo := new(MockTrackRepository)
+	mockAccessEventRepo := new(MockAccessEventRepository)
+	mockLocationService := new(MockLocationService)
+
+	mockTrackRepo.On("GetTracksByChatId", "chat1").Return([]*model.Track{
+		{ID: 1, ChatID: "chat1", ExternalID: 1, Run: "11111111-1", FullName: "John Doe"},
+	}, nil)
+
+	entryAt := time.Now().Add(-2 * time.Hour)
+	minutes := int64(1)
+	page := func(size int) []*model.Visit {
+		visits := make([]*model.Visit, size)
+		for i := range visits {
+			visits[i] = &model.Visit{EntryAt: entryAt, DurationMinutes: &minutes, Location: 104}
+		}
+		return visits
+	}
+	mockAccessEventRepo.On("GetVisits", 1, mock.Anything, mock.Anything, statsVisitsPage, 0).Return(page(statsVisitsPage), statsVisitsPage+500, nil)
+	mockAccessEventRepo.On("GetVisits", 1, mock.Anything, mock.Anything, statsVisitsPage, statsVisitsPage).Return(page(500), statsVisitsPage+500, nil)
+	mockLocationService.On("Name", int8(104)).Return("Calama")
+
+	service := NewStatsServiceImpl(mockTrackRepo, mockAccessEventRepo, nil, mockLocationService, &config.EnvironmentConfig{Zone: "UTC", StatsWindowDays: 30})
+
+	stats, err := service.GetStatsByChatId(context.Background(), "chat1", 0)
+
+	require.Nil(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, statsVisitsPage+500, stats[0].Visits)
+	assert.Equal(t, int64(statsVisitsPage+500), stats[0].TotalMinutes)
+	mockAccessEventRepo.AssertNumberOfCalls(t, "GetVisits", 2)
+}
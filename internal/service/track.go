@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"spl-notification/internal/dto/request"
 	"spl-notification/internal/errors"
 	"spl-notification/internal/model"
@@ -8,30 +10,39 @@ import (
 )
 
 type trackServiceImpl struct {
-	trackRepository     repository.TrackRepository
-	accessService       AccessService
-	notificationService NotificationService
+	trackRepository       repository.TrackRepository
+	accessEventRepository repository.AccessEventRepository
+	accessService         AccessService
+	notificationService   NotificationService
+	auditService          AuditService
+	locationService       LocationService
 }
 
 func NewTrackServiceImpl(
 	trackRepository repository.TrackRepository,
+	accessEventRepository repository.AccessEventRepository,
 	accessService AccessService,
 	notificationService NotificationService,
+	auditService AuditService,
+	locationService LocationService,
 ) TrackService {
 	return &trackServiceImpl{
-		trackRepository:     trackRepository,
-		accessService:       accessService,
-		notificationService: notificationService,
+		trackRepository:       trackRepository,
+		accessEventRepository: accessEventRepository,
+		accessService:         accessService,
+		notificationService:   notificationService,
+		auditService:          auditService,
+		locationService:       locationService,
 	}
 }
 
-func (t *trackServiceImpl) SendAllFollows(chatId string) *errors.AppError {
-	followTracks, err := t.trackRepository.GetTracksByChatId(chatId)
+func (t *trackServiceImpl) SendAllFollows(ctx context.Context, chatId string) *errors.AppError {
+	followTracks, err := t.trackRepository.GetTracksByChatId(ctx, chatId)
 	if err != nil {
 		return err
 	}
 
-	err = t.notificationService.SendTracks(chatId, followTracks)
+	err = t.notificationService.SendTracks(ctx, chatId, followTracks)
 	if err != nil {
 		return err
 	}
@@ -40,8 +51,8 @@ func (t *trackServiceImpl) SendAllFollows(chatId string) *errors.AppError {
 
 }
 
-func (t *trackServiceImpl) GetFollowTracksByChatId(chatId string) ([]*model.Track, *errors.AppError) {
-	followTracks, err := t.trackRepository.GetTracksByChatId(chatId)
+func (t *trackServiceImpl) GetFollowTracksByChatId(ctx context.Context, chatId string) ([]*model.Track, *errors.AppError) {
+	followTracks, err := t.trackRepository.GetTracksByChatId(ctx, chatId)
 	if err != nil {
 		return nil, err
 	}
@@ -49,31 +60,55 @@ func (t *trackServiceImpl) GetFollowTracksByChatId(chatId string) ([]*model.Trac
 	return followTracks, nil
 }
 
-func (t *trackServiceImpl) Create(trackDTO *request.CreateTrackDTO) *errors.AppError {
-	accesses, err := t.accessService.GetCompleteAccess()
+func (t *trackServiceImpl) GetVisitHistory(ctx context.Context, historyDTO *request.VisitHistoryDTO) ([]*model.Visit, int, *errors.AppError) {
+	track, err := t.trackRepository.GetTrackByChatIdAndRun(ctx, historyDTO.ChatID, historyDTO.Run)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	var userAccess *model.Access
-	for _, access := range accesses {
-		if access.ExternalID == trackDTO.ExternalID {
-			userAccess = access
-			break
-		}
+	if track == nil {
+		return nil, 0, errors.NewAppErrorWithType("TrackService", errors.TypeNotFound,
+			fmt.Errorf("track %s not found for chat %s", historyDTO.Run, historyDTO.ChatID))
+	}
+
+	offset := (historyDTO.Page - 1) * historyDTO.PageSize
+	visits, total, err := t.accessEventRepository.GetVisits(ctx, track.ID, historyDTO.From, historyDTO.To, historyDTO.PageSize, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, visit := range visits {
+		visit.LocationName = t.locationService.Name(visit.Location)
+	}
+	return visits, total, nil
+}
+
+func (t *trackServiceImpl) Create(ctx context.Context, trackDTO *request.CreateTrackDTO) *errors.AppError {
+	userAccess, err := t.currentAccess(ctx, trackDTO.ExternalID)
+	if err != nil {
+		return err
 	}
 
 	if userAccess != nil {
 		trackDTO.LastEntry = &userAccess.EntryAt
+		trackDTO.LastEntryLocation = &userAccess.Location
 		trackDTO.LastExit = userAccess.ExitAt
 	}
 
-	err = t.trackRepository.Create(trackDTO)
+	if trackDTO.NotifyType != nil && *trackDTO.NotifyType == model.NotifyModeBoth {
+		trackDTO.NotifyType = nil
+	}
+	if trackDTO.InactiveDays != nil && *trackDTO.InactiveDays == 0 {
+		trackDTO.InactiveDays = nil
+	}
+
+	track, err := t.trackRepository.Create(ctx, trackDTO)
 	if err != nil {
 		return err
 	}
+	t.auditService.Record(ctx, model.AuditActionTrackCreate, track.ChatID, track.Run, nil, track)
 
-	err = t.notificationService.SendMessage(trackDTO.ChatID, "✅ Agregado")
+	err = t.notificationService.SendMessage(ctx, trackDTO.ChatID, "✅ Agregado")
 	if err != nil {
 		return err
 	}
@@ -81,13 +116,64 @@ func (t *trackServiceImpl) Create(trackDTO *request.CreateTrackDTO) *errors.AppE
 	return nil
 }
 
-func (t *trackServiceImpl) Delete(deleteDTO *request.DeleteTrackDTO) *errors.AppError {
-	err := t.trackRepository.Delete(deleteDTO)
+func (t *trackServiceImpl) UpdateFilters(ctx context.Context, filtersDTO *request.UpdateTrackFiltersDTO) (*model.Track, *errors.AppError) {
+	track, err := t.trackRepository.GetTrackByChatIdAndRun(ctx, filtersDTO.ChatID, filtersDTO.Run)
+	if err != nil {
+		return nil, err
+	}
+
+	if track == nil {
+		return nil, errors.NewAppErrorWithType("TrackService", errors.TypeNotFound,
+			fmt.Errorf("track %s not found for chat %s", filtersDTO.Run, filtersDTO.ChatID))
+	}
+
+	before := *track
+	if filtersDTO.NotifyType != nil {
+		track.NotifyType = filtersDTO.NotifyType
+		if *filtersDTO.NotifyType == model.NotifyModeBoth {
+			track.NotifyType = nil
+		}
+	}
+	if filtersDTO.Locations != nil {
+		track.Locations = *filtersDTO.Locations
+	}
+	if filtersDTO.Schedule != nil {
+		track.Schedule = *filtersDTO.Schedule
+	}
+	if filtersDTO.LongStayHours != nil {
+		track.LongStayHours = filtersDTO.LongStayHours
+	}
+	if filtersDTO.InactiveDays != nil {
+		track.InactiveDays = filtersDTO.InactiveDays
+		if *filtersDTO.InactiveDays == 0 {
+			track.InactiveDays = nil
+		}
+	}
+
+	err = t.trackRepository.UpdateFilters(ctx, track)
+	if err != nil {
+		return nil, err
+	}
+	t.auditService.Record(ctx, model.AuditActionTrackUpdate, track.ChatID, track.Run, &before, track)
+
+	return track, nil
+}
+
+func (t *trackServiceImpl) Delete(ctx context.Context, deleteDTO *request.DeleteTrackDTO) *errors.AppError {
+	track, err := t.trackRepository.GetTrackByChatIdAndRun(ctx, deleteDTO.ChatID, deleteDTO.Run)
 	if err != nil {
 		return err
 	}
 
-	err = t.notificationService.SendMessage(deleteDTO.ChatID, "✅ Eliminado")
+	err = t.trackRepository.Delete(ctx, deleteDTO)
+	if err != nil {
+		return err
+	}
+	if track != nil {
+		t.auditService.Record(ctx, model.AuditActionTrackDelete, track.ChatID, track.Run, track, nil)
+	}
+
+	err = t.notificationService.SendMessage(ctx, deleteDTO.ChatID, "✅ Eliminado")
 	if err != nil {
 		return err
 	}
@@ -95,6 +181,64 @@ func (t *trackServiceImpl) Delete(deleteDTO *request.DeleteTrackDTO) *errors.App
 	return nil
 }
 
+// Restore brings back a deleted track. The person state is seeded from the
+// current accesses, as when the track is created, in case nobody else
+// follows the person anymore.
+func (t *trackServiceImpl) Restore(ctx context.Context, restoreDTO *request.RestoreTrackDTO) (*model.Track, *errors.AppError) {
+	active, err := t.trackRepository.GetTrackByChatIdAndRun(ctx, restoreDTO.ChatID, restoreDTO.Run)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, errors.NewAppErrorWithType("TrackService", errors.TypeConflict,
+			fmt.Errorf("track %s of chat %s is not deleted", restoreDTO.Run, restoreDTO.ChatID))
+	}
+
+	track, err := t.trackRepository.GetDeletedTrack(ctx, restoreDTO.ChatID, restoreDTO.Run)
+	if err != nil {
+		return nil, err
+	}
+	if track == nil {
+		return nil, errors.NewAppErrorWithType("TrackService", errors.TypeNotFound,
+			fmt.Errorf("deleted track %s not found for chat %s", restoreDTO.Run, restoreDTO.ChatID))
+	}
+
+	userAccess, err := t.currentAccess(ctx, track.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+	track.LastEntry, track.LastEntryLocation, track.LastExit = nil, nil, nil
+	if userAccess != nil {
+		track.LastEntry = &userAccess.EntryAt
+		track.LastEntryLocation = &userAccess.Location
+		track.LastExit = userAccess.ExitAt
+	}
+
+	restored, err := t.trackRepository.Restore(ctx, track)
+	if err != nil {
+		return nil, err
+	}
+	t.auditService.Record(ctx, model.AuditActionTrackRestore, restored.ChatID, restored.Run, nil, restored)
+
+	return restored, nil
+}
+
+// currentAccess returns the last access of the person, nil when the access
+// service doesn't list it.
+func (t *trackServiceImpl) currentAccess(ctx context.Context, externalID int32) (*model.Access, *errors.AppError) {
+	accesses, err := t.accessService.GetCompleteAccess(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, access := range accesses {
+		if access.ExternalID == externalID {
+			return access, nil
+		}
+	}
+	return nil, nil
+}
+
 func (t *trackServiceImpl) error(err error) *errors.AppError {
 	return errors.NewAppError("TrackService", err)
 }
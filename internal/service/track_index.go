@@ -0,0 +1,32 @@
+package service
+
+import (
+	"spl-notification/internal/model"
+)
+
+// indexedPerson is the state of a person along with the tracks following it.
+type indexedPerson struct {
+	state  *model.PersonState
+	tracks []*model.Track
+}
+
+// indexTracks groups the tracks by person so accesses are matched with a map
+// lookup instead of scanning every track, and each change is detected once
+// per person whatever the number of followers. The followers of a person are
+// read back with the same shared state.
+func indexTracks(tracks []*model.Track) map[int32]*indexedPerson {
+	byExternalID := make(map[int32]*indexedPerson, len(tracks))
+	for _, track := range tracks {
+		person, ok := byExternalID[track.ExternalID]
+		if !ok {
+			person = &indexedPerson{state: &model.PersonState{
+				ExternalID: track.ExternalID,
+				LastEntry:  track.LastEntry,
+				LastExit:   track.LastExit,
+			}}
+			byExternalID[track.ExternalID] = person
+		}
+		person.tracks = append(person.tracks, track)
+	}
+	return byExternalID
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"spl-notification/internal/dto/request"
+	apperrors "spl-notification/internal/errors"
+	"spl-notification/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTrackService_CreateSeedsCurrentAccess(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	createDTO := &request.CreateTrackDTO{ChatID: "chat1", Run: "12345678-9", ExternalID: 12345}
+	stored := &model.Track{ID: 7, ChatID: "chat1", ExternalID: 12345, Run: "12345678-9", LastEntry: &now}
+
+	mockRepo := new(MockTrackRepository)
+	mockAccessService := new(MockAccessService)
+	mockNotificationService := new(MockNotificationService)
+	auditService := &MockAuditService{}
+	service := NewTrackServiceImpl(mockRepo, nil, mockAccessService, mockNotificationService, auditService, newMockLocationService())
+
+	// The DTO carries the current access
+	mockAccessService.On("GetCompleteAccess").Return([]*model.Access{{ExternalID: 12345, EntryAt: now}}, nil)
+	mockRepo.On("Create", mock.MatchedBy(func(dto *request.CreateTrackDTO) bool {
+		return dto.LastEntry != nil && dto.LastEntry.Equal(now)
+	})).Return(stored, nil)
+	mockNotificationService.On("SendMessage", "chat1", mock.Anything).Return(nil)
+
+	assert.Nil(t, service.Create(context.Background(), createDTO))
+	assert.Equal(t, []string{model.AuditActionTrackCreate}, auditService.actions)
+}
+
+func TestTrackService_CreateWithoutInactivityAlert(t *testing.T) {
+	days := 0
+	createDTO := &request.CreateTrackDTO{ChatID: "chat1", Run: "12345678-9", ExternalID: 12345, InactiveDays: &days}
+	stored := &model.Track{ID: 7, ChatID: "chat1", ExternalID: 12345, Run: "12345678-9"}
+
+	mockRepo := new(MockTrackRepository)
+	mockAccessService := new(MockAccessService)
+	mockNotificationService := new(MockNotificationService)
+	service := NewTrackServiceImpl(mockRepo, nil, mockAccessService, mockNotificationService, &MockAuditService{}, newMockLocationService())
+
+	// Zero days is stored as no alert, the same as updating the filters
+	mockAccessService.On("GetCompleteAccess").Return([]*model.Access{}, nil)
+	mockRepo.On("Create", mock.MatchedBy(func(dto *request.CreateTrackDTO) bool {
+		return dto.InactiveDays == nil
+	})).Return(stored, nil)
+	mockNotificationService.On("SendMessage", "chat1", mock.Anything).Return(nil)
+
+	assert.Nil(t, service.Create(context.Background(), createDTO))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTrackService_CreateDuplicateIsNotAudited(t *testing.T) {
+	createDTO := &request.CreateTrackDTO{ChatID: "chat1", Run: "12345678-9", ExternalID: 12345}
+	duplicate := apperrors.NewAppErrorWithType("TrackRepository", apperrors.TypeConflict, errors.New("track already exists"))
+
+	mockRepo := new(MockTrackRepository)
+	mockAccessService := new(MockAccessService)
+	mockNotificationService := new(MockNotificationService)
+	auditService := &MockAuditService{}
+	service := NewTrackServiceImpl(mockRepo, nil, mockAccessService, mockNotificationService, auditService, newMockLocationService())
+
+	mockAccessService.On("GetCompleteAccess").Return([]*model.Access{}, nil)
+	mockRepo.On("Create", createDTO).Return(nil, duplicate)
+
+	// The active track is left as is: no audit, no message
+	err := service.Create(context.Background(), createDTO)
+	assert.True(t, err.HasType(apperrors.TypeConflict))
+	assert.Empty(t, auditService.actions)
+	mockNotificationService.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything)
+}
+
+func TestTrackService_DeleteAndRestoreAreAudited(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	track := &model.Track{ID: 1, ChatID: "chat1", ExternalID: 12345, Run: "12345678-9"}
+	deleteDTO := &request.DeleteTrackDTO{ChatID: "chat1", Run: "12345678-9"}
+	restoreDTO := &request.RestoreTrackDTO{ChatID: "chat1", Run: "12345678-9"}
+
+	mockRepo := new(MockTrackRepository)
+	mockAccessService := new(MockAccessService)
+	mockNotificationService := new(MockNotificationService)
+	auditService := &MockAuditService{}
+	service := NewTrackServiceImpl(mockRepo, nil, mockAccessService, mockNotificationService, auditService, newMockLocationService())
+
+	// Delete keeps the row, the service records what was removed
+	mockRepo.On("GetTrackByChatIdAndRun", "chat1", "12345678-9").Return(track, nil).Once()
+	mockRepo.On("Delete", deleteDTO).Return(nil)
+	mockNotificationService.On("SendMessage", "chat1", mock.Anything).Return(nil)
+	assert.Nil(t, service.Delete(context.Background(), deleteDTO))
+
+	// Restore seeds the person state from the current accesses
+	mockRepo.On("GetTrackByChatIdAndRun", "chat1", "12345678-9").Return(nil, nil).Once()
+	mockRepo.On("GetDeletedTrack", "chat1", "12345678-9").Return(track, nil)
+	mockAccessService.On("GetCompleteAccess").Return([]*model.Access{{ExternalID: 12345, EntryAt: now}}, nil)
+	mockRepo.On("Restore", mock.MatchedBy(func(restored *model.Track) bool {
+		return restored.LastEntry != nil && restored.LastEntry.Equal(now)
+	})).Return(track, nil)
+
+	restored, err := service.Restore(context.Background(), restoreDTO)
+	assert.Nil(t, err)
+	assert.Equal(t, track, restored)
+	assert.Equal(t, []string{model.AuditActionTrackDelete, model.AuditActionTrackRestore}, auditService.actions)
+
+	// A track that is still active can't be restored
+	mockRepo.On("GetTrackByChatIdAndRun", "chat1", "12345678-9").Return(track, nil).Once()
+	_, err = service.Restore(context.Background(), restoreDTO)
+	assert.True(t, err.HasType(apperrors.TypeConflict))
+}
+
+func TestTrackService_GetVisitHistory(t *testing.T) {
+	mockRepo := new(MockTrackRepository)
+	mockAccessEventRepo := new(MockAccessEventRepository)
+	locationService := new(MockLocationService)
+	service := NewTrackServiceImpl(mockRepo, mockAccessEventRepo, nil, nil, &MockAuditService{}, locationService)
+
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 10, 7, 23, 59, 59, 0, time.UTC)
+	exitAt := time.Date(2025, 10, 2, 9, 30, 0, 0, time.UTC)
+	visits := []*model.Visit{
+		{EntryAt: time.Date(2025, 10, 3, 8, 0, 0, 0, time.UTC), Location: 110},
+		{EntryAt: time.Date(2025, 10, 2, 8, 0, 0, 0, time.UTC), ExitAt: &exitAt, Location: 102},
+	}
+
+	mockRepo.On("GetTrackByChatIdAndRun", "chat1", "12345678-9").Return(&model.Track{ID: 7, ExternalID: 12345}, nil)
+	mockRepo.On("GetTrackByChatIdAndRun", "chat1", "98765432-1").Return(nil, nil)
+	// Page 3 of 10 skips the first 20 visits
+	mockAccessEventRepo.On("GetVisits", 7, &from, &to, 10, 20).Return(visits, 22, nil)
+	locationService.On("Name", int8(102)).Return("Espacio Urbano")
+	locationService.On("Name", int8(110)).Return(model.UnknownLocationName)
+
+	result, total, err := service.GetVisitHistory(context.Background(), &request.VisitHistoryDTO{
+		ChatID: "chat1", Run: "12345678-9", From: &from, To: &to, Page: 3, PageSize: 10,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 22, total)
+	assert.Equal(t, visits, result)
+	assert.Equal(t, model.UnknownLocationName, result[0].LocationName)
+	assert.Equal(t, "Espacio Urbano", result[1].LocationName)
+
+	// A RUN the chat doesn't follow has no history
+	_, _, err = service.GetVisitHistory(context.Background(), &request.VisitHistoryDTO{
+		ChatID: "chat1", Run: "98765432-1", Page: 1, PageSize: 10,
+	})
+	assert.NotNil(t, err)
+	assert.True(t, err.HasType(apperrors.TypeNotFound))
+	mockAccessEventRepo.AssertNumberOfCalls(t, "GetVisits", 1)
+}
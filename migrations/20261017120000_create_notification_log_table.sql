@@ -0,0 +1,24 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    type INTEGER NOT NULL,
+    chat_id VARCHAR(255) NOT NULL,
+    run VARCHAR(50) NOT NULL,
+    full_name VARCHAR(255) NOT NULL,
+    alias VARCHAR(100),
+    location INTEGER NOT NULL,
+    event_date TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chat_id, run, type, event_date)
+);
+
+CREATE INDEX idx_notification_log_chat_id_event_date ON notification_log(chat_id, event_date);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notification_log_chat_id_event_date;
+
+DROP TABLE IF EXISTS notification_log;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS access_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    external_id INTEGER NOT NULL,
+    run VARCHAR(50) NOT NULL,
+    type INTEGER NOT NULL,
+    location INTEGER NOT NULL,
+    event_date TIMESTAMP NOT NULL,
+    entry_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(external_id, type, event_date)
+);
+
+CREATE INDEX idx_access_event_external_id_entry_at ON access_event(external_id, entry_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_access_event_external_id_entry_at;
+
+DROP TABLE IF EXISTS access_event;
@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS chat_preferences (
+    chat_id VARCHAR(255) PRIMARY KEY,
+    notify_mode VARCHAR(10) NOT NULL DEFAULT 'BOTH',
+    quiet_hours_start VARCHAR(5),
+    quiet_hours_end VARCHAR(5),
+    time_zone VARCHAR(64),
+    quiet_mode VARCHAR(10) NOT NULL DEFAULT 'DROP',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS chat_preferences;
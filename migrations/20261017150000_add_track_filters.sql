@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE track ADD COLUMN notify_type VARCHAR(10);
+ALTER TABLE track ADD COLUMN locations TEXT;
+ALTER TABLE track ADD COLUMN schedule TEXT;
+
+-- +goose Down
+ALTER TABLE track DROP COLUMN schedule;
+ALTER TABLE track DROP COLUMN locations;
+ALTER TABLE track DROP COLUMN notify_type;
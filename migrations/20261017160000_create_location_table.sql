@@ -0,0 +1,22 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS location (
+    code INTEGER PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    address VARCHAR(255),
+    time_zone VARCHAR(64),
+    active BOOLEAN NOT NULL DEFAULT 1,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO location (code, name) VALUES
+    (102, 'Espacio Urbano'),
+    (104, 'Calama'),
+    (105, 'Pacífico'),
+    (106, 'Arauco'),
+    (107, 'Iquique'),
+    (108, 'Angamos')
+ON CONFLICT(code) DO NOTHING;
+
+-- +goose Down
+DROP TABLE IF EXISTS location;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE track ADD COLUMN long_stay_hours INTEGER;
+ALTER TABLE track ADD COLUMN long_stay_alerted_entry TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE track DROP COLUMN long_stay_alerted_entry;
+ALTER TABLE track DROP COLUMN long_stay_hours;
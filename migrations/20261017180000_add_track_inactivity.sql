@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE track ADD COLUMN inactive_days INTEGER;
+ALTER TABLE track ADD COLUMN inactive_alerted_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE track DROP COLUMN inactive_alerted_at;
+ALTER TABLE track DROP COLUMN inactive_days;
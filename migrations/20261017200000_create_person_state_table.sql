@@ -0,0 +1,46 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS person_state (
+    external_id INTEGER PRIMARY KEY,
+    last_entry TIMESTAMP,
+    last_exit TIMESTAMP,
+    last_entry_location INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The timestamps were stored with the offset of the source, they are
+-- normalized to UTC like the access events so they compare as strings
+UPDATE track SET
+    last_entry = strftime('%Y-%m-%dT%H:%M:%SZ', last_entry),
+    last_exit = strftime('%Y-%m-%dT%H:%M:%SZ', last_exit),
+    long_stay_alerted_entry = strftime('%Y-%m-%dT%H:%M:%SZ', long_stay_alerted_entry);
+
+-- Followers of the same person were updated together, only the ones that
+-- joined mid-visit may differ, so the latest value is kept
+INSERT INTO person_state (external_id, last_entry, last_exit)
+SELECT external_id, MAX(last_entry), MAX(last_exit)
+FROM track
+GROUP BY external_id;
+
+-- The location of the last entry comes from its access event, when it was
+-- recorded
+UPDATE person_state SET last_entry_location = (
+    SELECT location FROM access_event
+    WHERE access_event.external_id = person_state.external_id
+        AND access_event.type = 1
+        AND access_event.entry_at = person_state.last_entry
+    LIMIT 1
+);
+
+ALTER TABLE track DROP COLUMN last_entry;
+ALTER TABLE track DROP COLUMN last_exit;
+
+-- +goose Down
+ALTER TABLE track ADD COLUMN last_entry TIMESTAMP;
+ALTER TABLE track ADD COLUMN last_exit TIMESTAMP;
+
+UPDATE track SET
+    last_entry = (SELECT last_entry FROM person_state WHERE person_state.external_id = track.external_id),
+    last_exit = (SELECT last_exit FROM person_state WHERE person_state.external_id = track.external_id);
+
+DROP TABLE IF EXISTS person_state;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_delivery (
+    id VARCHAR(64) PRIMARY KEY,
+    delivered_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_notification_delivery_delivered_at ON notification_delivery(delivered_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notification_delivery_delivered_at;
+
+DROP TABLE IF EXISTS notification_delivery;
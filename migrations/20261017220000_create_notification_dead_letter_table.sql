@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS notification_dead_letter (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    notification_id VARCHAR(64) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    last_error TEXT NOT NULL,
+    status_code INTEGER,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    replayed_at TIMESTAMP
+);
+
+CREATE INDEX idx_notification_dead_letter_replayed_at ON notification_dead_letter(replayed_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_notification_dead_letter_replayed_at;
+
+DROP TABLE IF EXISTS notification_dead_letter;
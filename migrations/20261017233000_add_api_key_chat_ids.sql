@@ -0,0 +1,6 @@
+-- +goose Up
+-- Chats the key is restricted to, separated by commas. NULL grants every chat
+ALTER TABLE api_key ADD COLUMN chat_ids TEXT;
+
+-- +goose Down
+ALTER TABLE api_key DROP COLUMN chat_ids;
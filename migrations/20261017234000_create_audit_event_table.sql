@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS audit_event (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor VARCHAR(255) NOT NULL,
+    api_key_id INTEGER,
+    action VARCHAR(50) NOT NULL,
+    chat_id VARCHAR(255),
+    run VARCHAR(50),
+    before_payload TEXT,
+    after_payload TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_event_chat_id_created_at ON audit_event(chat_id, created_at);
+CREATE INDEX idx_audit_event_created_at ON audit_event(created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_event_created_at;
+DROP INDEX IF EXISTS idx_audit_event_chat_id_created_at;
+
+DROP TABLE IF EXISTS audit_event;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Deleted tracks are kept so they can be restored, a track is active while
+-- deleted_at is NULL
+ALTER TABLE track ADD COLUMN deleted_at TIMESTAMP;
+
+-- +goose Down
+DELETE FROM track WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE track DROP COLUMN deleted_at;